@@ -0,0 +1,115 @@
+package config
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	// DefaultSchedulerName is the name of the scheduler used when a profile doesn't set one.
+	DefaultSchedulerName = "volume-scheduler"
+	// MinWeight is the minimum weight a Score plugin can be configured with.
+	MinWeight int32 = 1
+	// MaxWeight is the maximum weight a Score plugin can be configured with.
+	MaxWeight int32 = 100
+	// DefaultPercentageOfPoolsToScore defines the percentage of pools of all pools that once
+	// found feasible, the scheduler stops looking for more pools. A value of 0 means adaptive,
+	// meaning the scheduler figures out a proper default.
+	DefaultPercentageOfPoolsToScore = 0
+	// DefaultMetricsBindAddress is the address the metrics are served on when the
+	// configuration doesn't set one.
+	DefaultMetricsBindAddress = "0.0.0.0:10251"
+)
+
+// VolumeSchedulerConfiguration configures a scheduler.
+type VolumeSchedulerConfiguration struct {
+	// Parallelism defines the amount of parallelism in algorithms for scheduling a volume.
+	// Must be greater than 0.
+	Parallelism int32
+	// PercentageOfPoolsToScore is the percentage of all pools that once found feasible for
+	// running a volume, the scheduler stops its search for more feasible pools in the cluster.
+	// This helps improve scheduler's performance. Scheduler always tries to find at least
+	// "minFeasiblePoolsToFind" feasible pools no matter what the value of this flag is.
+	// If this value is 0, a default percentage (5%--50% based on the size of the cluster) of
+	// the pools will be scored.
+	PercentageOfPoolsToScore int32
+	// MetricsBindAddress is the IP address and port for the metrics server to serve on at
+	// /metrics. An empty address disables the metrics server.
+	MetricsBindAddress string
+	// Profiles are scheduling profiles that the scheduler supports. Volumes can choose to be
+	// scheduled under a particular profile by setting its associated scheduler name.
+	Profiles []VolumeSchedulerProfile
+}
+
+// VolumeSchedulerProfile is a scheduling profile. It describes the set of plugins which are
+// enabled at each extension point and the arguments they are instantiated with.
+type VolumeSchedulerProfile struct {
+	// SchedulerName is the name of the scheduler associated to this profile.
+	SchedulerName string
+	// Plugins specify the set of plugins that should be enabled or disabled.
+	Plugins *Plugins
+	// PluginConfig is an optional set of custom plugin arguments for each plugin. Omitting
+	// config args for a plugin is equivalent to using the default config for that plugin.
+	PluginConfig []PluginConfig
+}
+
+// Plugins include multiple extension points. When specified, the list of plugins for a
+// particular extension point are the only ones enabled.
+type Plugins struct {
+	// QueueSort is a list of plugins that should be invoked when sorting volumes in the
+	// scheduling queue. Exactly one plugin must be enabled, and it must be the same in all
+	// the profiles.
+	QueueSort PluginSet
+
+	// PreFilter is a list of plugins that should be invoked at "PreFilter" extension point.
+	PreFilter PluginSet
+	// Filter is a list of plugins that should be invoked when filtering out pools that cannot
+	// hold the volume.
+	Filter PluginSet
+	// PostFilter is a list of plugins that are invoked after filtering phase, but only when no
+	// feasible pools were found for the volume.
+	PostFilter PluginSet
+	// PreScore is a list of plugins that are invoked before scoring.
+	PreScore PluginSet
+	// Score is a list of plugins that should be invoked when ranking pools that have passed the
+	// filtering phase.
+	Score PluginSet
+	// Reserve is a list of plugins invoked when reserving/unreserving resources after a pool is
+	// assigned to run the volume.
+	Reserve PluginSet
+	// Permit is a list of plugins that control binding of a volume. These plugins can prevent
+	// or delay binding of a volume.
+	Permit PluginSet
+	// PreBind is a list of plugins that should be invoked before a volume is bound.
+	PreBind PluginSet
+	// Bind is a list of plugins that should be invoked at "Bind" extension point of the
+	// scheduling framework. The scheduler calls these plugins in order. The scheduler skips the
+	// rest of these plugins as soon as one returns success.
+	Bind PluginSet
+	// PostBind is a list of plugins that should be invoked after a volume is successfully bound.
+	PostBind PluginSet
+}
+
+// PluginSet specifies the plugins enabled for an extension point, in the order they are called.
+type PluginSet struct {
+	// Enabled specifies plugins that should be enabled.
+	Enabled []Plugin
+}
+
+// Plugin specifies a plugin name and its weight when applicable. Weight is used only for
+// Score plugins.
+type Plugin struct {
+	// Name defines the name of plugin.
+	Name string
+	// Weight defines the weight of plugin, only used for Score plugins.
+	Weight int32
+}
+
+// PluginConfig specifies arguments that should be passed to a plugin at the time of
+// initialization. A plugin that is invoked at multiple extension points is initialized once.
+// Args can have arbitrary structure. It is up to the plugin to process these Args.
+type PluginConfig struct {
+	// Name defines the name of plugin being configured.
+	Name string
+	// Args defines the arguments passed to the plugins at the time of initialization.
+	Args runtime.Object
+}
@@ -0,0 +1,51 @@
+package config
+
+const (
+	// MaxCustomPriorityScore is the max score of a point of a UtilizationShape.
+	MaxCustomPriorityScore int32 = 10
+	// MaxUtilization is the max utilization of a point of a UtilizationShape.
+	MaxUtilization int32 = 100
+)
+
+// PoolResourcesFitArgs holds arguments used to configure the PoolResourcesFit plugin.
+type PoolResourcesFitArgs struct {
+	// ScoringStrategy selects the strategy used to rank the pools by their capacity.
+	ScoringStrategy *ScoringStrategy
+}
+
+// ScoringStrategyType is the type of scoring strategy used by the PoolResourcesFit plugin.
+type ScoringStrategyType string
+
+const (
+	// LeastAllocated strategy favors the pools with the most free capacity, spreading the
+	// volumes over the pools.
+	LeastAllocated ScoringStrategyType = "LeastAllocated"
+	// MostAllocated strategy favors the pools with the least free capacity, bin-packing the
+	// volumes on as few pools as possible.
+	MostAllocated ScoringStrategyType = "MostAllocated"
+	// RequestedToCapacityRatio strategy scores the pools with a piecewise linear function of
+	// their utilization.
+	RequestedToCapacityRatio ScoringStrategyType = "RequestedToCapacityRatio"
+)
+
+// ScoringStrategy defines the strategy used to score the pools.
+type ScoringStrategy struct {
+	// Type selects which strategy to run.
+	Type ScoringStrategyType
+	// RequestedToCapacityRatio holds the arguments of the RequestedToCapacityRatio strategy.
+	RequestedToCapacityRatio *RequestedToCapacityRatioParam
+}
+
+// RequestedToCapacityRatioParam define RequestedToCapacityRatio parameters.
+type RequestedToCapacityRatioParam struct {
+	// Shape is a list of points defining the scoring function shape.
+	Shape []UtilizationShapePoint
+}
+
+// UtilizationShapePoint represents a single point of a priority function shape.
+type UtilizationShapePoint struct {
+	// Utilization (x axis). Valid values are 0 to 100. Fully utilized pool maps to 100.
+	Utilization int32
+	// Score assigned to given utilization (y axis). Valid values are 0 to 10.
+	Score int32
+}
@@ -0,0 +1,91 @@
+package v1alpha1
+
+import (
+	"github.com/shovanmaity/volume-scheduler/apis/config"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Convert_v1alpha1_VolumeSchedulerConfiguration_To_config_VolumeSchedulerConfiguration converts
+// a defaulted versioned configuration into the internal configuration.
+func Convert_v1alpha1_VolumeSchedulerConfiguration_To_config_VolumeSchedulerConfiguration(
+	in *VolumeSchedulerConfiguration, out *config.VolumeSchedulerConfiguration) {
+	if in.Parallelism != nil {
+		out.Parallelism = *in.Parallelism
+	}
+	if in.PercentageOfPoolsToScore != nil {
+		out.PercentageOfPoolsToScore = *in.PercentageOfPoolsToScore
+	}
+	if in.MetricsBindAddress != nil {
+		out.MetricsBindAddress = *in.MetricsBindAddress
+	}
+	out.Profiles = make([]config.VolumeSchedulerProfile, len(in.Profiles))
+	for i := range in.Profiles {
+		convertProfile(&in.Profiles[i], &out.Profiles[i])
+	}
+}
+
+func convertProfile(in *VolumeSchedulerProfile, out *config.VolumeSchedulerProfile) {
+	if in.SchedulerName != nil {
+		out.SchedulerName = *in.SchedulerName
+	}
+	if in.Plugins != nil {
+		out.Plugins = &config.Plugins{
+			QueueSort:  convertPluginSet(in.Plugins.QueueSort),
+			PreFilter:  convertPluginSet(in.Plugins.PreFilter),
+			Filter:     convertPluginSet(in.Plugins.Filter),
+			PostFilter: convertPluginSet(in.Plugins.PostFilter),
+			PreScore:   convertPluginSet(in.Plugins.PreScore),
+			Score:      convertPluginSet(in.Plugins.Score),
+			Reserve:    convertPluginSet(in.Plugins.Reserve),
+			Permit:     convertPluginSet(in.Plugins.Permit),
+			PreBind:    convertPluginSet(in.Plugins.PreBind),
+			Bind:       convertPluginSet(in.Plugins.Bind),
+			PostBind:   convertPluginSet(in.Plugins.PostBind),
+		}
+	}
+	for _, pc := range in.PluginConfig {
+		c := config.PluginConfig{Name: pc.Name}
+		if len(pc.Args.Raw) != 0 {
+			// The plugin decodes its own arguments, see runtime.DecodeInto.
+			c.Args = &runtime.Unknown{
+				Raw:         pc.Args.Raw,
+				ContentType: runtime.ContentTypeJSON,
+			}
+		}
+		out.PluginConfig = append(out.PluginConfig, c)
+	}
+}
+
+func convertPluginSet(in PluginSet) config.PluginSet {
+	var out config.PluginSet
+	for _, p := range in.Enabled {
+		plugin := config.Plugin{Name: p.Name}
+		if p.Weight != nil {
+			plugin.Weight = *p.Weight
+		}
+		out.Enabled = append(out.Enabled, plugin)
+	}
+	return out
+}
+
+// Convert_v1alpha1_PoolResourcesFitArgs_To_config_PoolResourcesFitArgs converts defaulted
+// versioned PoolResourcesFit arguments into the internal ones.
+func Convert_v1alpha1_PoolResourcesFitArgs_To_config_PoolResourcesFitArgs(
+	in *PoolResourcesFitArgs, out *config.PoolResourcesFitArgs) {
+	if in.ScoringStrategy == nil {
+		return
+	}
+	out.ScoringStrategy = &config.ScoringStrategy{
+		Type: config.ScoringStrategyType(in.ScoringStrategy.Type),
+	}
+	if in.ScoringStrategy.RequestedToCapacityRatio != nil {
+		param := &config.RequestedToCapacityRatioParam{}
+		for _, p := range in.ScoringStrategy.RequestedToCapacityRatio.Shape {
+			param.Shape = append(param.Shape, config.UtilizationShapePoint{
+				Utilization: p.Utilization,
+				Score:       p.Score,
+			})
+		}
+		out.ScoringStrategy.RequestedToCapacityRatio = param
+	}
+}
@@ -0,0 +1,41 @@
+package v1alpha1
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/shovanmaity/volume-scheduler/apis/config"
+	"sigs.k8s.io/yaml"
+)
+
+// Kind is the kind of the scheduler configuration file.
+const Kind = "VolumeSchedulerConfiguration"
+
+// LoadConfigFromFile reads the file at the given path and decodes it into the internal
+// configuration.
+func LoadConfigFromFile(file string) (*config.VolumeSchedulerConfiguration, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return Decode(data)
+}
+
+// Decode decodes a YAML or JSON encoded VolumeSchedulerConfiguration, applies the defaults and
+// converts it into the internal configuration. Unknown fields are rejected. The result still
+// needs to be validated before it is used.
+func Decode(data []byte) (*config.VolumeSchedulerConfiguration, error) {
+	versioned := &VolumeSchedulerConfiguration{}
+	if err := yaml.UnmarshalStrict(data, versioned); err != nil {
+		return nil, fmt.Errorf("decoding scheduler configuration: %w", err)
+	}
+	if gvk := versioned.GroupVersionKind(); gvk != SchemeGroupVersion.WithKind(Kind) {
+		return nil, fmt.Errorf("unsupported scheduler configuration %q, expected %q",
+			gvk.String(), SchemeGroupVersion.WithKind(Kind).String())
+	}
+	SetDefaults_VolumeSchedulerConfiguration(versioned)
+
+	cfg := &config.VolumeSchedulerConfiguration{}
+	Convert_v1alpha1_VolumeSchedulerConfiguration_To_config_VolumeSchedulerConfiguration(versioned, cfg)
+	return cfg, nil
+}
@@ -0,0 +1,163 @@
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/shovanmaity/volume-scheduler/apis/config"
+	"github.com/shovanmaity/volume-scheduler/framework/plugins/names"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const header = `apiVersion: volumescheduler.config.openebs.io/v1alpha1
+kind: VolumeSchedulerConfiguration
+`
+
+// defaultPlugins returns the plugins of a profile which doesn't configure any.
+func defaultPlugins() *config.Plugins {
+	in := &VolumeSchedulerProfile{}
+	SetDefaults_VolumeSchedulerProfile(in)
+	var out config.VolumeSchedulerProfile
+	convertProfile(in, &out)
+	return out.Plugins
+}
+
+func TestDecode(t *testing.T) {
+	prioritySort := config.PluginSet{Enabled: []config.Plugin{{Name: names.PrioritySort}}}
+	tests := []struct {
+		name    string
+		data    string
+		want    *config.VolumeSchedulerConfiguration
+		wantErr bool
+	}{
+		{
+			name: "empty configuration gets the default profile",
+			data: header,
+			want: &config.VolumeSchedulerConfiguration{
+				Parallelism:        16,
+				MetricsBindAddress: config.DefaultMetricsBindAddress,
+				Profiles: []config.VolumeSchedulerProfile{{
+					SchedulerName: config.DefaultSchedulerName,
+					Plugins:       defaultPlugins(),
+				}},
+			},
+		},
+		{
+			name: "score weights default to the minimum weight",
+			data: header + `parallelism: 4
+metricsBindAddress: 127.0.0.1:8080
+profiles:
+- schedulerName: fast
+  plugins:
+    filter:
+      enabled:
+      - name: FilterPlugin
+    score:
+      enabled:
+      - name: ScorePlugin1
+      - name: ScorePlugin2
+        weight: 5
+- plugins:
+    bind:
+      enabled:
+      - name: BindPlugin
+`,
+			want: &config.VolumeSchedulerConfiguration{
+				Parallelism:        4,
+				MetricsBindAddress: "127.0.0.1:8080",
+				Profiles: []config.VolumeSchedulerProfile{
+					{
+						SchedulerName: "fast",
+						Plugins: &config.Plugins{
+							QueueSort: prioritySort,
+							Filter:    config.PluginSet{Enabled: []config.Plugin{{Name: "FilterPlugin"}}},
+							Score: config.PluginSet{Enabled: []config.Plugin{
+								{Name: "ScorePlugin1", Weight: config.MinWeight},
+								{Name: "ScorePlugin2", Weight: 5},
+							}},
+						},
+					},
+					{
+						SchedulerName: config.DefaultSchedulerName,
+						Plugins: &config.Plugins{
+							QueueSort: prioritySort,
+							Bind:      config.PluginSet{Enabled: []config.Plugin{{Name: "BindPlugin"}}},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "plugin args are kept for the plugin to decode",
+			data: header + `profiles:
+- pluginConfig:
+  - name: Plugin
+    args:
+      key: value
+  - name: NoArgs
+`,
+			want: &config.VolumeSchedulerConfiguration{
+				Parallelism:        16,
+				MetricsBindAddress: config.DefaultMetricsBindAddress,
+				Profiles: []config.VolumeSchedulerProfile{{
+					SchedulerName: config.DefaultSchedulerName,
+					Plugins:       defaultPlugins(),
+					PluginConfig: []config.PluginConfig{
+						{
+							Name: "Plugin",
+							Args: &runtime.Unknown{
+								Raw:         []byte(`{"key":"value"}`),
+								ContentType: runtime.ContentTypeJSON,
+							},
+						},
+						{Name: "NoArgs"},
+					},
+				}},
+			},
+		},
+		{
+			name: "JSON configuration",
+			data: `{"apiVersion":"volumescheduler.config.openebs.io/v1alpha1",` +
+				`"kind":"VolumeSchedulerConfiguration","profiles":[{"schedulerName":"json"}]}`,
+			want: &config.VolumeSchedulerConfiguration{
+				Parallelism:        16,
+				MetricsBindAddress: config.DefaultMetricsBindAddress,
+				Profiles: []config.VolumeSchedulerProfile{{
+					SchedulerName: "json",
+					Plugins:       defaultPlugins(),
+				}},
+			},
+		},
+		{
+			name:    "unknown field",
+			data:    header + "percentage: 10\n",
+			wantErr: true,
+		},
+		{
+			name:    "wrong kind",
+			data:    "apiVersion: volumescheduler.config.openebs.io/v1alpha1\nkind: Other\n",
+			wantErr: true,
+		},
+		{
+			name:    "wrong version",
+			data:    "apiVersion: volumescheduler.config.openebs.io/v1\nkind: VolumeSchedulerConfiguration\n",
+			wantErr: true,
+		},
+		{
+			name:    "malformed document",
+			data:    header + "profiles: {",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decode([]byte(tt.data))
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("unexpected configuration (-want, +got): %s", diff)
+			}
+		})
+	}
+}
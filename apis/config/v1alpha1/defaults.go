@@ -0,0 +1,128 @@
+package v1alpha1
+
+import (
+	"github.com/shovanmaity/volume-scheduler/apis/config"
+	"github.com/shovanmaity/volume-scheduler/framework/parallelize"
+	"github.com/shovanmaity/volume-scheduler/framework/plugins/names"
+)
+
+// getDefaultPlugins returns the default set of plugins, used by the profiles which don't
+// configure any plugin.
+func getDefaultPlugins() *Plugins {
+	return &Plugins{
+		QueueSort: PluginSet{
+			Enabled: []Plugin{
+				{Name: names.PrioritySort},
+			},
+		},
+		PreFilter: PluginSet{
+			Enabled: []Plugin{
+				{Name: names.PoolCapacityFit},
+				{Name: names.VolumeAffinity},
+				{Name: names.CohortTopologySpread},
+			},
+		},
+		Filter: PluginSet{
+			Enabled: []Plugin{
+				{Name: names.PoolSelector},
+				{Name: names.TaintToleration},
+				{Name: names.PoolCapacityFit},
+				{Name: names.CohortAffinity},
+				{Name: names.VolumeAffinity},
+				{Name: names.CohortTopologySpread},
+			},
+		},
+		PostFilter: PluginSet{
+			Enabled: []Plugin{
+				{Name: names.DefaultPreemption},
+			},
+		},
+		PreScore: PluginSet{
+			Enabled: []Plugin{
+				{Name: names.TaintToleration},
+				{Name: names.CohortAffinity},
+				{Name: names.VolumeAffinity},
+				{Name: names.CohortTopologySpread},
+			},
+		},
+		Score: PluginSet{
+			Enabled: []Plugin{
+				{Name: names.PoolResourcesFit},
+				{Name: names.TaintToleration},
+				{Name: names.CohortAffinity},
+				{Name: names.VolumeAffinity},
+				{Name: names.CohortTopologySpread},
+			},
+		},
+		Bind: PluginSet{
+			Enabled: []Plugin{
+				{Name: names.DefaultBinder},
+			},
+		},
+	}
+}
+
+// SetDefaults_VolumeSchedulerConfiguration sets additional defaults.
+func SetDefaults_VolumeSchedulerConfiguration(obj *VolumeSchedulerConfiguration) {
+	if obj.Parallelism == nil {
+		p := int32(parallelize.DefaultParallelism)
+		obj.Parallelism = &p
+	}
+
+	if obj.PercentageOfPoolsToScore == nil {
+		percentageOfPoolsToScore := int32(config.DefaultPercentageOfPoolsToScore)
+		obj.PercentageOfPoolsToScore = &percentageOfPoolsToScore
+	}
+
+	if obj.MetricsBindAddress == nil {
+		metricsBindAddress := config.DefaultMetricsBindAddress
+		obj.MetricsBindAddress = &metricsBindAddress
+	}
+
+	if len(obj.Profiles) == 0 {
+		obj.Profiles = append(obj.Profiles, VolumeSchedulerProfile{})
+	}
+	for i := range obj.Profiles {
+		SetDefaults_VolumeSchedulerProfile(&obj.Profiles[i])
+	}
+}
+
+// SetDefaults_VolumeSchedulerProfile sets the default scheduler name and the default weight of
+// the enabled Score plugins.
+func SetDefaults_VolumeSchedulerProfile(obj *VolumeSchedulerProfile) {
+	if obj.SchedulerName == nil || len(*obj.SchedulerName) == 0 {
+		name := config.DefaultSchedulerName
+		obj.SchedulerName = &name
+	}
+	if obj.Plugins == nil {
+		obj.Plugins = getDefaultPlugins()
+	}
+	if len(obj.Plugins.QueueSort.Enabled) == 0 {
+		obj.Plugins.QueueSort = getDefaultPlugins().QueueSort
+	}
+	for i := range obj.Plugins.Score.Enabled {
+		if obj.Plugins.Score.Enabled[i].Weight == nil {
+			weight := config.MinWeight
+			obj.Plugins.Score.Enabled[i].Weight = &weight
+		}
+	}
+}
+
+// SetDefaults_PoolResourcesFitArgs sets the LeastAllocated scoring strategy when none is set,
+// and the default shape of the RequestedToCapacityRatio strategy.
+func SetDefaults_PoolResourcesFitArgs(obj *PoolResourcesFitArgs) {
+	if obj.ScoringStrategy == nil {
+		obj.ScoringStrategy = &ScoringStrategy{}
+	}
+	if len(obj.ScoringStrategy.Type) == 0 {
+		obj.ScoringStrategy.Type = LeastAllocated
+	}
+	if obj.ScoringStrategy.Type == RequestedToCapacityRatio && obj.ScoringStrategy.RequestedToCapacityRatio == nil {
+		obj.ScoringStrategy.RequestedToCapacityRatio = &RequestedToCapacityRatioParam{
+			Shape: []UtilizationShapePoint{
+				{Utilization: 0, Score: config.MaxCustomPriorityScore},
+				{Utilization: config.MaxUtilization, Score: 0},
+			},
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/shovanmaity/volume-scheduler/apis/config"
+	"github.com/shovanmaity/volume-scheduler/framework/plugins/names"
+)
+
+func TestSetDefaultsVolumeSchedulerProfile(t *testing.T) {
+	name, weight := "custom", int32(7)
+	prioritySort := PluginSet{Enabled: []Plugin{{Name: names.PrioritySort}}}
+	defaultPlugins := getDefaultPlugins()
+	for i := range defaultPlugins.Score.Enabled {
+		defaultPlugins.Score.Enabled[i].Weight = int32Ptr(config.MinWeight)
+	}
+	tests := []struct {
+		name string
+		in   *VolumeSchedulerProfile
+		want *VolumeSchedulerProfile
+	}{
+		{
+			name: "scheduler name and default plugins",
+			in:   &VolumeSchedulerProfile{SchedulerName: new(string)},
+			want: &VolumeSchedulerProfile{
+				SchedulerName: stringPtr(config.DefaultSchedulerName),
+				Plugins:       defaultPlugins,
+			},
+		},
+		{
+			name: "set values are kept",
+			in: &VolumeSchedulerProfile{
+				SchedulerName: &name,
+				Plugins: &Plugins{
+					QueueSort: PluginSet{Enabled: []Plugin{{Name: "QueueSortPlugin"}}},
+					Score:     PluginSet{Enabled: []Plugin{{Name: "ScorePlugin", Weight: &weight}}},
+				},
+			},
+			want: &VolumeSchedulerProfile{
+				SchedulerName: stringPtr("custom"),
+				Plugins: &Plugins{
+					QueueSort: PluginSet{Enabled: []Plugin{{Name: "QueueSortPlugin"}}},
+					Score:     PluginSet{Enabled: []Plugin{{Name: "ScorePlugin", Weight: int32Ptr(7)}}},
+				},
+			},
+		},
+		{
+			name: "only score plugins get a weight",
+			in: &VolumeSchedulerProfile{
+				Plugins: &Plugins{
+					Filter: PluginSet{Enabled: []Plugin{{Name: "FilterPlugin"}}},
+					Score:  PluginSet{Enabled: []Plugin{{Name: "ScorePlugin"}}},
+				},
+			},
+			want: &VolumeSchedulerProfile{
+				SchedulerName: stringPtr(config.DefaultSchedulerName),
+				Plugins: &Plugins{
+					QueueSort: prioritySort,
+					Filter:    PluginSet{Enabled: []Plugin{{Name: "FilterPlugin"}}},
+					Score:     PluginSet{Enabled: []Plugin{{Name: "ScorePlugin", Weight: int32Ptr(config.MinWeight)}}},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetDefaults_VolumeSchedulerProfile(tt.in)
+			if diff := cmp.Diff(tt.want, tt.in); diff != "" {
+				t.Errorf("unexpected profile (-want, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestSetDefaultsPoolResourcesFitArgs(t *testing.T) {
+	defaultShape := []UtilizationShapePoint{
+		{Utilization: 0, Score: config.MaxCustomPriorityScore},
+		{Utilization: config.MaxUtilization, Score: 0},
+	}
+	customShape := []UtilizationShapePoint{{Utilization: 50, Score: 5}}
+	tests := []struct {
+		name string
+		in   *PoolResourcesFitArgs
+		want *PoolResourcesFitArgs
+	}{
+		{
+			name: "empty args",
+			in:   &PoolResourcesFitArgs{},
+			want: &PoolResourcesFitArgs{ScoringStrategy: &ScoringStrategy{Type: LeastAllocated}},
+		},
+		{
+			name: "strategy without type",
+			in:   &PoolResourcesFitArgs{ScoringStrategy: &ScoringStrategy{}},
+			want: &PoolResourcesFitArgs{ScoringStrategy: &ScoringStrategy{Type: LeastAllocated}},
+		},
+		{
+			name: "set type is kept",
+			in:   &PoolResourcesFitArgs{ScoringStrategy: &ScoringStrategy{Type: MostAllocated}},
+			want: &PoolResourcesFitArgs{ScoringStrategy: &ScoringStrategy{Type: MostAllocated}},
+		},
+		{
+			name: "default shape of the RequestedToCapacityRatio strategy",
+			in:   &PoolResourcesFitArgs{ScoringStrategy: &ScoringStrategy{Type: RequestedToCapacityRatio}},
+			want: &PoolResourcesFitArgs{ScoringStrategy: &ScoringStrategy{
+				Type:                     RequestedToCapacityRatio,
+				RequestedToCapacityRatio: &RequestedToCapacityRatioParam{Shape: defaultShape},
+			}},
+		},
+		{
+			name: "set shape is kept",
+			in: &PoolResourcesFitArgs{ScoringStrategy: &ScoringStrategy{
+				Type:                     RequestedToCapacityRatio,
+				RequestedToCapacityRatio: &RequestedToCapacityRatioParam{Shape: customShape},
+			}},
+			want: &PoolResourcesFitArgs{ScoringStrategy: &ScoringStrategy{
+				Type:                     RequestedToCapacityRatio,
+				RequestedToCapacityRatio: &RequestedToCapacityRatioParam{Shape: customShape},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetDefaults_PoolResourcesFitArgs(tt.in)
+			if diff := cmp.Diff(tt.want, tt.in); diff != "" {
+				t.Errorf("unexpected args (-want, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func stringPtr(s string) *string { return &s }
+
+func int32Ptr(i int32) *int32 { return &i }
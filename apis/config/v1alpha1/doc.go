@@ -0,0 +1,4 @@
+// Package v1alpha1 contains the versioned VolumeSchedulerConfiguration API, i.e. the format of
+// the file the scheduler is configured from, together with its defaulting and its conversion to
+// the internal config types.
+package v1alpha1
@@ -0,0 +1,111 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// GroupName is the group name used in this package.
+const GroupName = "volumescheduler.config.openebs.io"
+
+// SchemeGroupVersion is group version used to register these objects.
+var SchemeGroupVersion = schema.GroupVersion{Group: GroupName, Version: "v1alpha1"}
+
+// VolumeSchedulerConfiguration configures a scheduler.
+type VolumeSchedulerConfiguration struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// Parallelism defines the amount of parallelism in algorithms for scheduling a volume.
+	// Must be greater than 0. Defaults to 16.
+	Parallelism *int32 `json:"parallelism,omitempty"`
+
+	// PercentageOfPoolsToScore is the percentage of all pools that once found feasible for
+	// running a volume, the scheduler stops its search for more feasible pools in the cluster.
+	// If this value is 0, a default percentage (5%--50% based on the size of the cluster) of
+	// the pools will be scored. Defaults to 0.
+	PercentageOfPoolsToScore *int32 `json:"percentageOfPoolsToScore,omitempty"`
+
+	// MetricsBindAddress is the IP address and port for the metrics server to serve on at
+	// /metrics. An empty address disables the metrics server. Defaults to 0.0.0.0:10251.
+	MetricsBindAddress *string `json:"metricsBindAddress,omitempty"`
+
+	// Profiles are scheduling profiles that the scheduler supports. Volumes can choose to be
+	// scheduled under a particular profile by setting its associated scheduler name. If no
+	// profile is given a single profile with the default scheduler name is used.
+	Profiles []VolumeSchedulerProfile `json:"profiles,omitempty"`
+}
+
+// VolumeSchedulerProfile is a scheduling profile.
+type VolumeSchedulerProfile struct {
+	// SchedulerName is the name of the scheduler associated to this profile. Defaults to
+	// "volume-scheduler".
+	SchedulerName *string `json:"schedulerName,omitempty"`
+
+	// Plugins specify the set of plugins that should be enabled at each extension point,
+	// in the order they are called.
+	Plugins *Plugins `json:"plugins,omitempty"`
+
+	// PluginConfig is an optional set of custom plugin arguments for each plugin. Omitting
+	// config args for a plugin is equivalent to using the default config for that plugin.
+	PluginConfig []PluginConfig `json:"pluginConfig,omitempty"`
+}
+
+// Plugins include multiple extension points.
+type Plugins struct {
+	// QueueSort is a list of plugins that should be invoked when sorting volumes in the
+	// scheduling queue. Exactly one plugin must be enabled, and it must be the same in all
+	// the profiles.
+	QueueSort PluginSet `json:"queueSort,omitempty"`
+
+	// PreFilter is a list of plugins that should be invoked at "PreFilter" extension point.
+	PreFilter PluginSet `json:"preFilter,omitempty"`
+	// Filter is a list of plugins that should be invoked when filtering out pools that cannot
+	// hold the volume.
+	Filter PluginSet `json:"filter,omitempty"`
+	// PostFilter is a list of plugins that are invoked after filtering phase, but only when no
+	// feasible pools were found for the volume.
+	PostFilter PluginSet `json:"postFilter,omitempty"`
+	// PreScore is a list of plugins that are invoked before scoring.
+	PreScore PluginSet `json:"preScore,omitempty"`
+	// Score is a list of plugins that should be invoked when ranking pools that have passed the
+	// filtering phase.
+	Score PluginSet `json:"score,omitempty"`
+	// Reserve is a list of plugins invoked when reserving/unreserving resources after a pool is
+	// assigned to run the volume.
+	Reserve PluginSet `json:"reserve,omitempty"`
+	// Permit is a list of plugins that control binding of a volume. These plugins can prevent
+	// or delay binding of a volume.
+	Permit PluginSet `json:"permit,omitempty"`
+	// PreBind is a list of plugins that should be invoked before a volume is bound.
+	PreBind PluginSet `json:"preBind,omitempty"`
+	// Bind is a list of plugins that should be invoked at "Bind" extension point.
+	Bind PluginSet `json:"bind,omitempty"`
+	// PostBind is a list of plugins that should be invoked after a volume is successfully bound.
+	PostBind PluginSet `json:"postBind,omitempty"`
+}
+
+// PluginSet specifies the plugins enabled for an extension point, in the order they are called.
+type PluginSet struct {
+	// Enabled specifies plugins that should be enabled.
+	Enabled []Plugin `json:"enabled,omitempty"`
+}
+
+// Plugin specifies a plugin name and its weight when applicable. Weight is used only for
+// Score plugins.
+type Plugin struct {
+	// Name defines the name of plugin.
+	Name string `json:"name"`
+	// Weight defines the weight of plugin, only used for Score plugins. Defaults to 1.
+	Weight *int32 `json:"weight,omitempty"`
+}
+
+// PluginConfig specifies arguments that should be passed to a plugin at the time of
+// initialization.
+type PluginConfig struct {
+	// Name defines the name of plugin being configured.
+	Name string `json:"name"`
+	// Args defines the arguments passed to the plugins at the time of initialization. Args can
+	// have arbitrary structure.
+	Args runtime.RawExtension `json:"args,omitempty"`
+}
@@ -0,0 +1,44 @@
+package v1alpha1
+
+// PoolResourcesFitArgs holds arguments used to configure the PoolResourcesFit plugin.
+type PoolResourcesFitArgs struct {
+	// ScoringStrategy selects the strategy used to rank the pools by their capacity. Defaults
+	// to the LeastAllocated strategy.
+	ScoringStrategy *ScoringStrategy `json:"scoringStrategy,omitempty"`
+}
+
+// ScoringStrategyType is the type of scoring strategy used by the PoolResourcesFit plugin.
+type ScoringStrategyType string
+
+const (
+	// LeastAllocated strategy favors the pools with the most free capacity.
+	LeastAllocated ScoringStrategyType = "LeastAllocated"
+	// MostAllocated strategy favors the pools with the least free capacity.
+	MostAllocated ScoringStrategyType = "MostAllocated"
+	// RequestedToCapacityRatio strategy scores the pools with a piecewise linear function of
+	// their utilization.
+	RequestedToCapacityRatio ScoringStrategyType = "RequestedToCapacityRatio"
+)
+
+// ScoringStrategy defines the strategy used to score the pools.
+type ScoringStrategy struct {
+	// Type selects which strategy to run. Defaults to LeastAllocated.
+	Type ScoringStrategyType `json:"type,omitempty"`
+	// RequestedToCapacityRatio holds the arguments of the RequestedToCapacityRatio strategy.
+	// Defaults to a shape scoring the empty pools highest.
+	RequestedToCapacityRatio *RequestedToCapacityRatioParam `json:"requestedToCapacityRatio,omitempty"`
+}
+
+// RequestedToCapacityRatioParam define RequestedToCapacityRatio parameters.
+type RequestedToCapacityRatioParam struct {
+	// Shape is a list of points defining the scoring function shape.
+	Shape []UtilizationShapePoint `json:"shape,omitempty"`
+}
+
+// UtilizationShapePoint represents a single point of a priority function shape.
+type UtilizationShapePoint struct {
+	// Utilization (x axis). Valid values are 0 to 100. Fully utilized pool maps to 100.
+	Utilization int32 `json:"utilization"`
+	// Score assigned to given utilization (y axis). Valid values are 0 to 10.
+	Score int32 `json:"score"`
+}
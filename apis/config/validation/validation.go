@@ -0,0 +1,192 @@
+package validation
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"strconv"
+
+	"github.com/shovanmaity/volume-scheduler/apis/config"
+	"k8s.io/apimachinery/pkg/util/sets"
+	utilvalidation "k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// ValidateVolumeSchedulerConfiguration ensures validation of the VolumeSchedulerConfiguration
+// struct. knownPlugins is the set of plugin names present in the registry the profiles are
+// going to be built from.
+func ValidateVolumeSchedulerConfiguration(cc *config.VolumeSchedulerConfiguration,
+	knownPlugins sets.String) error {
+	var errs field.ErrorList
+	if cc.Parallelism <= 0 {
+		errs = append(errs, field.Invalid(field.NewPath("parallelism"), cc.Parallelism,
+			"should be an integer value greater than zero"))
+	}
+	if cc.PercentageOfPoolsToScore < 0 || cc.PercentageOfPoolsToScore > 100 {
+		errs = append(errs, field.Invalid(field.NewPath("percentageOfPoolsToScore"),
+			cc.PercentageOfPoolsToScore, "not in valid range [0-100]"))
+	}
+	if len(cc.MetricsBindAddress) != 0 {
+		errs = append(errs, validateHostPort(field.NewPath("metricsBindAddress"), cc.MetricsBindAddress)...)
+	}
+
+	profilesPath := field.NewPath("profiles")
+	if len(cc.Profiles) == 0 {
+		errs = append(errs, field.Required(profilesPath, ""))
+	}
+	existingProfiles := make(map[string]int, len(cc.Profiles))
+	for i := range cc.Profiles {
+		profile := &cc.Profiles[i]
+		path := profilesPath.Index(i)
+		errs = append(errs, validateVolumeSchedulerProfile(path, profile, knownPlugins)...)
+		if idx, ok := existingProfiles[profile.SchedulerName]; ok {
+			errs = append(errs, field.Duplicate(path.Child("schedulerName"),
+				profilesPath.Index(idx).Child("schedulerName")))
+		}
+		existingProfiles[profile.SchedulerName] = i
+	}
+	errs = append(errs, validateCommonQueueSort(profilesPath, cc.Profiles)...)
+	return errs.ToAggregate()
+}
+
+// validateCommonQueueSort checks that all the profiles enable the same queue sort plugin, as
+// they share a single scheduling queue.
+func validateCommonQueueSort(path *field.Path, profiles []config.VolumeSchedulerProfile) field.ErrorList {
+	var errs field.ErrorList
+	if len(profiles) == 0 {
+		return errs
+	}
+	var canQueueSort config.PluginSet
+	if profiles[0].Plugins != nil {
+		canQueueSort = profiles[0].Plugins.QueueSort
+	}
+	for i := 1; i < len(profiles); i++ {
+		var curQueueSort config.PluginSet
+		if profiles[i].Plugins != nil {
+			curQueueSort = profiles[i].Plugins.QueueSort
+		}
+		if !reflect.DeepEqual(canQueueSort, curQueueSort) {
+			errs = append(errs, field.Invalid(path.Index(i).Child("plugins", "queueSort"),
+				curQueueSort, "has to match for all profiles"))
+		}
+	}
+	return errs
+}
+
+func validateVolumeSchedulerProfile(path *field.Path, profile *config.VolumeSchedulerProfile,
+	knownPlugins sets.String) field.ErrorList {
+	var errs field.ErrorList
+	if len(profile.SchedulerName) == 0 {
+		errs = append(errs, field.Required(path.Child("schedulerName"), ""))
+	}
+
+	enabled := sets.NewString()
+	if profile.Plugins != nil {
+		pluginsPath := path.Child("plugins")
+		for _, e := range extensionPoints(profile.Plugins) {
+			setPath := pluginsPath.Child(e.name, "enabled")
+			errs = append(errs, validatePluginSet(setPath, e.set, knownPlugins)...)
+			for _, p := range e.set.Enabled {
+				enabled.Insert(p.Name)
+			}
+		}
+		errs = append(errs, validateScoreWeights(pluginsPath.Child("score", "enabled"),
+			profile.Plugins.Score)...)
+	}
+	if profile.Plugins == nil || len(profile.Plugins.QueueSort.Enabled) != 1 {
+		errs = append(errs, field.Invalid(path.Child("plugins", "queueSort", "enabled"),
+			queueSortCount(profile.Plugins), "exactly one queue sort plugin must be enabled"))
+	}
+
+	configPath := path.Child("pluginConfig")
+	seenConfigs := make(map[string]int, len(profile.PluginConfig))
+	for i, pc := range profile.PluginConfig {
+		namePath := configPath.Index(i).Child("name")
+		if idx, ok := seenConfigs[pc.Name]; ok {
+			errs = append(errs, field.Duplicate(namePath, configPath.Index(idx).Child("name")))
+		}
+		seenConfigs[pc.Name] = i
+		if !enabled.Has(pc.Name) {
+			errs = append(errs, field.Invalid(namePath, pc.Name,
+				"configuration for a plugin that is not enabled at any extension point"))
+		}
+	}
+	return errs
+}
+
+func validatePluginSet(path *field.Path, set config.PluginSet, knownPlugins sets.String) field.ErrorList {
+	var errs field.ErrorList
+	seen := make(map[string]int, len(set.Enabled))
+	for i, p := range set.Enabled {
+		namePath := path.Index(i).Child("name")
+		if len(p.Name) == 0 {
+			errs = append(errs, field.Required(namePath, ""))
+			continue
+		}
+		if !knownPlugins.Has(p.Name) {
+			errs = append(errs, field.NotSupported(namePath, p.Name, knownPlugins.List()))
+		}
+		if idx, ok := seen[p.Name]; ok {
+			errs = append(errs, field.Duplicate(namePath, path.Index(idx).Child("name")))
+		}
+		seen[p.Name] = i
+	}
+	return errs
+}
+
+func validateScoreWeights(path *field.Path, set config.PluginSet) field.ErrorList {
+	var errs field.ErrorList
+	for i, p := range set.Enabled {
+		if p.Weight < config.MinWeight || p.Weight > config.MaxWeight {
+			errs = append(errs, field.Invalid(path.Index(i).Child("weight"), p.Weight,
+				fmt.Sprintf("should be in the range [%d, %d]", config.MinWeight, config.MaxWeight)))
+		}
+	}
+	return errs
+}
+
+func queueSortCount(plugins *config.Plugins) int {
+	if plugins == nil {
+		return 0
+	}
+	return len(plugins.QueueSort.Enabled)
+}
+
+type extensionPoint struct {
+	name string
+	set  config.PluginSet
+}
+
+func extensionPoints(plugins *config.Plugins) []extensionPoint {
+	return []extensionPoint{
+		{"queueSort", plugins.QueueSort},
+		{"preFilter", plugins.PreFilter},
+		{"filter", plugins.Filter},
+		{"postFilter", plugins.PostFilter},
+		{"preScore", plugins.PreScore},
+		{"score", plugins.Score},
+		{"reserve", plugins.Reserve},
+		{"permit", plugins.Permit},
+		{"preBind", plugins.PreBind},
+		{"bind", plugins.Bind},
+		{"postBind", plugins.PostBind},
+	}
+}
+
+// validateHostPort checks that the address is a host:port pair with a valid IP and port.
+func validateHostPort(path *field.Path, address string) field.ErrorList {
+	var errs field.ErrorList
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return append(errs, field.Invalid(path, address, "must be IP:port"))
+	}
+	if ip := net.ParseIP(host); ip == nil {
+		errs = append(errs, field.Invalid(path, address, "must be a valid IP"))
+	}
+	if p, err := strconv.Atoi(port); err != nil {
+		errs = append(errs, field.Invalid(path, address, "must be a valid port"))
+	} else if p < 1 || p > 65535 {
+		errs = append(errs, field.Invalid(path, address, utilvalidation.InclusiveRangeError(1, 65535)))
+	}
+	return errs
+}
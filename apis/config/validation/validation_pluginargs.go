@@ -0,0 +1,62 @@
+package validation
+
+import (
+	"fmt"
+
+	"github.com/shovanmaity/volume-scheduler/apis/config"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+var supportedScoringStrategyTypes = []string{
+	string(config.LeastAllocated),
+	string(config.MostAllocated),
+	string(config.RequestedToCapacityRatio),
+}
+
+// ValidatePoolResourcesFitArgs validates the arguments of the PoolResourcesFit plugin.
+func ValidatePoolResourcesFitArgs(path *field.Path, args *config.PoolResourcesFitArgs) error {
+	var errs field.ErrorList
+	strategyPath := path.Child("scoringStrategy")
+	if args.ScoringStrategy == nil {
+		return field.ErrorList{field.Required(strategyPath, "")}.ToAggregate()
+	}
+	switch args.ScoringStrategy.Type {
+	case config.LeastAllocated, config.MostAllocated:
+	case config.RequestedToCapacityRatio:
+		paramPath := strategyPath.Child("requestedToCapacityRatio")
+		if args.ScoringStrategy.RequestedToCapacityRatio == nil {
+			errs = append(errs, field.Required(paramPath, ""))
+		} else {
+			errs = append(errs, validateFunctionShape(paramPath.Child("shape"),
+				args.ScoringStrategy.RequestedToCapacityRatio.Shape)...)
+		}
+	default:
+		errs = append(errs, field.NotSupported(strategyPath.Child("type"), args.ScoringStrategy.Type,
+			supportedScoringStrategyTypes))
+	}
+	return errs.ToAggregate()
+}
+
+// validateFunctionShape checks that the shape has at least one point, that the utilizations
+// are increasing and that all the values are in range.
+func validateFunctionShape(path *field.Path, shape []config.UtilizationShapePoint) field.ErrorList {
+	var errs field.ErrorList
+	if len(shape) == 0 {
+		return append(errs, field.Required(path, "at least one point must be specified"))
+	}
+	for i, point := range shape {
+		if point.Utilization < 0 || point.Utilization > config.MaxUtilization {
+			errs = append(errs, field.Invalid(path.Index(i).Child("utilization"), point.Utilization,
+				fmt.Sprintf("not in valid range [0, %d]", config.MaxUtilization)))
+		}
+		if i > 0 && point.Utilization <= shape[i-1].Utilization {
+			errs = append(errs, field.Invalid(path.Index(i).Child("utilization"), point.Utilization,
+				"utilization values must be sorted in increasing order"))
+		}
+		if point.Score < 0 || point.Score > config.MaxCustomPriorityScore {
+			errs = append(errs, field.Invalid(path.Index(i).Child("score"), point.Score,
+				fmt.Sprintf("not in valid range [0, %d]", config.MaxCustomPriorityScore)))
+		}
+	}
+	return errs
+}
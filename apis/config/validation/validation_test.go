@@ -0,0 +1,171 @@
+package validation
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/shovanmaity/volume-scheduler/apis/config"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func TestValidateVolumeSchedulerConfiguration(t *testing.T) {
+	knownPlugins := sets.NewString("QueueSortPlugin", "FilterPlugin", "ScorePlugin", "BindPlugin")
+	valid := func() *config.VolumeSchedulerConfiguration {
+		return &config.VolumeSchedulerConfiguration{
+			Parallelism: 16,
+			Profiles: []config.VolumeSchedulerProfile{{
+				SchedulerName: config.DefaultSchedulerName,
+				Plugins: &config.Plugins{
+					QueueSort: config.PluginSet{Enabled: []config.Plugin{{Name: "QueueSortPlugin"}}},
+					Filter:    config.PluginSet{Enabled: []config.Plugin{{Name: "FilterPlugin"}}},
+					Score:     config.PluginSet{Enabled: []config.Plugin{{Name: "ScorePlugin", Weight: 1}}},
+					Bind:      config.PluginSet{Enabled: []config.Plugin{{Name: "BindPlugin"}}},
+				},
+				PluginConfig: []config.PluginConfig{{Name: "ScorePlugin"}},
+			}},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		update func(cfg *config.VolumeSchedulerConfiguration)
+		// wantErr is a part of the expected error, empty if the configuration is valid.
+		wantErr string
+	}{
+		{
+			name:   "valid",
+			update: func(cfg *config.VolumeSchedulerConfiguration) {},
+		},
+		{
+			name: "maximum score weight",
+			update: func(cfg *config.VolumeSchedulerConfiguration) {
+				cfg.Profiles[0].Plugins.Score.Enabled[0].Weight = config.MaxWeight
+			},
+		},
+		{
+			name: "score weight below the minimum",
+			update: func(cfg *config.VolumeSchedulerConfiguration) {
+				cfg.Profiles[0].Plugins.Score.Enabled[0].Weight = 0
+			},
+			wantErr: "profiles[0].plugins.score.enabled[0].weight",
+		},
+		{
+			name: "score weight above the maximum",
+			update: func(cfg *config.VolumeSchedulerConfiguration) {
+				cfg.Profiles[0].Plugins.Score.Enabled[0].Weight = config.MaxWeight + 1
+			},
+			wantErr: "profiles[0].plugins.score.enabled[0].weight",
+		},
+		{
+			name: "parallelism not positive",
+			update: func(cfg *config.VolumeSchedulerConfiguration) {
+				cfg.Parallelism = 0
+			},
+			wantErr: "parallelism",
+		},
+		{
+			name: "no profile",
+			update: func(cfg *config.VolumeSchedulerConfiguration) {
+				cfg.Profiles = nil
+			},
+			wantErr: "profiles: Required value",
+		},
+		{
+			name: "duplicate scheduler name",
+			update: func(cfg *config.VolumeSchedulerConfiguration) {
+				cfg.Profiles = append(cfg.Profiles, config.VolumeSchedulerProfile{
+					SchedulerName: config.DefaultSchedulerName,
+					Plugins:       cfg.Profiles[0].Plugins,
+				})
+			},
+			wantErr: "profiles[1].schedulerName: Duplicate value",
+		},
+		{
+			name: "no queue sort plugin",
+			update: func(cfg *config.VolumeSchedulerConfiguration) {
+				cfg.Profiles[0].Plugins.QueueSort = config.PluginSet{}
+			},
+			wantErr: "profiles[0].plugins.queueSort.enabled: Invalid value",
+		},
+		{
+			name: "two queue sort plugins",
+			update: func(cfg *config.VolumeSchedulerConfiguration) {
+				queueSort := &cfg.Profiles[0].Plugins.QueueSort
+				queueSort.Enabled = append(queueSort.Enabled, config.Plugin{Name: "FilterPlugin"})
+			},
+			wantErr: "profiles[0].plugins.queueSort.enabled: Invalid value",
+		},
+		{
+			name: "profiles with different queue sort plugins",
+			update: func(cfg *config.VolumeSchedulerConfiguration) {
+				cfg.Profiles = append(cfg.Profiles, config.VolumeSchedulerProfile{
+					SchedulerName: "other",
+					Plugins: &config.Plugins{
+						QueueSort: config.PluginSet{Enabled: []config.Plugin{{Name: "FilterPlugin"}}},
+					},
+				})
+			},
+			wantErr: "profiles[1].plugins.queueSort: Invalid value",
+		},
+		{
+			name: "empty scheduler name",
+			update: func(cfg *config.VolumeSchedulerConfiguration) {
+				cfg.Profiles[0].SchedulerName = ""
+			},
+			wantErr: "profiles[0].schedulerName: Required value",
+		},
+		{
+			name: "unknown plugin",
+			update: func(cfg *config.VolumeSchedulerConfiguration) {
+				cfg.Profiles[0].Plugins.Filter.Enabled[0].Name = "UnknownPlugin"
+			},
+			wantErr: "profiles[0].plugins.filter.enabled[0].name: Unsupported value",
+		},
+		{
+			name: "plugin enabled twice at an extension point",
+			update: func(cfg *config.VolumeSchedulerConfiguration) {
+				filter := &cfg.Profiles[0].Plugins.Filter
+				filter.Enabled = append(filter.Enabled, config.Plugin{Name: "FilterPlugin"})
+			},
+			wantErr: "profiles[0].plugins.filter.enabled[1].name: Duplicate value",
+		},
+		{
+			name: "plugin without name",
+			update: func(cfg *config.VolumeSchedulerConfiguration) {
+				cfg.Profiles[0].Plugins.Bind.Enabled[0].Name = ""
+			},
+			wantErr: "profiles[0].plugins.bind.enabled[0].name: Required value",
+		},
+		{
+			name: "configuration of a disabled plugin",
+			update: func(cfg *config.VolumeSchedulerConfiguration) {
+				cfg.Profiles[0].PluginConfig[0].Name = "OtherPlugin"
+			},
+			wantErr: "profiles[0].pluginConfig[0].name: Invalid value",
+		},
+		{
+			name: "plugin configured twice",
+			update: func(cfg *config.VolumeSchedulerConfiguration) {
+				cfg.Profiles[0].PluginConfig = append(cfg.Profiles[0].PluginConfig,
+					config.PluginConfig{Name: "ScorePlugin"})
+			},
+			wantErr: "profiles[0].pluginConfig[1].name: Duplicate value",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := valid()
+			tt.update(cfg)
+			err := ValidateVolumeSchedulerConfiguration(cfg, knownPlugins)
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got error %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -27,6 +27,8 @@ type StateKey string
 type CycleState struct {
 	mx      sync.RWMutex
 	storage map[StateKey]StateData
+	// if recordPluginMetrics is true, PluginExecutionDuration will be recorded for this cycle.
+	recordPluginMetrics bool
 }
 
 // NewCycleState initializes a new CycleState and returns its pointer.
@@ -36,6 +38,22 @@ func NewCycleState() *CycleState {
 	}
 }
 
+// ShouldRecordPluginMetrics returns whether PluginExecutionDuration metrics should be recorded.
+func (c *CycleState) ShouldRecordPluginMetrics() bool {
+	if c == nil {
+		return false
+	}
+	return c.recordPluginMetrics
+}
+
+// SetRecordPluginMetrics sets recordPluginMetrics to the given value.
+func (c *CycleState) SetRecordPluginMetrics(flag bool) {
+	if c == nil {
+		return
+	}
+	c.recordPluginMetrics = flag
+}
+
 // Clone creates a copy of CycleState and returns its pointer. Clone returns
 // nil if the context being cloned is nil.
 func (c *CycleState) Clone() *CycleState {
@@ -46,6 +64,7 @@ func (c *CycleState) Clone() *CycleState {
 	for k, v := range c.storage {
 		copy.Write(k, v.Clone())
 	}
+	copy.recordPluginMetrics = c.recordPluginMetrics
 	return copy
 }
 
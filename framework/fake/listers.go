@@ -0,0 +1,27 @@
+package fake
+
+import (
+	"fmt"
+
+	"github.com/shovanmaity/volume-scheduler/framework"
+)
+
+// PoolInfoLister declares a []*framework.PoolInfo type for testing.
+type PoolInfoLister []*framework.PoolInfo
+
+var _ framework.PoolInfoLister = PoolInfoLister{}
+
+// List returns the pools.
+func (pools PoolInfoLister) List() ([]*framework.PoolInfo, error) {
+	return pools, nil
+}
+
+// Get returns the pool with the given namespace/name key.
+func (pools PoolInfoLister) Get(poolKey string) (*framework.PoolInfo, error) {
+	for _, poolInfo := range pools {
+		if poolInfo.Pool != nil && framework.GetPoolKey(poolInfo.Pool) == poolKey {
+			return poolInfo, nil
+		}
+	}
+	return nil, fmt.Errorf("unable to find pool: %s", poolKey)
+}
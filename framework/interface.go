@@ -2,17 +2,42 @@ package framework
 
 import (
 	"context"
+	"math"
 	"time"
 
 	scpv1alpha1 "github.com/openebs/device-localpv/pkg/apis/openebs.io/scp/v1alpha1"
+	clientset "github.com/openebs/device-localpv/pkg/generated/clientset/scp/internalclientset"
+	"github.com/shovanmaity/volume-scheduler/framework/parallelize"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
 )
 
-type VolumeInfo struct {
-	Volume *scpv1alpha1.StorageVolume
-}
-type PoolInfo struct {
-	Pool *scpv1alpha1.StoragePool
+const (
+	// MaxPoolScore is the maximum score a Score plugin is expected to return.
+	MaxPoolScore int64 = 100
+
+	// MinPoolScore is the minimum score a Score plugin is expected to return.
+	MinPoolScore int64 = 0
+
+	// MaxTotalScore is the maximum total score.
+	MaxTotalScore int64 = math.MaxInt64
+)
+
+// LessFunc is the function to sort volume info in the scheduling queue.
+type LessFunc func(volumeInfo1, volumeInfo2 *QueuedVolumeInfo) bool
+
+// WaitingVolume represents a volume currently waiting in the permit phase.
+type WaitingVolume interface {
+	// GetVolume returns a reference to the waiting volume.
+	GetVolume() *scpv1alpha1.StorageVolume
+	// GetPendingPlugins returns a list of pending Permit plugin's name.
+	GetPendingPlugins() []string
+	// Allow declares the waiting volume is allowed to be scheduled by the plugin named as
+	// "pluginName". If this is the last remaining plugin to allow, then a success signal is
+	// delivered to unblock the volume.
+	Allow(pluginName string)
+	// Reject declares the waiting volume unschedulable.
+	Reject(msg string)
 }
 
 // Plugin is the parent type for all the scheduling framework plugins.
@@ -20,6 +45,27 @@ type Plugin interface {
 	Name() string
 }
 
+// EnqueueExtensions is an optional interface that plugins can implement to efficiently move
+// unschedulable volumes in the scheduling queue. A volume rejected by a plugin is only moved
+// back to be retried when one of the events the plugin registered fires. The events of a plugin
+// which doesn't implement the interface are all the events.
+type EnqueueExtensions interface {
+	// EventsToRegister returns a series of possible events that may cause a volume which failed
+	// this plugin schedulable. The events will be registered when instantiating the internal
+	// scheduling queue. Note: the returned list needs to be static (not depend on configuration
+	// parameters); otherwise it would lead to undefined behavior.
+	EventsToRegister() []ClusterEvent
+}
+
+// QueueSortPlugin is an interface that must be implemented by "QueueSort" plugins. These plugins
+// are used to sort volumes in the scheduling queue. Only one queue sort plugin may be enabled at
+// a time.
+type QueueSortPlugin interface {
+	Plugin
+	// Less are used to sort volumes in the scheduling queue.
+	Less(*QueuedVolumeInfo, *QueuedVolumeInfo) bool
+}
+
 // PreFilterPlugin is an interface that must be implemented by "PreFilter" plugins.
 // These plugins are called at the beginning of the scheduling cycle.
 type PreFilterPlugin interface {
@@ -76,10 +122,19 @@ type PostFilterPlugin interface {
 	//
 	// Informational plugins should be configured ahead of other ones, and always return Unschedulable
 	// status. Optionally, a non-nil PostFilterResult may be returned along with a Success status.
-	// For example, a preemption plugin may choose to return nominatedNodeName, so that framework
-	// can reuse that to update the preemptor pod's .spec.status.nominatedNodeName field.
+	// For example, a preemption plugin may choose to return the nominated pool and the volumes
+	// which have to be evicted from it for the volume to fit.
 	PostFilter(ctx context.Context, state *CycleState, volume *scpv1alpha1.StorageVolume,
-		filteredPoolStatusMap PoolToStatusMap) (string, *Status)
+		filteredPoolStatusMap PoolToStatusMap) (*PostFilterResult, *Status)
+}
+
+// PostFilterResult wraps needed info for scheduler framework to act upon PostFilter phase.
+type PostFilterResult struct {
+	// NominatedPoolName is the namespace/name key of the pool the volume is expected to fit on
+	// once the victims are evicted, see GetPoolKey.
+	NominatedPoolName string
+	// Victims are the volumes to evict from the nominated pool.
+	Victims []*scpv1alpha1.StorageVolume
 }
 
 // PreScorePlugin is an interface for "PreScore" plugin. PreScore is an informational extension
@@ -110,8 +165,10 @@ type ScorePlugin interface {
 type ScoreExtensions interface {
 	// NormalizeScore is called for all pools scores produced by the same plugin's "Score"
 	// method. A successful run of NormalizeScore will update the scores list and return
-	// a success status.
-	//NormalizeScore(ctx context.Context, state *CycleState, p *v1.Pod, scores NodeScoreList) *Status
+	// a success status. After normalization every score must be in the range
+	// [MinPoolScore, MaxPoolScore].
+	NormalizeScore(ctx context.Context, state *CycleState, volume *scpv1alpha1.StorageVolume,
+		scores PoolScoreList) *Status
 }
 
 // ReservePlugin is an interface for plugins with Reserve and Unreserve methods. These are meant
@@ -182,3 +239,49 @@ type BindPlugin interface {
 	Bind(ctx context.Context, state *CycleState, volume *scpv1alpha1.StorageVolume,
 		pool *corev1.ObjectReference, cohort *corev1.ObjectReference) *Status
 }
+
+// Handle provides data and some tools that plugins can use. It is passed to the plugin factories
+// at the time of plugin initialization. Plugins must store and use this handle to call framework
+// functions.
+type Handle interface {
+	// Parallelizer returns a parallelizer holding parallelism for scheduler.
+	Parallelizer() parallelize.Parallelizer
+
+	// IterateOverWaitingVolumes acquires a read lock and iterates over the WaitingVolumes map.
+	IterateOverWaitingVolumes(callback func(WaitingVolume))
+
+	// GetWaitingVolume returns a waiting volume given its UID, nil if it is not waiting.
+	GetWaitingVolume(uid types.UID) WaitingVolume
+
+	// RejectWaitingVolume rejects a waiting volume given its UID. The return value indicates if
+	// the volume is waiting or not.
+	RejectWaitingVolume(uid types.UID) bool
+
+	// SnapshotPoolInfoLister returns a lister of the pools of the snapshot the current scheduling
+	// cycle runs on. The snapshot is taken at the beginning of a scheduling cycle and remains
+	// unchanged until a volume finishes "Permit" point, so plugins see the same pools, volumes
+	// and capacities across the extension points of a cycle.
+	SnapshotPoolInfoLister() PoolInfoLister
+
+	// ClientSet returns a clientset of the StorageVolume, StoragePool and StorageCohort API.
+	ClientSet() clientset.Interface
+
+	PluginsRunner
+}
+
+// PluginsRunner abstracts operations to run some plugins. This is used by preemption PostFilter
+// plugins when evaluating the feasibility of scheduling the volume on pools when certain
+// victim volumes get evicted.
+type PluginsRunner interface {
+	// RunFilterPlugins runs the set of configured Filter plugins for volume on the given pool.
+	RunFilterPlugins(ctx context.Context, state *CycleState, volume *scpv1alpha1.StorageVolume,
+		poolInfo *PoolInfo) PluginToStatus
+	// RunPreFilterExtensionAddVolume calls the AddVolume interface for the set of configured
+	// PreFilter plugins.
+	RunPreFilterExtensionAddVolume(ctx context.Context, state *CycleState,
+		volumeToSchedule *scpv1alpha1.StorageVolume, volumeInfoToAdd *VolumeInfo, poolInfo *PoolInfo) *Status
+	// RunPreFilterExtensionRemoveVolume calls the RemoveVolume interface for the set of configured
+	// PreFilter plugins.
+	RunPreFilterExtensionRemoveVolume(ctx context.Context, state *CycleState,
+		volumeToSchedule *scpv1alpha1.StorageVolume, volumeInfoToRemove *VolumeInfo, poolInfo *PoolInfo) *Status
+}
@@ -0,0 +1,9 @@
+package framework
+
+// PoolInfoLister interface represents anything that can list/get PoolInfo objects from pool key.
+type PoolInfoLister interface {
+	// List returns the list of PoolInfos.
+	List() ([]*PoolInfo, error)
+	// Get returns the PoolInfo of the pool with the given namespace/name key, see GetPoolKey.
+	Get(poolKey string) (*PoolInfo, error)
+}
@@ -2,14 +2,20 @@ package parallelize
 
 import (
 	"context"
+	"fmt"
 	"math"
+	"runtime/debug"
+	"sync"
 
-	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
 )
 
 // DefaultParallelism is the default parallelism used in scheduler.
 const DefaultParallelism int = 16
 
+// DoWorkPieceFunc is the work done for a single piece, identified by its index.
+type DoWorkPieceFunc func(piece int)
+
 // Parallelizer holds the parallelism for scheduler.
 type Parallelizer struct {
 	parallelism int
@@ -34,8 +40,78 @@ func chunkSizeFor(n, parallelism int) int {
 	return s
 }
 
-// Until is a wrapper around workqueue.ParallelizeUntil to use in scheduling algorithms.
-func (p Parallelizer) Until(ctx context.Context, pieces int, doWorkPiece workqueue.DoWorkPieceFunc) {
-	//workqueue.ParallelizeUntil(ctx, p.parallelism, pieces, doWorkPiece,
-	//	workqueue.WithChunkSize(chunkSizeFor(pieces, p.parallelism)))
+// Until executes doWorkPiece for every piece in [0, pieces) using at most parallelism workers.
+// Pieces are handed out to the workers in chunks of chunkSizeFor(pieces, parallelism). No new
+// piece is started once ctx is cancelled. A panic in a piece is recovered and logged, and stops
+// the remaining pieces.
+func (p Parallelizer) Until(ctx context.Context, pieces int, doWorkPiece DoWorkPieceFunc) {
+	p.UntilWithErrorChannel(ctx, pieces, doWorkPiece, nil)
+}
+
+// UntilWithErrorChannel is like Until, but a panic in a piece is reported to errCh as an error
+// instead of being only logged. Callers are expected to call errCh.ReceiveError once it returns.
+func (p Parallelizer) UntilWithErrorChannel(ctx context.Context, pieces int,
+	doWorkPiece DoWorkPieceFunc, errCh *ErrorChannel) {
+	if pieces <= 0 {
+		return
+	}
+	workers := p.parallelism
+	if workers < 1 {
+		workers = 1
+	}
+	chunkSize := chunkSizeFor(pieces, workers)
+	chunks := (pieces + chunkSize - 1) / chunkSize
+	if chunks < workers {
+		workers = chunks
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	toProcess := make(chan int, chunks)
+	for i := 0; i < chunks; i++ {
+		toProcess <- i
+	}
+	close(toProcess)
+
+	wg := sync.WaitGroup{}
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			defer handlePanic(errCh, cancel)
+			for chunk := range toProcess {
+				start := chunk * chunkSize
+				end := start + chunkSize
+				if end > pieces {
+					end = pieces
+				}
+				for piece := start; piece < end; piece++ {
+					select {
+					case <-ctx.Done():
+						return
+					default:
+						doWorkPiece(piece)
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// handlePanic recovers a panic raised by a work piece and reports it, cancelling the pieces which
+// have not started yet.
+func handlePanic(errCh *ErrorChannel, cancel context.CancelFunc) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	err := fmt.Errorf("recovered from panic in work piece: %v", r)
+	if errCh == nil {
+		klog.ErrorS(err, "Observed a panic", "stacktrace", string(debug.Stack()))
+		cancel()
+		return
+	}
+	errCh.SendErrorWithCancel(err, cancel)
 }
@@ -0,0 +1,115 @@
+package parallelize
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestChunkSizeFor(t *testing.T) {
+	tests := []struct {
+		n, parallelism int
+		want           int
+	}{
+		{n: 0, parallelism: 16, want: 1},
+		{n: 1, parallelism: 16, want: 1},
+		{n: 10, parallelism: 16, want: 1},
+		{n: 100, parallelism: 16, want: 7},
+		{n: 1000, parallelism: 16, want: 31},
+		{n: 1000, parallelism: 100, want: 11},
+	}
+	for _, tt := range tests {
+		if got := chunkSizeFor(tt.n, tt.parallelism); got != tt.want {
+			t.Errorf("chunkSizeFor(%d, %d) = %d, want %d", tt.n, tt.parallelism, got, tt.want)
+		}
+	}
+}
+
+func TestUntilBoundsWorkers(t *testing.T) {
+	for _, parallelism := range []int{1, 3, 16} {
+		var running, maxRunning int32
+		NewParallelizer(parallelism).Until(context.Background(), 200, func(int) {
+			n := atomic.AddInt32(&running, 1)
+			for {
+				m := atomic.LoadInt32(&maxRunning)
+				if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&running, -1)
+		})
+		if maxRunning > int32(parallelism) {
+			t.Errorf("parallelism %d: %d pieces ran at once", parallelism, maxRunning)
+		}
+	}
+}
+
+func TestUntilRunsEveryPieceOnce(t *testing.T) {
+	tests := []struct {
+		pieces, parallelism int
+	}{
+		{pieces: 1, parallelism: 16},
+		{pieces: 7, parallelism: 3},
+		{pieces: 100, parallelism: 16},
+		{pieces: 1001, parallelism: 7},
+		{pieces: 50, parallelism: 0},
+	}
+	for _, tt := range tests {
+		counts := make([]int32, tt.pieces)
+		NewParallelizer(tt.parallelism).Until(context.Background(), tt.pieces, func(piece int) {
+			atomic.AddInt32(&counts[piece], 1)
+		})
+		for piece, count := range counts {
+			if count != 1 {
+				t.Errorf("pieces %d, parallelism %d: piece %d ran %d times", tt.pieces,
+					tt.parallelism, piece, count)
+			}
+		}
+	}
+}
+
+func TestUntilWithErrorChannelReportsPanic(t *testing.T) {
+	errCh := NewErrorChannel()
+	var ran int32
+	NewParallelizer(1).UntilWithErrorChannel(context.Background(), 100, func(piece int) {
+		atomic.AddInt32(&ran, 1)
+		if piece == 10 {
+			panic("boom")
+		}
+	}, errCh)
+	err := errCh.ReceiveError()
+	if err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("got error %v, want the recovered panic", err)
+	}
+	if ran != 11 {
+		t.Errorf("%d pieces ran, want the pieces after the panic to be skipped", ran)
+	}
+}
+
+func TestUntilStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var ran int32
+	NewParallelizer(4).Until(ctx, 100, func(int) {
+		atomic.AddInt32(&ran, 1)
+	})
+	if ran != 0 {
+		t.Errorf("%d pieces ran with a cancelled context, want 0", ran)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	ran = 0
+	NewParallelizer(1).Until(ctx, 100, func(piece int) {
+		atomic.AddInt32(&ran, 1)
+		if piece == 20 {
+			cancel()
+		}
+	})
+	if ran != 21 {
+		t.Errorf("%d pieces ran, want the pieces after the cancellation to be skipped", ran)
+	}
+}
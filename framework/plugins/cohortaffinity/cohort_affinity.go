@@ -0,0 +1,203 @@
+package cohortaffinity
+
+import (
+	"context"
+	"fmt"
+
+	scpv1alpha1 "github.com/openebs/device-localpv/pkg/apis/openebs.io/scp/v1alpha1"
+	"github.com/shovanmaity/volume-scheduler/framework"
+	"github.com/shovanmaity/volume-scheduler/framework/plugins/helper"
+	"github.com/shovanmaity/volume-scheduler/framework/plugins/names"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	// Name is the name of the plugin used in the plugin registry and configurations.
+	Name = names.CohortAffinity
+
+	// preScoreStateKey is the key in CycleState to CohortAffinity pre-computed data for Scoring.
+	preScoreStateKey = "PreScore" + Name
+
+	// ErrReasonCohortUnknown is used when the cohort of the pool is not known.
+	ErrReasonCohortUnknown = "pool's cohort is not known"
+	// ErrReasonAffinityNotMatch is used when the cohort doesn't match the cohort affinity.
+	ErrReasonAffinityNotMatch = "pool's cohort didn't match the volume's cohort affinity"
+	// ErrReasonAntiAffinityMatch is used when the cohort matches the cohort anti-affinity.
+	ErrReasonAntiAffinityMatch = "pool's cohort matched the volume's cohort anti-affinity"
+)
+
+// CohortAffinity is a plugin that checks if a pool's cohort matches the required cohort affinity
+// and anti-affinity of a volume, and favors the cohorts matching its preferred ones.
+type CohortAffinity struct {
+	handle framework.Handle
+}
+
+var _ framework.FilterPlugin = &CohortAffinity{}
+var _ framework.PreScorePlugin = &CohortAffinity{}
+var _ framework.ScorePlugin = &CohortAffinity{}
+var _ framework.EnqueueExtensions = &CohortAffinity{}
+
+// weightedSelector is a parsed WeightedCohortAffinityTerm. Anti-affinity terms have a negative
+// weight.
+type weightedSelector struct {
+	weight   int64
+	selector labels.Selector
+}
+
+// preScoreState computed at PreScore and used at Score.
+type preScoreState struct {
+	terms []weightedSelector
+}
+
+// Clone implements the mandatory Clone interface. We don't really copy the data since
+// there is no need for that.
+func (s *preScoreState) Clone() framework.StateData {
+	return s
+}
+
+// Name returns name of the plugin.
+func (pl *CohortAffinity) Name() string {
+	return Name
+}
+
+// EventsToRegister returns the possible events that may make a volume failed by this plugin
+// schedulable: the cohort of a pool, or the labels of a cohort, changing.
+func (pl *CohortAffinity) EventsToRegister() []framework.ClusterEvent {
+	return []framework.ClusterEvent{
+		{Resource: framework.StoragePool, ActionType: framework.Add | framework.UpdatePoolCohort},
+		{Resource: framework.StorageCohort, ActionType: framework.Add | framework.UpdateCohortLabel},
+	}
+}
+
+// Filter checks if the pool's cohort matches the volume's required cohort affinity and
+// anti-affinity.
+func (pl *CohortAffinity) Filter(ctx context.Context, state *framework.CycleState,
+	volume *scpv1alpha1.StorageVolume, poolInfo *framework.PoolInfo) *framework.Status {
+	affinity := volume.Spec.Affinity
+	if affinity == nil || (len(affinity.CohortAffinity) == 0 && len(affinity.CohortAntiAffinity) == 0) {
+		return nil
+	}
+	if poolInfo.Cohort == nil {
+		return framework.NewStatus(framework.UnschedulableAndUnresolvable, ErrReasonCohortUnknown)
+	}
+	cohortLabels := labels.Set(poolInfo.Cohort.Labels)
+	for i := range affinity.CohortAffinity {
+		selector, err := metav1.LabelSelectorAsSelector(&affinity.CohortAffinity[i])
+		if err != nil {
+			return framework.AsStatus(fmt.Errorf("parsing cohort affinity: %w", err))
+		}
+		if !selector.Matches(cohortLabels) {
+			return framework.NewStatus(framework.UnschedulableAndUnresolvable, ErrReasonAffinityNotMatch)
+		}
+	}
+	for i := range affinity.CohortAntiAffinity {
+		selector, err := metav1.LabelSelectorAsSelector(&affinity.CohortAntiAffinity[i])
+		if err != nil {
+			return framework.AsStatus(fmt.Errorf("parsing cohort anti-affinity: %w", err))
+		}
+		if selector.Matches(cohortLabels) {
+			return framework.NewStatus(framework.UnschedulableAndUnresolvable, ErrReasonAntiAffinityMatch)
+		}
+	}
+	return nil
+}
+
+// PreScore builds and writes cycle state used by Score and NormalizeScore.
+func (pl *CohortAffinity) PreScore(ctx context.Context, cycleState *framework.CycleState,
+	volume *scpv1alpha1.StorageVolume, pools []*scpv1alpha1.StoragePool) *framework.Status {
+	affinity, err := helper.GetPreferredAffinity(volume)
+	if err != nil {
+		return framework.AsStatus(err)
+	}
+	state := &preScoreState{}
+	if affinity != nil {
+		for _, term := range affinity.CohortAffinity {
+			if state.terms, err = appendTerm(state.terms, term, 1); err != nil {
+				return framework.AsStatus(err)
+			}
+		}
+		for _, term := range affinity.CohortAntiAffinity {
+			if state.terms, err = appendTerm(state.terms, term, -1); err != nil {
+				return framework.AsStatus(err)
+			}
+		}
+	}
+	cycleState.Write(preScoreStateKey, state)
+	return nil
+}
+
+func appendTerm(terms []weightedSelector, term helper.WeightedCohortAffinityTerm,
+	multiplier int64) ([]weightedSelector, error) {
+	if term.Weight == 0 {
+		return terms, nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(&term.LabelSelector)
+	if err != nil {
+		return nil, fmt.Errorf("parsing preferred cohort affinity: %w", err)
+	}
+	return append(terms, weightedSelector{
+		weight:   multiplier * int64(term.Weight),
+		selector: selector,
+	}), nil
+}
+
+// Score returns the sum of the weights of the preferred cohort affinity terms matching the
+// pool's cohort, minus the ones of the matching preferred anti-affinity terms.
+func (pl *CohortAffinity) Score(ctx context.Context, cycleState *framework.CycleState,
+	volume *scpv1alpha1.StorageVolume, pool, cohort *corev1.ObjectReference) (int64, *framework.Status) {
+	s, err := getPreScoreState(cycleState)
+	if err != nil {
+		return 0, framework.AsStatus(err)
+	}
+	if len(s.terms) == 0 {
+		return 0, nil
+	}
+	poolKey := framework.GetReferenceKey(pool, volume.Namespace)
+	poolInfo, err := pl.handle.SnapshotPoolInfoLister().Get(poolKey)
+	if err != nil {
+		return 0, framework.AsStatus(fmt.Errorf("getting pool %q from Snapshot: %w", poolKey, err))
+	}
+	if poolInfo.Cohort == nil {
+		return 0, nil
+	}
+	var score int64
+	cohortLabels := labels.Set(poolInfo.Cohort.Labels)
+	for _, term := range s.terms {
+		if term.selector.Matches(cohortLabels) {
+			score += term.weight
+		}
+	}
+	return score, nil
+}
+
+// NormalizeScore scales the scores into the range [MinPoolScore, MaxPoolScore].
+func (pl *CohortAffinity) NormalizeScore(ctx context.Context, cycleState *framework.CycleState,
+	volume *scpv1alpha1.StorageVolume, scores framework.PoolScoreList) *framework.Status {
+	helper.MinMaxNormalizeScore(scores)
+	return nil
+}
+
+// ScoreExtensions of the Score plugin.
+func (pl *CohortAffinity) ScoreExtensions() framework.ScoreExtensions {
+	return pl
+}
+
+func getPreScoreState(cycleState *framework.CycleState) (*preScoreState, error) {
+	c, err := cycleState.Read(preScoreStateKey)
+	if err != nil {
+		return nil, fmt.Errorf("reading %q from cycleState: %w", preScoreStateKey, err)
+	}
+	s, ok := c.(*preScoreState)
+	if !ok {
+		return nil, fmt.Errorf("%+v convert to cohortaffinity.preScoreState error", c)
+	}
+	return s, nil
+}
+
+// New initializes a new plugin and returns it.
+func New(_ runtime.Object, handle framework.Handle) (framework.Plugin, error) {
+	return &CohortAffinity{handle: handle}, nil
+}
@@ -0,0 +1,233 @@
+package cohortaffinity
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	scpv1alpha1 "github.com/openebs/device-localpv/pkg/apis/openebs.io/scp/v1alpha1"
+	"github.com/shovanmaity/volume-scheduler/framework"
+	"github.com/shovanmaity/volume-scheduler/framework/fake"
+	"github.com/shovanmaity/volume-scheduler/framework/plugins/helper"
+	frameworkruntime "github.com/shovanmaity/volume-scheduler/framework/runtime"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func makePoolInfo(name string, cohortLabels map[string]string) *framework.PoolInfo {
+	poolInfo := framework.NewPoolInfo()
+	poolInfo.SetPool(&scpv1alpha1.StoragePool{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name}})
+	if cohortLabels != nil {
+		poolInfo.SetCohort(&scpv1alpha1.StorageCohort{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name + "-cohort", Labels: cohortLabels},
+		})
+	}
+	return poolInfo
+}
+
+func selector(key, value string) metav1.LabelSelector {
+	return metav1.LabelSelector{MatchLabels: map[string]string{key: value}}
+}
+
+func withPreferredAffinity(t *testing.T, volume *scpv1alpha1.StorageVolume,
+	affinity *helper.PreferredAffinity) *scpv1alpha1.StorageVolume {
+	t.Helper()
+	data, err := json.Marshal(affinity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	volume.Annotations = map[string]string{helper.PreferredAffinityAnnotation: string(data)}
+	return volume
+}
+
+func newPlugin(t *testing.T, poolInfos []*framework.PoolInfo) *CohortAffinity {
+	t.Helper()
+	fh, err := frameworkruntime.NewFramework(nil, nil,
+		frameworkruntime.WithSnapshotPoolInfoLister(fake.PoolInfoLister(poolInfos)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := New(nil, fh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p.(*CohortAffinity)
+}
+
+func TestCohortAffinityFilter(t *testing.T) {
+	fast := map[string]string{"tier": "fast"}
+	tests := []struct {
+		name     string
+		affinity *scpv1alpha1.Affinity
+		poolInfo *framework.PoolInfo
+		want     *framework.Status
+	}{
+		{
+			name:     "volume without affinity",
+			poolInfo: makePoolInfo("pool", nil),
+		},
+		{
+			name:     "volume with volume affinity only",
+			affinity: &scpv1alpha1.Affinity{VolumeAffinity: []scpv1alpha1.VolumeAffinityTerm{{}}},
+			poolInfo: makePoolInfo("pool", nil),
+		},
+		{
+			name:     "cohort matches the affinity",
+			affinity: &scpv1alpha1.Affinity{CohortAffinity: []metav1.LabelSelector{selector("tier", "fast")}},
+			poolInfo: makePoolInfo("pool", fast),
+		},
+		{
+			name: "cohort matches only one of the affinity terms",
+			affinity: &scpv1alpha1.Affinity{CohortAffinity: []metav1.LabelSelector{
+				selector("tier", "fast"), selector("zone", "a"),
+			}},
+			poolInfo: makePoolInfo("pool", fast),
+			want:     framework.NewStatus(framework.UnschedulableAndUnresolvable, ErrReasonAffinityNotMatch),
+		},
+		{
+			name:     "cohort doesn't match the affinity",
+			affinity: &scpv1alpha1.Affinity{CohortAffinity: []metav1.LabelSelector{selector("tier", "slow")}},
+			poolInfo: makePoolInfo("pool", fast),
+			want:     framework.NewStatus(framework.UnschedulableAndUnresolvable, ErrReasonAffinityNotMatch),
+		},
+		{
+			name:     "cohort matches the anti-affinity",
+			affinity: &scpv1alpha1.Affinity{CohortAntiAffinity: []metav1.LabelSelector{selector("tier", "fast")}},
+			poolInfo: makePoolInfo("pool", fast),
+			want:     framework.NewStatus(framework.UnschedulableAndUnresolvable, ErrReasonAntiAffinityMatch),
+		},
+		{
+			name:     "cohort doesn't match the anti-affinity",
+			affinity: &scpv1alpha1.Affinity{CohortAntiAffinity: []metav1.LabelSelector{selector("tier", "slow")}},
+			poolInfo: makePoolInfo("pool", fast),
+		},
+		{
+			name:     "cohort of the pool is not known",
+			affinity: &scpv1alpha1.Affinity{CohortAntiAffinity: []metav1.LabelSelector{selector("tier", "slow")}},
+			poolInfo: makePoolInfo("pool", nil),
+			want:     framework.NewStatus(framework.UnschedulableAndUnresolvable, ErrReasonCohortUnknown),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			volume := &scpv1alpha1.StorageVolume{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "vol"}}
+			volume.Spec.Affinity = tt.affinity
+			p := newPlugin(t, []*framework.PoolInfo{tt.poolInfo})
+			got := p.Filter(context.Background(), framework.NewCycleState(), volume, tt.poolInfo)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("unexpected status (-want, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestCohortAffinityScore(t *testing.T) {
+	poolInfos := []*framework.PoolInfo{
+		makePoolInfo("fast-a", map[string]string{"tier": "fast", "zone": "a"}),
+		makePoolInfo("fast-b", map[string]string{"tier": "fast", "zone": "b"}),
+		makePoolInfo("slow-a", map[string]string{"tier": "slow", "zone": "a"}),
+		makePoolInfo("unknown", nil),
+	}
+	tests := []struct {
+		name     string
+		affinity *helper.PreferredAffinity
+		want     []int64
+	}{
+		{
+			name: "no preferred affinity",
+			want: []int64{0, 0, 0, 0},
+		},
+		{
+			name: "preferred affinity",
+			affinity: &helper.PreferredAffinity{CohortAffinity: []helper.WeightedCohortAffinityTerm{
+				{Weight: 10, LabelSelector: selector("tier", "fast")},
+				{Weight: 5, LabelSelector: selector("zone", "a")},
+			}},
+			want: []int64{100, 66, 33, 0},
+		},
+		{
+			name: "preferred anti-affinity",
+			affinity: &helper.PreferredAffinity{CohortAntiAffinity: []helper.WeightedCohortAffinityTerm{
+				{Weight: 10, LabelSelector: selector("zone", "a")},
+			}},
+			want: []int64{0, 100, 0, 100},
+		},
+		{
+			name: "preferred affinity and anti-affinity",
+			affinity: &helper.PreferredAffinity{
+				CohortAffinity: []helper.WeightedCohortAffinityTerm{
+					{Weight: 10, LabelSelector: selector("tier", "fast")},
+				},
+				CohortAntiAffinity: []helper.WeightedCohortAffinityTerm{
+					{Weight: 20, LabelSelector: selector("zone", "a")},
+				},
+			},
+			want: []int64{33, 100, 0, 66},
+		},
+		{
+			name: "terms without weight are ignored",
+			affinity: &helper.PreferredAffinity{CohortAffinity: []helper.WeightedCohortAffinityTerm{
+				{Weight: 0, LabelSelector: selector("tier", "fast")},
+			}},
+			want: []int64{0, 0, 0, 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			volume := &scpv1alpha1.StorageVolume{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "vol"}}
+			if tt.affinity != nil {
+				volume = withPreferredAffinity(t, volume, tt.affinity)
+			}
+			p := newPlugin(t, poolInfos)
+			cycleState := framework.NewCycleState()
+			var pools []*scpv1alpha1.StoragePool
+			for _, poolInfo := range poolInfos {
+				pools = append(pools, poolInfo.Pool)
+			}
+			if status := p.PreScore(context.Background(), cycleState, volume, pools); !status.IsSuccess() {
+				t.Fatalf("prescore failed with status: %v", status)
+			}
+			var scores framework.PoolScoreList
+			for _, pool := range pools {
+				score, status := p.Score(context.Background(), cycleState, volume,
+					framework.PoolReference(pool), pool.Spec.StorageCohortReference)
+				if !status.IsSuccess() {
+					t.Fatalf("score failed with status: %v", status)
+				}
+				scores = append(scores, framework.PoolScore{Namespace: pool.Namespace, Name: pool.Name, Score: score})
+			}
+			if status := p.NormalizeScore(context.Background(), cycleState, volume, scores); !status.IsSuccess() {
+				t.Fatalf("normalize score failed with status: %v", status)
+			}
+			var got []int64
+			for _, s := range scores {
+				got = append(got, s.Score)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("unexpected scores (-want, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestCohortAffinityScoreUnknownPool(t *testing.T) {
+	volume := withPreferredAffinity(t,
+		&scpv1alpha1.StorageVolume{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "vol"}},
+		&helper.PreferredAffinity{CohortAffinity: []helper.WeightedCohortAffinityTerm{
+			{Weight: 10, LabelSelector: selector("tier", "fast")},
+		}})
+	poolInfo := makePoolInfo("pool", map[string]string{"tier": "fast"})
+	p := newPlugin(t, []*framework.PoolInfo{poolInfo})
+	cycleState := framework.NewCycleState()
+	if status := p.PreScore(context.Background(), cycleState, volume, nil); !status.IsSuccess() {
+		t.Fatalf("prescore failed with status: %v", status)
+	}
+
+	// The pool has the same name as the one of the snapshot, in another namespace.
+	other := poolInfo.Pool.DeepCopy()
+	other.Namespace = "other"
+	_, status := p.Score(context.Background(), cycleState, volume, framework.PoolReference(other), nil)
+	if status.Code() != framework.Error {
+		t.Errorf("got status %v, want an error", status)
+	}
+}
@@ -0,0 +1,476 @@
+package cohorttopologyspread
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	scpv1alpha1 "github.com/openebs/device-localpv/pkg/apis/openebs.io/scp/v1alpha1"
+	"github.com/shovanmaity/volume-scheduler/framework"
+	"github.com/shovanmaity/volume-scheduler/framework/plugins/helper"
+	"github.com/shovanmaity/volume-scheduler/framework/plugins/names"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	// Name is the name of the plugin used in the plugin registry and configurations.
+	Name = names.CohortTopologySpread
+
+	// preFilterStateKey is the key in CycleState to CohortTopologySpread pre-computed data for
+	// Filtering.
+	preFilterStateKey = "PreFilter" + Name
+	// preScoreStateKey is the key in CycleState to CohortTopologySpread pre-computed data for
+	// Scoring.
+	preScoreStateKey = "PreScore" + Name
+
+	// ErrReasonConstraintsNotMatch is used for CohortTopologySpread filter error.
+	ErrReasonConstraintsNotMatch = "pool(s) didn't match volume topology spread constraints"
+	// ErrReasonCohortLabelNotMatch is used when the pool's cohort doesn't have the required
+	// topology label.
+	ErrReasonCohortLabelNotMatch = ErrReasonConstraintsNotMatch + " (missing required label)"
+)
+
+// CohortTopologySpread is a plugin that spreads the volumes over the topologies of the cohorts,
+// e.g. nodes, racks or zones. DoNotSchedule constraints filter out the pools which would break
+// the maximum skew, ScheduleAnyway constraints favor the pools which reduce the skew.
+type CohortTopologySpread struct {
+	handle framework.Handle
+}
+
+var _ framework.PreFilterPlugin = &CohortTopologySpread{}
+var _ framework.PreFilterExtensions = &CohortTopologySpread{}
+var _ framework.FilterPlugin = &CohortTopologySpread{}
+var _ framework.PreScorePlugin = &CohortTopologySpread{}
+var _ framework.ScorePlugin = &CohortTopologySpread{}
+var _ framework.EnqueueExtensions = &CohortTopologySpread{}
+
+// topologySpreadConstraint is a parsed TopologySpreadConstraint.
+type topologySpreadConstraint struct {
+	maxSkew     int32
+	topologyKey string
+	selector    labels.Selector
+}
+
+// matches returns true if the volume is in the namespace of the volume being scheduled and
+// matches the selector of the constraint.
+func (c *topologySpreadConstraint) matches(namespace string, volume *scpv1alpha1.StorageVolume) bool {
+	return volume.Namespace == namespace && c.selector.Matches(labels.Set(volume.Labels))
+}
+
+// topologyToMatchedVolumes maps a topology value to the number of volumes placed in that
+// topology which match a constraint.
+type topologyToMatchedVolumes map[string]int32
+
+// preFilterState computed at PreFilter and used at Filter.
+type preFilterState struct {
+	namespace    string
+	poolSelector labels.Selector
+	constraints  []topologySpreadConstraint
+	// counts[i] counts the volumes matching constraints[i] in each topology of the eligible
+	// pools. Every topology of the eligible pools has an entry, even if it holds no volume.
+	counts []topologyToMatchedVolumes
+	// minCounts[i] is the smallest count of counts[i], it is kept up to date by AddVolume and
+	// RemoveVolume.
+	minCounts []int32
+}
+
+// Clone the prefilter state. Constraints are never mutated, only the counts are copied.
+func (s *preFilterState) Clone() framework.StateData {
+	if s == nil {
+		return nil
+	}
+	copy := *s
+	copy.counts = make([]topologyToMatchedVolumes, len(s.counts))
+	for i, m := range s.counts {
+		copy.counts[i] = make(topologyToMatchedVolumes, len(m))
+		for k, v := range m {
+			copy.counts[i][k] = v
+		}
+	}
+	copy.minCounts = append([]int32(nil), s.minCounts...)
+	return &copy
+}
+
+// updateWithVolume updates the counts with the volume placed on the pool, delta is 1 for an
+// added volume and -1 for a removed one.
+func (s *preFilterState) updateWithVolume(volume *scpv1alpha1.StorageVolume, poolInfo *framework.PoolInfo,
+	delta int32) {
+	if len(s.constraints) == 0 || !isEligiblePool(s.poolSelector, poolInfo) {
+		return
+	}
+	for i, c := range s.constraints {
+		if !c.matches(s.namespace, volume) {
+			continue
+		}
+		value, ok := helper.TopologyValue(poolInfo.Cohort, c.topologyKey)
+		if !ok {
+			continue
+		}
+		s.counts[i][value] += delta
+		s.minCounts[i] = minCount(s.counts[i])
+	}
+}
+
+// preScoreState computed at PreScore and used at Score.
+type preScoreState struct {
+	constraints []topologySpreadConstraint
+	// counts[i] counts the volumes matching constraints[i] in each topology of the eligible
+	// pools.
+	counts []topologyToMatchedVolumes
+	// weights[i] is the normalizing weight of constraints[i], topologies with more values get a
+	// higher weight so that they are not dominated by the ones with few values.
+	weights []float64
+	// ignoredPools are the keys of the filtered pools whose cohort misses a topology key, they
+	// get the lowest score.
+	ignoredPools sets.String
+}
+
+// Clone implements the mandatory Clone interface. We don't really copy the data since
+// there is no need for that.
+func (s *preScoreState) Clone() framework.StateData {
+	return s
+}
+
+// Name returns name of the plugin.
+func (pl *CohortTopologySpread) Name() string {
+	return Name
+}
+
+// EventsToRegister returns the possible events that may make a volume failed by this plugin
+// schedulable. The eligible pools depend on their labels, their topology on their cohorts and
+// on the labels of the cohorts.
+func (pl *CohortTopologySpread) EventsToRegister() []framework.ClusterEvent {
+	return []framework.ClusterEvent{
+		{Resource: framework.StorageVolume, ActionType: framework.All},
+		{Resource: framework.StoragePool, ActionType: framework.Add | framework.Delete |
+			framework.UpdatePoolLabel | framework.UpdatePoolCohort},
+		{Resource: framework.StorageCohort, ActionType: framework.All},
+	}
+}
+
+// PreFilter counts, for every DoNotSchedule constraint of the volume, the existing volumes
+// matching the constraint in each topology.
+func (pl *CohortTopologySpread) PreFilter(ctx context.Context, cycleState *framework.CycleState,
+	volume *scpv1alpha1.StorageVolume) *framework.Status {
+	constraints, err := getConstraints(volume, corev1.DoNotSchedule)
+	if err != nil {
+		return framework.AsStatus(err)
+	}
+	s := &preFilterState{namespace: volume.Namespace, constraints: constraints}
+	if len(constraints) > 0 {
+		if s.poolSelector, err = helper.GetPoolSelector(volume); err != nil {
+			return framework.AsStatus(err)
+		}
+		poolInfos, err := pl.handle.SnapshotPoolInfoLister().List()
+		if err != nil {
+			return framework.AsStatus(fmt.Errorf("listing pools: %w", err))
+		}
+		s.counts = countMatchingVolumes(volume, constraints, s.poolSelector, poolInfos)
+		s.minCounts = make([]int32, len(constraints))
+		for i := range s.counts {
+			s.minCounts[i] = minCount(s.counts[i])
+		}
+	}
+	cycleState.Write(preFilterStateKey, s)
+	return nil
+}
+
+// PreFilterExtensions returns prefilter extensions, volume add and remove.
+func (pl *CohortTopologySpread) PreFilterExtensions() framework.PreFilterExtensions {
+	return pl
+}
+
+// AddVolume from pre-computed data in cycleState.
+func (pl *CohortTopologySpread) AddVolume(ctx context.Context, cycleState *framework.CycleState,
+	volumeToSchedule *scpv1alpha1.StorageVolume, volumeInfoToAdd *framework.VolumeInfo,
+	poolInfo *framework.PoolInfo) *framework.Status {
+	s, err := getPreFilterState(cycleState)
+	if err != nil {
+		return framework.AsStatus(err)
+	}
+	s.updateWithVolume(volumeInfoToAdd.Volume, poolInfo, 1)
+	return nil
+}
+
+// RemoveVolume from pre-computed data in cycleState.
+func (pl *CohortTopologySpread) RemoveVolume(ctx context.Context, cycleState *framework.CycleState,
+	volumeToSchedule *scpv1alpha1.StorageVolume, volumeInfoToRemove *framework.VolumeInfo,
+	poolInfo *framework.PoolInfo) *framework.Status {
+	s, err := getPreFilterState(cycleState)
+	if err != nil {
+		return framework.AsStatus(err)
+	}
+	s.updateWithVolume(volumeInfoToRemove.Volume, poolInfo, -1)
+	return nil
+}
+
+// Filter checks that placing the volume in the topology of the pool's cohort doesn't make the
+// skew of any DoNotSchedule constraint exceed its maxSkew. The skew is the difference between
+// the number of matching volumes in that topology and the smallest number in any topology.
+func (pl *CohortTopologySpread) Filter(ctx context.Context, cycleState *framework.CycleState,
+	volume *scpv1alpha1.StorageVolume, poolInfo *framework.PoolInfo) *framework.Status {
+	s, err := getPreFilterState(cycleState)
+	if err != nil {
+		return framework.AsStatus(err)
+	}
+	for i, c := range s.constraints {
+		value, ok := helper.TopologyValue(poolInfo.Cohort, c.topologyKey)
+		if !ok {
+			return framework.NewStatus(framework.UnschedulableAndUnresolvable, ErrReasonCohortLabelNotMatch)
+		}
+		selfMatch := int32(0)
+		if c.selector.Matches(labels.Set(volume.Labels)) {
+			selfMatch = 1
+		}
+		skew := s.counts[i][value] + selfMatch - s.minCounts[i]
+		if skew > c.maxSkew {
+			return framework.NewStatus(framework.Unschedulable, ErrReasonConstraintsNotMatch)
+		}
+	}
+	return nil
+}
+
+// PreScore counts, for every ScheduleAnyway constraint of the volume, the existing volumes
+// matching the constraint in each topology.
+func (pl *CohortTopologySpread) PreScore(ctx context.Context, cycleState *framework.CycleState,
+	volume *scpv1alpha1.StorageVolume, pools []*scpv1alpha1.StoragePool) *framework.Status {
+	constraints, err := getConstraints(volume, corev1.ScheduleAnyway)
+	if err != nil {
+		return framework.AsStatus(err)
+	}
+	state := &preScoreState{
+		constraints:  constraints,
+		ignoredPools: sets.NewString(),
+	}
+	defer cycleState.Write(preScoreStateKey, state)
+	if len(constraints) == 0 {
+		return nil
+	}
+
+	lister := pl.handle.SnapshotPoolInfoLister()
+	for _, pool := range pools {
+		poolKey := framework.GetPoolKey(pool)
+		poolInfo, err := lister.Get(poolKey)
+		if err != nil {
+			return framework.AsStatus(fmt.Errorf("getting pool %q from Snapshot: %w", poolKey, err))
+		}
+		for _, c := range constraints {
+			if _, ok := helper.TopologyValue(poolInfo.Cohort, c.topologyKey); !ok {
+				state.ignoredPools.Insert(poolKey)
+				break
+			}
+		}
+	}
+
+	poolSelector, err := helper.GetPoolSelector(volume)
+	if err != nil {
+		return framework.AsStatus(err)
+	}
+	poolInfos, err := lister.List()
+	if err != nil {
+		return framework.AsStatus(fmt.Errorf("listing pools: %w", err))
+	}
+	state.counts = countMatchingVolumes(volume, constraints, poolSelector, poolInfos)
+	state.weights = make([]float64, len(constraints))
+	for i := range state.counts {
+		state.weights[i] = topologyNormalizingWeight(len(state.counts[i]))
+	}
+	return nil
+}
+
+// Score sums, over the ScheduleAnyway constraints, the weighted number of matching volumes in
+// the topology of the pool's cohort. A higher score means a worse spread, it is reversed by
+// NormalizeScore.
+func (pl *CohortTopologySpread) Score(ctx context.Context, cycleState *framework.CycleState,
+	volume *scpv1alpha1.StorageVolume, pool, cohort *corev1.ObjectReference) (int64, *framework.Status) {
+	s, err := getPreScoreState(cycleState)
+	if err != nil {
+		return 0, framework.AsStatus(err)
+	}
+	poolKey := framework.GetReferenceKey(pool, volume.Namespace)
+	if len(s.constraints) == 0 || s.ignoredPools.Has(poolKey) {
+		return 0, nil
+	}
+	poolInfo, err := pl.handle.SnapshotPoolInfoLister().Get(poolKey)
+	if err != nil {
+		return 0, framework.AsStatus(fmt.Errorf("getting pool %q from Snapshot: %w", poolKey, err))
+	}
+	var score float64
+	for i, c := range s.constraints {
+		value, _ := helper.TopologyValue(poolInfo.Cohort, c.topologyKey)
+		// maxSkew-1 is added so that constraints with a higher maxSkew are less significant.
+		score += float64(s.counts[i][value])*s.weights[i] + float64(c.maxSkew-1)
+	}
+	return int64(math.Round(score)), nil
+}
+
+// NormalizeScore reverses the scores and scales them into the range
+// [MinPoolScore, MaxPoolScore]. The pools with the fewest matching volumes get the highest
+// score, the ignored pools get MinPoolScore.
+func (pl *CohortTopologySpread) NormalizeScore(ctx context.Context, cycleState *framework.CycleState,
+	volume *scpv1alpha1.StorageVolume, scores framework.PoolScoreList) *framework.Status {
+	s, err := getPreScoreState(cycleState)
+	if err != nil {
+		return framework.AsStatus(err)
+	}
+	if len(s.constraints) == 0 {
+		return nil
+	}
+
+	var minScore int64 = math.MaxInt64
+	var maxScore int64
+	for i := range scores {
+		if s.ignoredPools.Has(scoreKey(scores[i])) {
+			continue
+		}
+		if scores[i].Score < minScore {
+			minScore = scores[i].Score
+		}
+		if scores[i].Score > maxScore {
+			maxScore = scores[i].Score
+		}
+	}
+	for i := range scores {
+		if s.ignoredPools.Has(scoreKey(scores[i])) {
+			scores[i].Score = framework.MinPoolScore
+			continue
+		}
+		if maxScore == 0 {
+			scores[i].Score = framework.MaxPoolScore
+			continue
+		}
+		scores[i].Score = framework.MaxPoolScore * (maxScore + minScore - scores[i].Score) / maxScore
+	}
+	return nil
+}
+
+// ScoreExtensions of the Score plugin.
+func (pl *CohortTopologySpread) ScoreExtensions() framework.ScoreExtensions {
+	return pl
+}
+
+// getConstraints returns the parsed constraints of the volume with the given action.
+func getConstraints(volume *scpv1alpha1.StorageVolume,
+	action corev1.UnsatisfiableConstraintAction) ([]topologySpreadConstraint, error) {
+	constraints, err := helper.GetTopologySpreadConstraints(volume)
+	if err != nil {
+		return nil, err
+	}
+	var result []topologySpreadConstraint
+	for i := range constraints {
+		if constraints[i].WhenUnsatisfiable != action {
+			continue
+		}
+		if constraints[i].MaxSkew <= 0 {
+			return nil, fmt.Errorf("topology spread constraint %q has a non positive maxSkew %d",
+				constraints[i].TopologyKey, constraints[i].MaxSkew)
+		}
+		selector, err := metav1.LabelSelectorAsSelector(constraints[i].LabelSelector)
+		if err != nil {
+			return nil, fmt.Errorf("parsing topology spread constraint: %w", err)
+		}
+		result = append(result, topologySpreadConstraint{
+			maxSkew:     constraints[i].MaxSkew,
+			topologyKey: constraints[i].TopologyKey,
+			selector:    selector,
+		})
+	}
+	return result, nil
+}
+
+// countMatchingVolumes counts, for each constraint, the volumes matching it in every topology of
+// the pools eligible for the volume. The volume itself is not counted.
+func countMatchingVolumes(volume *scpv1alpha1.StorageVolume, constraints []topologySpreadConstraint,
+	poolSelector labels.Selector, poolInfos []*framework.PoolInfo) []topologyToMatchedVolumes {
+	counts := make([]topologyToMatchedVolumes, len(constraints))
+	for i := range counts {
+		counts[i] = make(topologyToMatchedVolumes)
+	}
+	for _, poolInfo := range poolInfos {
+		if !isEligiblePool(poolSelector, poolInfo) {
+			continue
+		}
+		for i, c := range constraints {
+			value, ok := helper.TopologyValue(poolInfo.Cohort, c.topologyKey)
+			if !ok {
+				continue
+			}
+			count := counts[i][value]
+			for _, v := range poolInfo.Volumes {
+				if v.Volume.UID != volume.UID && c.matches(volume.Namespace, v.Volume) {
+					count++
+				}
+			}
+			counts[i][value] = count
+		}
+	}
+	return counts
+}
+
+// isEligiblePool returns true if the volume could be placed on the pool as far as its pool
+// selector is concerned. Only the topologies of the eligible pools are taken into account.
+func isEligiblePool(poolSelector labels.Selector, poolInfo *framework.PoolInfo) bool {
+	return poolInfo.Pool != nil && poolSelector.Matches(labels.Set(poolInfo.Pool.Labels))
+}
+
+// scoreKey returns the namespace/name key of the scored pool.
+func scoreKey(score framework.PoolScore) string {
+	return types.NamespacedName{Namespace: score.Namespace, Name: score.Name}.String()
+}
+
+// minCount returns the smallest count of the topologies, 0 if there is no topology.
+func minCount(counts topologyToMatchedVolumes) int32 {
+	if len(counts) == 0 {
+		return 0
+	}
+	result := int32(math.MaxInt32)
+	for _, count := range counts {
+		if count < result {
+			result = count
+		}
+	}
+	return result
+}
+
+// topologyNormalizingWeight calculates the weight for the topology, based on the number of
+// values that exist for a topology. The logarithm keeps the weight of topologies with many
+// values, such as the cohorts themselves, in the same order of magnitude as the weight of
+// topologies with a few values, such as zones.
+func topologyNormalizingWeight(size int) float64 {
+	return math.Log(float64(size + 2))
+}
+
+func getPreFilterState(cycleState *framework.CycleState) (*preFilterState, error) {
+	c, err := cycleState.Read(preFilterStateKey)
+	if err != nil {
+		// preFilterState doesn't exist, likely PreFilter wasn't invoked.
+		return nil, fmt.Errorf("error reading %q from cycleState: %w", preFilterStateKey, err)
+	}
+	s, ok := c.(*preFilterState)
+	if !ok {
+		return nil, fmt.Errorf("%+v convert to cohorttopologyspread.preFilterState error", c)
+	}
+	return s, nil
+}
+
+func getPreScoreState(cycleState *framework.CycleState) (*preScoreState, error) {
+	c, err := cycleState.Read(preScoreStateKey)
+	if err != nil {
+		return nil, fmt.Errorf("reading %q from cycleState: %w", preScoreStateKey, err)
+	}
+	s, ok := c.(*preScoreState)
+	if !ok {
+		return nil, fmt.Errorf("%+v convert to cohorttopologyspread.preScoreState error", c)
+	}
+	return s, nil
+}
+
+// New initializes a new plugin and returns it.
+func New(_ runtime.Object, handle framework.Handle) (framework.Plugin, error) {
+	return &CohortTopologySpread{handle: handle}, nil
+}
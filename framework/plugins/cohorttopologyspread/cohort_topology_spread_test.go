@@ -0,0 +1,294 @@
+package cohorttopologyspread
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	scpv1alpha1 "github.com/openebs/device-localpv/pkg/apis/openebs.io/scp/v1alpha1"
+	"github.com/shovanmaity/volume-scheduler/framework"
+	"github.com/shovanmaity/volume-scheduler/framework/fake"
+	"github.com/shovanmaity/volume-scheduler/framework/plugins/helper"
+	frameworkruntime "github.com/shovanmaity/volume-scheduler/framework/runtime"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const zoneKey = "zone"
+
+func makeVolume(namespace, name, app string) *scpv1alpha1.StorageVolume {
+	return &scpv1alpha1.StorageVolume{ObjectMeta: metav1.ObjectMeta{
+		Namespace: namespace,
+		Name:      name,
+		UID:       types.UID(namespace + "/" + name),
+		Labels:    map[string]string{"app": app},
+	}}
+}
+
+// makePoolInfo returns a pool in a cohort of its own name, in the given zone if it isn't empty.
+func makePoolInfo(name, zone string, volumes ...*scpv1alpha1.StorageVolume) *framework.PoolInfo {
+	poolInfo := framework.NewPoolInfo(volumes...)
+	poolInfo.SetPool(&scpv1alpha1.StoragePool{ObjectMeta: metav1.ObjectMeta{
+		Namespace: "ns",
+		Name:      name,
+		Labels:    map[string]string{zoneKey: zone},
+	}})
+	cohort := &scpv1alpha1.StorageCohort{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name}}
+	if len(zone) != 0 {
+		cohort.Labels = map[string]string{zoneKey: zone}
+	}
+	poolInfo.SetCohort(cohort)
+	return poolInfo
+}
+
+// testPools returns the pools the tests run on: two database volumes in zone a, one in zone b
+// and none in zone c, where a database volume of another namespace is placed.
+func testPools() []*framework.PoolInfo {
+	return []*framework.PoolInfo{
+		makePoolInfo("pool-a1", "a", makeVolume("ns", "db-1", "db"), makeVolume("ns", "db-2", "db")),
+		makePoolInfo("pool-a2", "a"),
+		makePoolInfo("pool-b", "b", makeVolume("ns", "db-3", "db"), makeVolume("ns", "web", "web")),
+		makePoolInfo("pool-c", "c", makeVolume("other", "db", "db")),
+		makePoolInfo("pool-no-zone", ""),
+	}
+}
+
+func constraint(maxSkew int32, topologyKey string, action corev1.UnsatisfiableConstraintAction) corev1.TopologySpreadConstraint {
+	return corev1.TopologySpreadConstraint{
+		MaxSkew:           maxSkew,
+		TopologyKey:       topologyKey,
+		WhenUnsatisfiable: action,
+		LabelSelector:     &metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}},
+	}
+}
+
+func withConstraints(t *testing.T, volume *scpv1alpha1.StorageVolume,
+	constraints ...corev1.TopologySpreadConstraint) *scpv1alpha1.StorageVolume {
+	t.Helper()
+	if len(constraints) == 0 {
+		return volume
+	}
+	data, err := json.Marshal(constraints)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if volume.Annotations == nil {
+		volume.Annotations = map[string]string{}
+	}
+	volume.Annotations[helper.TopologySpreadConstraintsAnnotation] = string(data)
+	return volume
+}
+
+func newPlugin(t *testing.T, poolInfos []*framework.PoolInfo) *CohortTopologySpread {
+	t.Helper()
+	fh, err := frameworkruntime.NewFramework(nil, nil,
+		frameworkruntime.WithSnapshotPoolInfoLister(fake.PoolInfoLister(poolInfos)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := New(nil, fh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p.(*CohortTopologySpread)
+}
+
+// filter runs the Filter plugin on every pool and returns the codes.
+func filter(p *CohortTopologySpread, cycleState *framework.CycleState, volume *scpv1alpha1.StorageVolume,
+	poolInfos []*framework.PoolInfo) []framework.Code {
+	var codes []framework.Code
+	for _, poolInfo := range poolInfos {
+		codes = append(codes, p.Filter(context.Background(), cycleState, volume, poolInfo).Code())
+	}
+	return codes
+}
+
+func TestCohortTopologySpreadFilter(t *testing.T) {
+	const (
+		ok            = framework.Success
+		unschedulable = framework.Unschedulable
+		unresolvable  = framework.UnschedulableAndUnresolvable
+	)
+	tests := []struct {
+		name         string
+		volume       *scpv1alpha1.StorageVolume
+		constraints  []corev1.TopologySpreadConstraint
+		poolSelector string
+		want         []framework.Code
+	}{
+		{
+			name:   "volume without constraints",
+			volume: makeVolume("ns", "vol", "db"),
+			want:   []framework.Code{ok, ok, ok, ok, ok},
+		},
+		{
+			name:        "ScheduleAnyway constraints are ignored",
+			volume:      makeVolume("ns", "vol", "db"),
+			constraints: []corev1.TopologySpreadConstraint{constraint(1, zoneKey, corev1.ScheduleAnyway)},
+			want:        []framework.Code{ok, ok, ok, ok, ok},
+		},
+		{
+			name:        "only the zone with the fewest volumes keeps the skew",
+			volume:      makeVolume("ns", "vol", "db"),
+			constraints: []corev1.TopologySpreadConstraint{constraint(1, zoneKey, corev1.DoNotSchedule)},
+			want:        []framework.Code{unschedulable, unschedulable, unschedulable, ok, unresolvable},
+		},
+		{
+			name:        "a higher max skew allows more zones",
+			volume:      makeVolume("ns", "vol", "db"),
+			constraints: []corev1.TopologySpreadConstraint{constraint(2, zoneKey, corev1.DoNotSchedule)},
+			want:        []framework.Code{unschedulable, unschedulable, ok, ok, unresolvable},
+		},
+		{
+			name:        "volume not matching the constraint doesn't count itself",
+			volume:      makeVolume("ns", "vol", "web"),
+			constraints: []corev1.TopologySpreadConstraint{constraint(1, zoneKey, corev1.DoNotSchedule)},
+			want:        []framework.Code{unschedulable, unschedulable, ok, ok, unresolvable},
+		},
+		{
+			name:        "spread over the cohorts",
+			volume:      makeVolume("ns", "vol", "db"),
+			constraints: []corev1.TopologySpreadConstraint{constraint(1, "", corev1.DoNotSchedule)},
+			want:        []framework.Code{unschedulable, ok, unschedulable, ok, ok},
+		},
+		{
+			name:         "pools not selected by the volume are not counted",
+			volume:       makeVolume("ns", "vol", "db"),
+			constraints:  []corev1.TopologySpreadConstraint{constraint(1, zoneKey, corev1.DoNotSchedule)},
+			poolSelector: "zone!=c",
+			want:         []framework.Code{unschedulable, unschedulable, ok, ok, unresolvable},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			volume := withConstraints(t, tt.volume, tt.constraints...)
+			if len(tt.poolSelector) != 0 {
+				volume.Annotations[helper.PoolSelectorAnnotation] = tt.poolSelector
+			}
+			poolInfos := testPools()
+			p := newPlugin(t, poolInfos)
+			cycleState := framework.NewCycleState()
+			if status := p.PreFilter(context.Background(), cycleState, volume); !status.IsSuccess() {
+				t.Fatalf("prefilter failed with status: %v", status)
+			}
+			if diff := cmp.Diff(tt.want, filter(p, cycleState, volume, poolInfos)); diff != "" {
+				t.Errorf("unexpected filter codes (-want, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestCohortTopologySpreadAddRemoveVolume(t *testing.T) {
+	volume := withConstraints(t, makeVolume("ns", "vol", "db"), constraint(1, zoneKey, corev1.DoNotSchedule))
+	poolInfos := testPools()
+	p := newPlugin(t, poolInfos)
+	cycleState := framework.NewCycleState()
+	if status := p.PreFilter(context.Background(), cycleState, volume); !status.IsSuccess() {
+		t.Fatalf("prefilter failed with status: %v", status)
+	}
+
+	// A database volume in zone c raises the smallest count, zone b keeps the skew again.
+	db := framework.NewVolumeInfo(makeVolume("ns", "db-4", "db"))
+	if status := p.AddVolume(context.Background(), cycleState, volume, db, poolInfos[3]); !status.IsSuccess() {
+		t.Fatalf("add volume failed with status: %v", status)
+	}
+	clone := cycleState.Clone()
+	want := []framework.Code{framework.Unschedulable, framework.Unschedulable, framework.Success,
+		framework.Success, framework.UnschedulableAndUnresolvable}
+	if diff := cmp.Diff(want, filter(p, cycleState, volume, poolInfos)); diff != "" {
+		t.Errorf("unexpected filter codes after AddVolume (-want, +got): %s", diff)
+	}
+
+	if status := p.RemoveVolume(context.Background(), cycleState, volume, db, poolInfos[3]); !status.IsSuccess() {
+		t.Fatalf("remove volume failed with status: %v", status)
+	}
+	want = []framework.Code{framework.Unschedulable, framework.Unschedulable, framework.Unschedulable,
+		framework.Success, framework.UnschedulableAndUnresolvable}
+	if diff := cmp.Diff(want, filter(p, cycleState, volume, poolInfos)); diff != "" {
+		t.Errorf("unexpected filter codes after RemoveVolume (-want, +got): %s", diff)
+	}
+
+	// The clone of the state isn't changed by later updates of the original state.
+	want = []framework.Code{framework.Unschedulable, framework.Unschedulable, framework.Success,
+		framework.Success, framework.UnschedulableAndUnresolvable}
+	if diff := cmp.Diff(want, filter(p, clone, volume, poolInfos)); diff != "" {
+		t.Errorf("unexpected filter codes on the cloned state (-want, +got): %s", diff)
+	}
+}
+
+func TestCohortTopologySpreadScore(t *testing.T) {
+	tests := []struct {
+		name        string
+		constraints []corev1.TopologySpreadConstraint
+		want        []int64
+	}{
+		{
+			name: "volume without constraints",
+			want: []int64{0, 0, 0, 0, 0},
+		},
+		{
+			name:        "DoNotSchedule constraints are ignored",
+			constraints: []corev1.TopologySpreadConstraint{constraint(1, zoneKey, corev1.DoNotSchedule)},
+			want:        []int64{0, 0, 0, 0, 0},
+		},
+		{
+			name:        "spread over the zones, the pool without zone gets the lowest score",
+			constraints: []corev1.TopologySpreadConstraint{constraint(1, zoneKey, corev1.ScheduleAnyway)},
+			want:        []int64{0, 0, 33, 100, 0},
+		},
+		{
+			name:        "a higher max skew makes the constraint less significant",
+			constraints: []corev1.TopologySpreadConstraint{constraint(3, zoneKey, corev1.ScheduleAnyway)},
+			want:        []int64{40, 40, 60, 100, 0},
+		},
+		{
+			name:        "spread over the cohorts",
+			constraints: []corev1.TopologySpreadConstraint{constraint(1, "", corev1.ScheduleAnyway)},
+			want:        []int64{0, 100, 50, 100, 100},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			volume := withConstraints(t, makeVolume("ns", "vol", "db"), tt.constraints...)
+			poolInfos := testPools()
+			p := newPlugin(t, poolInfos)
+			cycleState := framework.NewCycleState()
+			var pools []*scpv1alpha1.StoragePool
+			for _, poolInfo := range poolInfos {
+				pools = append(pools, poolInfo.Pool)
+			}
+			if status := p.PreScore(context.Background(), cycleState, volume, pools); !status.IsSuccess() {
+				t.Fatalf("prescore failed with status: %v", status)
+			}
+			var scores framework.PoolScoreList
+			for _, pool := range pools {
+				score, status := p.Score(context.Background(), cycleState, volume,
+					framework.PoolReference(pool), pool.Spec.StorageCohortReference)
+				if !status.IsSuccess() {
+					t.Fatalf("score failed with status: %v", status)
+				}
+				scores = append(scores, framework.PoolScore{Namespace: pool.Namespace, Name: pool.Name, Score: score})
+			}
+			if status := p.NormalizeScore(context.Background(), cycleState, volume, scores); !status.IsSuccess() {
+				t.Fatalf("normalize score failed with status: %v", status)
+			}
+			var got []int64
+			for _, s := range scores {
+				got = append(got, s.Score)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("unexpected scores (-want, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestCohortTopologySpreadInvalidConstraints(t *testing.T) {
+	volume := withConstraints(t, makeVolume("ns", "vol", "db"), constraint(0, zoneKey, corev1.DoNotSchedule))
+	p := newPlugin(t, testPools())
+	if status := p.PreFilter(context.Background(), framework.NewCycleState(), volume); status.Code() != framework.Error {
+		t.Errorf("got status %v, want an error", status)
+	}
+}
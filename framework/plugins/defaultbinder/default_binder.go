@@ -0,0 +1,82 @@
+package defaultbinder
+
+import (
+	"context"
+	"fmt"
+
+	scpv1alpha1 "github.com/openebs/device-localpv/pkg/apis/openebs.io/scp/v1alpha1"
+	"github.com/shovanmaity/volume-scheduler/framework"
+	"github.com/shovanmaity/volume-scheduler/framework/plugins/names"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+)
+
+// Name of the plugin used in the plugin registry and configurations.
+const Name = names.DefaultBinder
+
+// DefaultBinder binds volumes to pools by writing the selected pool and cohort to the
+// StorageVolume.
+type DefaultBinder struct {
+	handle framework.Handle
+}
+
+var _ framework.BindPlugin = &DefaultBinder{}
+
+// Name returns the name of the plugin.
+func (b *DefaultBinder) Name() string {
+	return Name
+}
+
+// Bind binds volumes to pools using the clientset. The update is retried with the latest
+// version of the volume when it conflicts with another writer.
+func (b *DefaultBinder) Bind(ctx context.Context, state *framework.CycleState, volume *scpv1alpha1.StorageVolume,
+	pool, cohort *corev1.ObjectReference) *framework.Status {
+	klog.V(3).InfoS("Attempting to bind volume to pool", "volume", klog.KObj(volume), "pool", pool.Name)
+	client := b.handle.ClientSet().ScpV1alpha1().StorageVolumes(volume.Namespace)
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		current, err := client.Get(ctx, volume.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if current.UID != volume.UID {
+			return apierrors.NewNotFound(schema.GroupResource{
+				Group:    scpv1alpha1.SchemeGroupVersion.Group,
+				Resource: "storagevolumes",
+			}, volume.Name)
+		}
+		if ref := current.Spec.StoragePoolReference; ref != nil && len(ref.Name) != 0 {
+			if ref.Name == pool.Name {
+				// Already bound, e.g. by a previous attempt whose response was lost.
+				return nil
+			}
+			return fmt.Errorf("volume is already bound to pool %q", ref.Name)
+		}
+		current.Spec.StoragePoolReference = pool
+		current.Spec.StorageCohortReference = cohort
+		_, err = client.Update(ctx, current, metav1.UpdateOptions{})
+		return err
+	})
+	switch {
+	case err == nil:
+		return nil
+	case apierrors.IsNotFound(err):
+		return framework.AsStatus(fmt.Errorf("binding volume %s to pool %s: volume was deleted: %w",
+			klog.KObj(volume), pool.Name, err))
+	default:
+		return framework.AsStatus(fmt.Errorf("binding volume %s to pool %s: %w", klog.KObj(volume),
+			pool.Name, err))
+	}
+}
+
+// New creates a DefaultBinder.
+func New(_ runtime.Object, handle framework.Handle) (framework.Plugin, error) {
+	if handle.ClientSet() == nil {
+		return nil, fmt.Errorf("%s requires a clientset", Name)
+	}
+	return &DefaultBinder{handle: handle}, nil
+}
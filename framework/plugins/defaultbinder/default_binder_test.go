@@ -0,0 +1,127 @@
+package defaultbinder
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	scpv1alpha1 "github.com/openebs/device-localpv/pkg/apis/openebs.io/scp/v1alpha1"
+	"github.com/openebs/device-localpv/pkg/generated/clientset/scp/internalclientset/fake"
+	"github.com/shovanmaity/volume-scheduler/framework"
+	frameworkruntime "github.com/shovanmaity/volume-scheduler/framework/runtime"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestDefaultBinder(t *testing.T) {
+	pool := &corev1.ObjectReference{Kind: "StoragePool", Namespace: "ns", Name: "pool-a"}
+	cohort := &corev1.ObjectReference{Kind: "StorageCohort", Namespace: "ns", Name: "cohort-a"}
+	newVolume := func(poolName string) *scpv1alpha1.StorageVolume {
+		v := &scpv1alpha1.StorageVolume{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "vol", UID: "vol-uid"},
+		}
+		if len(poolName) != 0 {
+			v.Spec.StoragePoolReference = &corev1.ObjectReference{Name: poolName}
+		}
+		return v
+	}
+
+	tests := []struct {
+		name string
+		// existing is the volume the clientset holds, nil if it holds none.
+		existing *scpv1alpha1.StorageVolume
+		// conflicts is the number of updates which fail with a conflict.
+		conflicts  int
+		wantErr    bool
+		wantPool   string
+		wantUpdate bool
+	}{
+		{
+			name:       "unbound volume",
+			existing:   newVolume(""),
+			wantPool:   pool.Name,
+			wantUpdate: true,
+		},
+		{
+			name:       "conflicting update is retried",
+			existing:   newVolume(""),
+			conflicts:  2,
+			wantPool:   pool.Name,
+			wantUpdate: true,
+		},
+		{
+			name:     "already bound to the pool",
+			existing: newVolume(pool.Name),
+			wantPool: pool.Name,
+		},
+		{
+			name:     "bound to another pool",
+			existing: newVolume("pool-b"),
+			wantErr:  true,
+			wantPool: "pool-b",
+		},
+		{
+			name:    "deleted volume",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var objects []runtime.Object
+			if tt.existing != nil {
+				objects = append(objects, tt.existing)
+			}
+			client := fake.NewSimpleClientset(objects...)
+			conflicts, updates := tt.conflicts, 0
+			client.PrependReactor("update", "storagevolumes",
+				func(action clienttesting.Action) (bool, runtime.Object, error) {
+					if conflicts == 0 {
+						updates++
+						return false, nil, nil
+					}
+					conflicts--
+					return true, nil, apierrors.NewConflict(schema.GroupResource{
+						Group:    scpv1alpha1.SchemeGroupVersion.Group,
+						Resource: "storagevolumes",
+					}, "vol", nil)
+				})
+
+			fh, err := frameworkruntime.NewFramework(nil, nil, frameworkruntime.WithClientSet(client))
+			if err != nil {
+				t.Fatal(err)
+			}
+			pl, err := New(nil, fh)
+			if err != nil {
+				t.Fatal(err)
+			}
+			status := pl.(framework.BindPlugin).Bind(context.Background(), framework.NewCycleState(),
+				newVolume(""), pool, cohort)
+			if gotErr := !status.IsSuccess(); gotErr != tt.wantErr {
+				t.Errorf("got status %v, want error %v", status, tt.wantErr)
+			}
+			if gotUpdate := updates != 0; gotUpdate != tt.wantUpdate {
+				t.Errorf("got %d updates, want an update %v", updates, tt.wantUpdate)
+			}
+			if tt.existing == nil {
+				return
+			}
+			got, err := client.ScpV1alpha1().StorageVolumes("ns").Get(context.Background(), "vol",
+				metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.wantPool, got.Spec.StoragePoolReference.Name); diff != "" {
+				t.Errorf("unexpected pool (-want, +got): %s", diff)
+			}
+			if tt.wantUpdate {
+				if diff := cmp.Diff(cohort, got.Spec.StorageCohortReference); diff != "" {
+					t.Errorf("unexpected cohort (-want, +got): %s", diff)
+				}
+			}
+		})
+	}
+}
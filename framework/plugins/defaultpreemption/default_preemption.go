@@ -0,0 +1,235 @@
+package defaultpreemption
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	scpv1alpha1 "github.com/openebs/device-localpv/pkg/apis/openebs.io/scp/v1alpha1"
+	"github.com/shovanmaity/volume-scheduler/framework"
+	"github.com/shovanmaity/volume-scheduler/framework/parallelize"
+	"github.com/shovanmaity/volume-scheduler/framework/plugins/helper"
+	"github.com/shovanmaity/volume-scheduler/framework/plugins/names"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2"
+)
+
+// Name of the plugin used in the plugin registry and configurations.
+const Name = names.DefaultPreemption
+
+// DefaultPreemption is a PostFilter plugin implements the preemption logic. It finds a pool the
+// volume fits on once some volumes with a lower priority are evicted from it.
+type DefaultPreemption struct {
+	handle framework.Handle
+}
+
+var _ framework.PostFilterPlugin = &DefaultPreemption{}
+
+// candidate is a pool the volume fits on once the victims are evicted.
+type candidate struct {
+	poolKey string
+	victims []*scpv1alpha1.StorageVolume
+}
+
+// Name returns name of the plugin. It is used in logs, etc.
+func (pl *DefaultPreemption) Name() string {
+	return Name
+}
+
+// PostFilter invoked at the postFilter extension point. It simulates the eviction of lower
+// priority volumes from the pools the volume failed to fit on, and nominates the pool which
+// needs the fewest and the lowest priority victims.
+func (pl *DefaultPreemption) PostFilter(ctx context.Context, state *framework.CycleState,
+	volume *scpv1alpha1.StorageVolume, m framework.PoolToStatusMap) (*framework.PostFilterResult, *framework.Status) {
+	poolInfos, err := pl.handle.SnapshotPoolInfoLister().List()
+	if err != nil {
+		return nil, framework.AsStatus(fmt.Errorf("listing pools: %w", err))
+	}
+	potentialPools := poolsWherePreemptionMightHelp(poolInfos, m)
+	if len(potentialPools) == 0 {
+		klog.V(3).InfoS("Preemption will not help schedule volume on any pool", "volume", klog.KObj(volume))
+		return nil, framework.NewStatus(framework.Unschedulable,
+			fmt.Sprintf("preemption: 0/%d pools are available", len(poolInfos)))
+	}
+
+	candidates, err := pl.findCandidates(ctx, state, volume, potentialPools)
+	if err != nil {
+		return nil, framework.AsStatus(err)
+	}
+	if len(candidates) == 0 {
+		return nil, framework.NewStatus(framework.Unschedulable,
+			fmt.Sprintf("preemption: 0/%d pools are available: no preemption victims found", len(poolInfos)))
+	}
+
+	best := selectCandidate(candidates)
+	// The victims which are still waiting on permit haven't been bound yet, rejecting them
+	// frees their capacity right away.
+	for _, victim := range best.victims {
+		if pl.handle.RejectWaitingVolume(victim.UID) {
+			klog.V(2).InfoS("Rejected waiting volume to make room for preemptor", "victim",
+				klog.KObj(victim), "preemptor", klog.KObj(volume))
+		}
+	}
+	klog.V(2).InfoS("Found preemption victims", "volume", klog.KObj(volume), "pool", best.poolKey,
+		"victims", len(best.victims))
+	return &framework.PostFilterResult{
+		NominatedPoolName: best.poolKey,
+		Victims:           best.victims,
+	}, nil
+}
+
+// poolsWherePreemptionMightHelp returns the pools which failed with an Unschedulable status.
+// Evicting volumes cannot help the pools which failed with UnschedulableAndUnresolvable, and the
+// pools which are not in the map were not evaluated.
+func poolsWherePreemptionMightHelp(poolInfos []*framework.PoolInfo,
+	m framework.PoolToStatusMap) []*framework.PoolInfo {
+	var potentialPools []*framework.PoolInfo
+	for _, poolInfo := range poolInfos {
+		if poolInfo.Pool == nil {
+			continue
+		}
+		status, ok := m[framework.GetPoolKey(poolInfo.Pool)]
+		if !ok || status.Code() == framework.UnschedulableAndUnresolvable {
+			continue
+		}
+		potentialPools = append(potentialPools, poolInfo)
+	}
+	return potentialPools
+}
+
+// findCandidates runs selectVictimsOnPool on every potential pool in parallel.
+func (pl *DefaultPreemption) findCandidates(ctx context.Context, state *framework.CycleState,
+	volume *scpv1alpha1.StorageVolume, potentialPools []*framework.PoolInfo) ([]candidate, error) {
+	var candidatesLock sync.Mutex
+	var candidates []candidate
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	errCh := parallelize.NewErrorChannel()
+	checkPool := func(i int) {
+		poolInfo := potentialPools[i].Clone()
+		victims, fits, err := pl.selectVictimsOnPool(ctx, state.Clone(), volume, poolInfo)
+		if err != nil {
+			errCh.SendErrorWithCancel(err, cancel)
+			return
+		}
+		if fits && len(victims) > 0 {
+			candidatesLock.Lock()
+			candidates = append(candidates, candidate{poolKey: framework.GetPoolKey(poolInfo.Pool), victims: victims})
+			candidatesLock.Unlock()
+		}
+	}
+	pl.handle.Parallelizer().UntilWithErrorChannel(ctx, len(potentialPools), checkPool, errCh)
+	if err := errCh.ReceiveError(); err != nil {
+		return nil, err
+	}
+	return candidates, nil
+}
+
+// selectVictimsOnPool finds the minimum set of volumes on the given pool that should be evicted
+// for the volume to fit. It first removes all the volumes with a lower priority, checks that the
+// volume fits, then adds them back, highest priority first, as long as the volume keeps fitting.
+// The ones which could not be added back are the victims. The state and poolInfo are modified,
+// the caller is expected to pass clones.
+func (pl *DefaultPreemption) selectVictimsOnPool(ctx context.Context, state *framework.CycleState,
+	volume *scpv1alpha1.StorageVolume, poolInfo *framework.PoolInfo) ([]*scpv1alpha1.StorageVolume, bool, error) {
+	removeVolume := func(vi *framework.VolumeInfo) error {
+		if err := poolInfo.RemoveVolume(vi.Volume); err != nil {
+			return err
+		}
+		return pl.handle.RunPreFilterExtensionRemoveVolume(ctx, state, volume, vi, poolInfo).AsError()
+	}
+	addVolume := func(vi *framework.VolumeInfo) error {
+		poolInfo.AddVolumeInfo(vi)
+		return pl.handle.RunPreFilterExtensionAddVolume(ctx, state, volume, vi, poolInfo).AsError()
+	}
+	fits := func() (bool, error) {
+		status := pl.handle.RunFilterPlugins(ctx, state, volume, poolInfo).Merge()
+		if status.Code() == framework.Error {
+			return false, status.AsError()
+		}
+		return status.IsSuccess(), nil
+	}
+
+	preemptorPriority := helper.VolumePriority(volume)
+	var potentialVictims []*framework.VolumeInfo
+	for _, vi := range poolInfo.Volumes {
+		if helper.VolumePriority(vi.Volume) < preemptorPriority {
+			potentialVictims = append(potentialVictims, vi)
+		}
+	}
+	if len(potentialVictims) == 0 {
+		return nil, false, nil
+	}
+	for _, vi := range potentialVictims {
+		if err := removeVolume(vi); err != nil {
+			return nil, false, err
+		}
+	}
+	if ok, err := fits(); !ok || err != nil {
+		return nil, false, err
+	}
+
+	// Try to reprieve as many volumes as possible, starting with the highest priority ones.
+	sort.SliceStable(potentialVictims, func(i, j int) bool {
+		return helper.VolumePriority(potentialVictims[i].Volume) > helper.VolumePriority(potentialVictims[j].Volume)
+	})
+	var victims []*scpv1alpha1.StorageVolume
+	for _, vi := range potentialVictims {
+		if err := addVolume(vi); err != nil {
+			return nil, false, err
+		}
+		ok, err := fits()
+		if err != nil {
+			return nil, false, err
+		}
+		if !ok {
+			if err := removeVolume(vi); err != nil {
+				return nil, false, err
+			}
+			victims = append(victims, vi.Volume)
+		}
+	}
+	return victims, true, nil
+}
+
+// selectCandidate picks the candidate with the fewest victims. Ties are broken by the lowest
+// highest victim priority, then by the lowest sum of the victim priorities, then by the pool
+// key so that the same input always selects the same pool.
+func selectCandidate(candidates []candidate) candidate {
+	sort.Slice(candidates, func(i, j int) bool {
+		ci, cj := candidates[i], candidates[j]
+		if len(ci.victims) != len(cj.victims) {
+			return len(ci.victims) < len(cj.victims)
+		}
+		maxI, sumI := victimPriorities(ci.victims)
+		maxJ, sumJ := victimPriorities(cj.victims)
+		if maxI != maxJ {
+			return maxI < maxJ
+		}
+		if sumI != sumJ {
+			return sumI < sumJ
+		}
+		return ci.poolKey < cj.poolKey
+	})
+	return candidates[0]
+}
+
+// victimPriorities returns the highest priority and the sum of the priorities of the victims.
+func victimPriorities(victims []*scpv1alpha1.StorageVolume) (int32, int64) {
+	var highest int32
+	var sum int64
+	for i, v := range victims {
+		p := helper.VolumePriority(v)
+		if i == 0 || p > highest {
+			highest = p
+		}
+		sum += int64(p)
+	}
+	return highest, sum
+}
+
+// New initializes a new plugin and returns it.
+func New(_ runtime.Object, fh framework.Handle) (framework.Plugin, error) {
+	return &DefaultPreemption{handle: fh}, nil
+}
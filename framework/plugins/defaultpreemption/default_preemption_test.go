@@ -0,0 +1,271 @@
+package defaultpreemption
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	scpv1alpha1 "github.com/openebs/device-localpv/pkg/apis/openebs.io/scp/v1alpha1"
+	"github.com/shovanmaity/volume-scheduler/apis/config"
+	"github.com/shovanmaity/volume-scheduler/framework"
+	"github.com/shovanmaity/volume-scheduler/framework/fake"
+	"github.com/shovanmaity/volume-scheduler/framework/plugins/helper"
+	"github.com/shovanmaity/volume-scheduler/framework/plugins/poolcapacityfit"
+	"github.com/shovanmaity/volume-scheduler/framework/plugins/queuesort"
+	frameworkruntime "github.com/shovanmaity/volume-scheduler/framework/runtime"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func makeVolume(name, capacity, priority string) *scpv1alpha1.StorageVolume {
+	v := &scpv1alpha1.StorageVolume{ObjectMeta: metav1.ObjectMeta{
+		Namespace:   "ns",
+		Name:        name,
+		UID:         types.UID(name),
+		Annotations: map[string]string{helper.VolumePriorityAnnotation: priority},
+	}}
+	v.Spec.Capacity = resource.MustParse(capacity)
+	return v
+}
+
+// makePoolInfo returns a pool of 10Gi holding the volumes.
+func makePoolInfo(namespace, name string, volumes ...*scpv1alpha1.StorageVolume) *framework.PoolInfo {
+	pool := &scpv1alpha1.StoragePool{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}
+	pool.Status.Capacity.Total = resource.MustParse("10Gi")
+	poolInfo := framework.NewPoolInfo(volumes...)
+	poolInfo.SetPool(pool)
+	return poolInfo
+}
+
+// newPlugin returns the plugin running on a framework which filters the pools by their free
+// capacity.
+func newPlugin(t *testing.T, poolInfos []*framework.PoolInfo) (*DefaultPreemption, *frameworkruntime.Framework) {
+	t.Helper()
+	registry := frameworkruntime.Registry{
+		queuesort.Name:       queuesort.New,
+		poolcapacityfit.Name: poolcapacityfit.New,
+	}
+	capacityFit := config.PluginSet{Enabled: []config.Plugin{{Name: poolcapacityfit.Name}}}
+	profile := &config.VolumeSchedulerProfile{
+		SchedulerName: "test",
+		Plugins: &config.Plugins{
+			QueueSort: config.PluginSet{Enabled: []config.Plugin{{Name: queuesort.Name}}},
+			PreFilter: capacityFit,
+			Filter:    capacityFit,
+		},
+	}
+	fwk, err := frameworkruntime.NewFramework(registry, profile,
+		frameworkruntime.WithSnapshotPoolInfoLister(fake.PoolInfoLister(poolInfos)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := New(nil, fwk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p.(*DefaultPreemption), fwk
+}
+
+// unschedulable returns the statuses of pools which failed with an Unschedulable status.
+func unschedulable(poolInfos ...*framework.PoolInfo) framework.PoolToStatusMap {
+	m := make(framework.PoolToStatusMap)
+	for _, poolInfo := range poolInfos {
+		m[framework.GetPoolKey(poolInfo.Pool)] = framework.NewStatus(framework.Unschedulable, "full")
+	}
+	return m
+}
+
+func TestPostFilter(t *testing.T) {
+	tests := []struct {
+		name        string
+		poolInfos   []*framework.PoolInfo
+		statuses    func(poolInfos []*framework.PoolInfo) framework.PoolToStatusMap
+		wantPool    string
+		wantVictims []string
+		wantCode    framework.Code
+	}{
+		{
+			name: "no pool failed",
+			poolInfos: []*framework.PoolInfo{
+				makePoolInfo("ns", "pool-a", makeVolume("a1", "8Gi", "1")),
+			},
+			statuses: func([]*framework.PoolInfo) framework.PoolToStatusMap { return nil },
+			wantCode: framework.Unschedulable,
+		},
+		{
+			name: "volumes with the same or a higher priority are not victims",
+			poolInfos: []*framework.PoolInfo{
+				makePoolInfo("ns", "pool-a", makeVolume("a1", "4Gi", "100"), makeVolume("a2", "4Gi", "200")),
+			},
+			statuses: func(poolInfos []*framework.PoolInfo) framework.PoolToStatusMap {
+				return unschedulable(poolInfos...)
+			},
+			wantCode: framework.Unschedulable,
+		},
+		{
+			name: "evicting all the lower priority volumes doesn't make room",
+			poolInfos: []*framework.PoolInfo{
+				makePoolInfo("ns", "pool-a", makeVolume("a1", "2Gi", "1"), makeVolume("a2", "7Gi", "200")),
+			},
+			statuses: func(poolInfos []*framework.PoolInfo) framework.PoolToStatusMap {
+				return unschedulable(poolInfos...)
+			},
+			wantCode: framework.Unschedulable,
+		},
+		{
+			name: "higher priority volumes are reprieved first",
+			poolInfos: []*framework.PoolInfo{
+				makePoolInfo("ns", "pool-a", makeVolume("a1", "3Gi", "1"), makeVolume("a2", "3Gi", "3"),
+					makeVolume("a3", "3Gi", "2")),
+			},
+			statuses: func(poolInfos []*framework.PoolInfo) framework.PoolToStatusMap {
+				return unschedulable(poolInfos...)
+			},
+			wantPool:    "ns/pool-a",
+			wantVictims: []string{"a3", "a1"},
+		},
+		{
+			name: "only the pools which failed are candidates",
+			poolInfos: []*framework.PoolInfo{
+				makePoolInfo("ns", "pool-a", makeVolume("a1", "8Gi", "1")),
+				makePoolInfo("other", "pool-a", makeVolume("b1", "8Gi", "1")),
+			},
+			statuses: func(poolInfos []*framework.PoolInfo) framework.PoolToStatusMap {
+				return unschedulable(poolInfos[1])
+			},
+			wantPool:    "other/pool-a",
+			wantVictims: []string{"b1"},
+		},
+		{
+			name: "pools which failed with UnschedulableAndUnresolvable are not candidates",
+			poolInfos: []*framework.PoolInfo{
+				makePoolInfo("ns", "pool-a", makeVolume("a1", "8Gi", "1")),
+				makePoolInfo("ns", "pool-b", makeVolume("b1", "4Gi", "1"), makeVolume("b2", "4Gi", "2")),
+			},
+			statuses: func(poolInfos []*framework.PoolInfo) framework.PoolToStatusMap {
+				m := unschedulable(poolInfos[1])
+				m[framework.GetPoolKey(poolInfos[0].Pool)] = framework.NewStatus(
+					framework.UnschedulableAndUnresolvable, "unresolvable")
+				return m
+			},
+			wantPool:    "ns/pool-b",
+			wantVictims: []string{"b1"},
+		},
+		{
+			name: "no candidate left once the unresolvable pools are skipped",
+			poolInfos: []*framework.PoolInfo{
+				makePoolInfo("ns", "pool-a", makeVolume("a1", "8Gi", "1")),
+			},
+			statuses: func(poolInfos []*framework.PoolInfo) framework.PoolToStatusMap {
+				return framework.PoolToStatusMap{framework.GetPoolKey(poolInfos[0].Pool): framework.NewStatus(
+					framework.UnschedulableAndUnresolvable, "unresolvable")}
+			},
+			wantCode: framework.Unschedulable,
+		},
+		{
+			name: "pool with the fewest victims",
+			poolInfos: []*framework.PoolInfo{
+				makePoolInfo("ns", "pool-a", makeVolume("a1", "3Gi", "1"), makeVolume("a2", "3Gi", "1"),
+					makeVolume("a3", "3Gi", "1")),
+				makePoolInfo("ns", "pool-b", makeVolume("b1", "8Gi", "50")),
+			},
+			statuses: func(poolInfos []*framework.PoolInfo) framework.PoolToStatusMap {
+				return unschedulable(poolInfos...)
+			},
+			wantPool:    "ns/pool-b",
+			wantVictims: []string{"b1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, fwk := newPlugin(t, tt.poolInfos)
+			volume := makeVolume("preemptor", "5Gi", "100")
+			state := framework.NewCycleState()
+			if status := fwk.RunPreFilterPlugins(context.Background(), state, volume); !status.IsSuccess() {
+				t.Fatalf("prefilter failed with status: %v", status)
+			}
+			result, status := p.PostFilter(context.Background(), state, volume, tt.statuses(tt.poolInfos))
+			if wantCode := tt.wantCode; status.Code() != wantCode {
+				t.Fatalf("got status %v, want code %v", status, wantCode)
+			}
+			var gotPool string
+			var gotVictims []string
+			if result != nil {
+				gotPool = result.NominatedPoolName
+				for _, v := range result.Victims {
+					gotVictims = append(gotVictims, v.Name)
+				}
+			}
+			if gotPool != tt.wantPool {
+				t.Errorf("got nominated pool %q, want %q", gotPool, tt.wantPool)
+			}
+			if diff := cmp.Diff(tt.wantVictims, gotVictims); diff != "" {
+				t.Errorf("unexpected victims (-want, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestSelectCandidate(t *testing.T) {
+	victims := func(priorities ...string) []*scpv1alpha1.StorageVolume {
+		var volumes []*scpv1alpha1.StorageVolume
+		for _, p := range priorities {
+			volumes = append(volumes, makeVolume("v"+p, "1Gi", p))
+		}
+		return volumes
+	}
+	tests := []struct {
+		name       string
+		candidates []candidate
+		want       string
+	}{
+		{
+			name: "fewest victims",
+			candidates: []candidate{
+				{poolKey: "ns/pool-a", victims: victims("1", "1")},
+				{poolKey: "ns/pool-b", victims: victims("50")},
+			},
+			want: "ns/pool-b",
+		},
+		{
+			name: "lowest highest victim priority",
+			candidates: []candidate{
+				{poolKey: "ns/pool-a", victims: victims("1", "5")},
+				{poolKey: "ns/pool-b", victims: victims("3", "4")},
+			},
+			want: "ns/pool-b",
+		},
+		{
+			name: "lowest sum of the victim priorities",
+			candidates: []candidate{
+				{poolKey: "ns/pool-a", victims: victims("5", "5")},
+				{poolKey: "ns/pool-b", victims: victims("1", "5")},
+			},
+			want: "ns/pool-b",
+		},
+		{
+			name: "negative priorities",
+			candidates: []candidate{
+				{poolKey: "ns/pool-a", victims: victims("-1")},
+				{poolKey: "ns/pool-b", victims: victims("-5")},
+			},
+			want: "ns/pool-b",
+		},
+		{
+			name: "lowest pool key",
+			candidates: []candidate{
+				{poolKey: "other/pool-a", victims: victims("1")},
+				{poolKey: "ns/pool-b", victims: victims("1")},
+			},
+			want: "ns/pool-b",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := selectCandidate(tt.candidates).poolKey; got != tt.want {
+				t.Errorf("got pool %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package helper
+
+import (
+	"encoding/json"
+	"fmt"
+
+	scpv1alpha1 "github.com/openebs/device-localpv/pkg/apis/openebs.io/scp/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PreferredAffinityAnnotation is the annotation holding the preferred affinity of a
+// StorageVolume, as a JSON encoded PreferredAffinity. Required affinity is part of the
+// StorageVolume spec, the scheduler tries to satisfy preferred affinity without rejecting the
+// volume when it can't.
+const PreferredAffinityAnnotation = "volume-scheduler.openebs.io/preferred-affinity"
+
+// PreferredAffinity is a group of preferred affinity scheduling rules.
+type PreferredAffinity struct {
+	// VolumeAffinity prefers placing the volume in the same topology as the matching volumes.
+	VolumeAffinity []WeightedVolumeAffinityTerm `json:"volumeAffinity,omitempty"`
+	// VolumeAntiAffinity prefers placing the volume in a different topology than the matching
+	// volumes.
+	VolumeAntiAffinity []WeightedVolumeAffinityTerm `json:"volumeAntiAffinity,omitempty"`
+	// CohortAffinity prefers placing the volume in the cohorts matching the selectors.
+	CohortAffinity []WeightedCohortAffinityTerm `json:"cohortAffinity,omitempty"`
+	// CohortAntiAffinity prefers not placing the volume in the cohorts matching the selectors.
+	CohortAntiAffinity []WeightedCohortAffinityTerm `json:"cohortAntiAffinity,omitempty"`
+}
+
+// WeightedVolumeAffinityTerm is a volume affinity term with a weight in the range 1-100.
+type WeightedVolumeAffinityTerm struct {
+	Weight             int32                          `json:"weight"`
+	VolumeAffinityTerm scpv1alpha1.VolumeAffinityTerm `json:"volumeAffinityTerm"`
+}
+
+// WeightedCohortAffinityTerm is a cohort selector with a weight in the range 1-100.
+type WeightedCohortAffinityTerm struct {
+	Weight        int32                `json:"weight"`
+	LabelSelector metav1.LabelSelector `json:"labelSelector"`
+}
+
+// GetPreferredAffinity returns the preferred affinity of the volume, nil if the volume doesn't
+// have the preferred affinity annotation.
+func GetPreferredAffinity(volume *scpv1alpha1.StorageVolume) (*PreferredAffinity, error) {
+	value, ok := volume.GetAnnotations()[PreferredAffinityAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	affinity := &PreferredAffinity{}
+	if err := json.Unmarshal([]byte(value), affinity); err != nil {
+		return nil, fmt.Errorf("parsing annotation %q: %w", PreferredAffinityAnnotation, err)
+	}
+	return affinity, nil
+}
+
+// TopologyValue returns the topology value of the cohort for the given topology key. An empty
+// key stands for the cohort itself. It returns false if the cohort doesn't have the key.
+func TopologyValue(cohort *scpv1alpha1.StorageCohort, topologyKey string) (string, bool) {
+	if cohort == nil {
+		return "", false
+	}
+	if topologyKey == "" {
+		return cohort.Name, true
+	}
+	value, ok := cohort.Labels[topologyKey]
+	return value, ok
+}
@@ -0,0 +1,65 @@
+package helper
+
+import (
+	"github.com/shovanmaity/volume-scheduler/framework"
+)
+
+// MinMaxNormalizeScore scales the scores, which may be negative, linearly into the range
+// [MinPoolScore, MaxPoolScore]. The lowest score becomes MinPoolScore and the highest one
+// MaxPoolScore. All scores become MinPoolScore if they are equal.
+func MinMaxNormalizeScore(scores framework.PoolScoreList) {
+	if len(scores) == 0 {
+		return
+	}
+	minCount, maxCount := scores[0].Score, scores[0].Score
+	for i := range scores {
+		if scores[i].Score > maxCount {
+			maxCount = scores[i].Score
+		}
+		if scores[i].Score < minCount {
+			minCount = scores[i].Score
+		}
+	}
+
+	maxMinDiff := maxCount - minCount
+	for i := range scores {
+		fScore := float64(0)
+		if maxMinDiff > 0 {
+			fScore = float64(framework.MaxPoolScore) * (float64(scores[i].Score-minCount) / float64(maxMinDiff))
+		}
+		scores[i].Score = int64(fScore)
+	}
+}
+
+// DefaultNormalizeScore generates a Normalize Score function that can normalize the
+// scores to [0, maxPriority]. If reverse is set to true, it reverses the scores by
+// subtracting it from maxPriority.
+func DefaultNormalizeScore(maxPriority int64, reverse bool, scores framework.PoolScoreList) *framework.Status {
+	var maxCount int64
+	for i := range scores {
+		if scores[i].Score > maxCount {
+			maxCount = scores[i].Score
+		}
+	}
+
+	if maxCount == 0 {
+		if reverse {
+			for i := range scores {
+				scores[i].Score = maxPriority
+			}
+		}
+		return nil
+	}
+
+	for i := range scores {
+		score := scores[i].Score
+
+		score = maxPriority * score / maxCount
+		if reverse {
+			score = maxPriority - score
+		}
+
+		scores[i].Score = score
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package helper
+
+import (
+	"fmt"
+
+	scpv1alpha1 "github.com/openebs/device-localpv/pkg/apis/openebs.io/scp/v1alpha1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// PoolSelectorAnnotation is the annotation holding the pool selector of a StorageVolume, in the
+// label selector syntax, e.g. "tier=nvme,maintenance!=true". The volume can only be placed on the
+// pools whose labels match the selector.
+const PoolSelectorAnnotation = "volume-scheduler.openebs.io/pool-selector"
+
+// GetPoolSelector returns the pool selector of the volume, labels.Everything() if the volume has
+// no pool selector annotation.
+func GetPoolSelector(volume *scpv1alpha1.StorageVolume) (labels.Selector, error) {
+	value, ok := volume.GetAnnotations()[PoolSelectorAnnotation]
+	if !ok {
+		return labels.Everything(), nil
+	}
+	selector, err := labels.Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("parsing annotation %q: %w", PoolSelectorAnnotation, err)
+	}
+	return selector, nil
+}
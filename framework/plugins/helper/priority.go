@@ -0,0 +1,28 @@
+package helper
+
+import (
+	"strconv"
+
+	scpv1alpha1 "github.com/openebs/device-localpv/pkg/apis/openebs.io/scp/v1alpha1"
+)
+
+// VolumePriorityAnnotation is the annotation holding the priority of a StorageVolume. Volumes
+// with a higher priority are scheduled first and may preempt volumes with a lower priority.
+const VolumePriorityAnnotation = "volume-scheduler.openebs.io/priority"
+
+// DefaultVolumePriority is the priority of volumes without a valid priority annotation.
+const DefaultVolumePriority int32 = 0
+
+// VolumePriority returns the priority of the volume, DefaultVolumePriority if the volume has
+// no priority annotation or if it is not a valid 32 bit integer.
+func VolumePriority(volume *scpv1alpha1.StorageVolume) int32 {
+	value, ok := volume.GetAnnotations()[VolumePriorityAnnotation]
+	if !ok {
+		return DefaultVolumePriority
+	}
+	priority, err := strconv.ParseInt(value, 10, 32)
+	if err != nil {
+		return DefaultVolumePriority
+	}
+	return int32(priority)
+}
@@ -0,0 +1,73 @@
+package helper
+
+import (
+	"encoding/json"
+	"fmt"
+
+	scpv1alpha1 "github.com/openebs/device-localpv/pkg/apis/openebs.io/scp/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// PoolTaintsAnnotation is the annotation holding the taints of a StoragePool, as a JSON
+	// encoded list of taints.
+	PoolTaintsAnnotation = "volume-scheduler.openebs.io/taints"
+
+	// VolumeTolerationsAnnotation is the annotation holding the tolerations of a StorageVolume,
+	// as a JSON encoded list of tolerations.
+	VolumeTolerationsAnnotation = "volume-scheduler.openebs.io/tolerations"
+)
+
+// GetPoolTaints returns the taints of the pool, nil if the pool has no taints annotation.
+func GetPoolTaints(pool *scpv1alpha1.StoragePool) ([]corev1.Taint, error) {
+	value, ok := pool.GetAnnotations()[PoolTaintsAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	var taints []corev1.Taint
+	if err := json.Unmarshal([]byte(value), &taints); err != nil {
+		return nil, fmt.Errorf("parsing annotation %q: %w", PoolTaintsAnnotation, err)
+	}
+	return taints, nil
+}
+
+// GetVolumeTolerations returns the tolerations of the volume, nil if the volume has no
+// tolerations annotation.
+func GetVolumeTolerations(volume *scpv1alpha1.StorageVolume) ([]corev1.Toleration, error) {
+	value, ok := volume.GetAnnotations()[VolumeTolerationsAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	var tolerations []corev1.Toleration
+	if err := json.Unmarshal([]byte(value), &tolerations); err != nil {
+		return nil, fmt.Errorf("parsing annotation %q: %w", VolumeTolerationsAnnotation, err)
+	}
+	return tolerations, nil
+}
+
+// TolerationsTolerateTaint checks if taint is tolerated by any of the tolerations.
+func TolerationsTolerateTaint(tolerations []corev1.Toleration, taint *corev1.Taint) bool {
+	for i := range tolerations {
+		if tolerations[i].ToleratesTaint(taint) {
+			return true
+		}
+	}
+	return false
+}
+
+type taintsFilterFunc func(*corev1.Taint) bool
+
+// FindMatchingUntoleratedTaint checks if the given tolerations tolerates all the filtered taints,
+// and returns the first taint without a toleration.
+func FindMatchingUntoleratedTaint(taints []corev1.Taint, tolerations []corev1.Toleration,
+	inclusionFilter taintsFilterFunc) (corev1.Taint, bool) {
+	for i := range taints {
+		if inclusionFilter != nil && !inclusionFilter(&taints[i]) {
+			continue
+		}
+		if !TolerationsTolerateTaint(tolerations, &taints[i]) {
+			return taints[i], true
+		}
+	}
+	return corev1.Taint{}, false
+}
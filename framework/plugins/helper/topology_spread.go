@@ -0,0 +1,29 @@
+package helper
+
+import (
+	"encoding/json"
+	"fmt"
+
+	scpv1alpha1 "github.com/openebs/device-localpv/pkg/apis/openebs.io/scp/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// TopologySpreadConstraintsAnnotation is the annotation holding the topology spread constraints
+// of a StorageVolume, as a JSON encoded list of topology spread constraints. The topology key of
+// a constraint is a label of the StorageCohorts, an empty key spreads the volumes over the
+// cohorts themselves.
+const TopologySpreadConstraintsAnnotation = "volume-scheduler.openebs.io/topology-spread-constraints"
+
+// GetTopologySpreadConstraints returns the topology spread constraints of the volume, nil if the
+// volume has no topology spread constraints annotation.
+func GetTopologySpreadConstraints(volume *scpv1alpha1.StorageVolume) ([]corev1.TopologySpreadConstraint, error) {
+	value, ok := volume.GetAnnotations()[TopologySpreadConstraintsAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	var constraints []corev1.TopologySpreadConstraint
+	if err := json.Unmarshal([]byte(value), &constraints); err != nil {
+		return nil, fmt.Errorf("parsing annotation %q: %w", TopologySpreadConstraintsAnnotation, err)
+	}
+	return constraints, nil
+}
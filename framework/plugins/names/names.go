@@ -0,0 +1,15 @@
+// Package names holds the names of the in-tree plugins.
+package names
+
+const (
+	PrioritySort         = "PrioritySort"
+	PoolCapacityFit      = "PoolCapacityFit"
+	CohortAffinity       = "CohortAffinity"
+	VolumeAffinity       = "VolumeAffinity"
+	PoolSelector         = "PoolSelector"
+	TaintToleration      = "TaintToleration"
+	PoolResourcesFit     = "PoolResourcesFit"
+	CohortTopologySpread = "CohortTopologySpread"
+	DefaultPreemption    = "DefaultPreemption"
+	DefaultBinder        = "DefaultBinder"
+)
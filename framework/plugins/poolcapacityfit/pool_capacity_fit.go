@@ -0,0 +1,172 @@
+package poolcapacityfit
+
+import (
+	"context"
+	"fmt"
+
+	scpv1alpha1 "github.com/openebs/device-localpv/pkg/apis/openebs.io/scp/v1alpha1"
+	"github.com/shovanmaity/volume-scheduler/framework"
+	"github.com/shovanmaity/volume-scheduler/framework/plugins/names"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	// Name is the name of the plugin used in the plugin registry and configurations.
+	Name = names.PoolCapacityFit
+
+	// preFilterStateKey is the key in CycleState to PoolCapacityFit pre-computed data.
+	// Using the name of the plugin will likely help us avoid collisions with other plugins.
+	preFilterStateKey = "PreFilter" + Name
+
+	// ErrReasonInsufficientCapacity is used for insufficient pool capacity error.
+	ErrReasonInsufficientCapacity = "Insufficient capacity"
+)
+
+// PoolCapacityFit is a plugin that checks if a pool has enough free capacity for a volume.
+type PoolCapacityFit struct {
+	handle framework.Handle
+}
+
+var _ framework.PreFilterPlugin = &PoolCapacityFit{}
+var _ framework.PreFilterExtensions = &PoolCapacityFit{}
+var _ framework.FilterPlugin = &PoolCapacityFit{}
+var _ framework.EnqueueExtensions = &PoolCapacityFit{}
+
+// preFilterState computed at PreFilter and used at Filter.
+type preFilterState struct {
+	// request is the capacity requested by the volume being scheduled.
+	request resource.Quantity
+	// requested is the capacity already requested from each pool, keyed by pool namespace/name.
+	// It is updated by AddVolume and RemoveVolume while evaluating preemption.
+	requested map[string]resource.Quantity
+}
+
+// Clone the prefilter state.
+func (s *preFilterState) Clone() framework.StateData {
+	copy := &preFilterState{
+		request:   s.request.DeepCopy(),
+		requested: make(map[string]resource.Quantity, len(s.requested)),
+	}
+	for key, q := range s.requested {
+		copy.requested[key] = q.DeepCopy()
+	}
+	return copy
+}
+
+// Name returns name of the plugin.
+func (pl *PoolCapacityFit) Name() string {
+	return Name
+}
+
+// EventsToRegister returns the possible events that may make a volume failed by this plugin
+// schedulable: a new pool, more capacity on a pool or a volume freeing its capacity.
+func (pl *PoolCapacityFit) EventsToRegister() []framework.ClusterEvent {
+	return []framework.ClusterEvent{
+		{Resource: framework.StoragePool, ActionType: framework.Add | framework.UpdatePoolCapacity},
+		{Resource: framework.StorageVolume, ActionType: framework.Delete},
+	}
+}
+
+// PreFilter invoked at the prefilter extension point. It computes the capacity requested by the
+// volume and the capacity already requested from every pool in the snapshot.
+func (pl *PoolCapacityFit) PreFilter(ctx context.Context, cycleState *framework.CycleState,
+	volume *scpv1alpha1.StorageVolume) *framework.Status {
+	s := &preFilterState{
+		request:   volume.Spec.Capacity.DeepCopy(),
+		requested: make(map[string]resource.Quantity),
+	}
+	if lister := pl.handle.SnapshotPoolInfoLister(); lister != nil {
+		poolInfos, err := lister.List()
+		if err != nil {
+			return framework.AsStatus(fmt.Errorf("listing pools: %w", err))
+		}
+		for _, poolInfo := range poolInfos {
+			if poolInfo.Pool == nil {
+				continue
+			}
+			s.requested[framework.GetPoolKey(poolInfo.Pool)] = poolInfo.Requested.DeepCopy()
+		}
+	}
+	cycleState.Write(preFilterStateKey, s)
+	return nil
+}
+
+// PreFilterExtensions returns prefilter extensions, volume add and remove.
+func (pl *PoolCapacityFit) PreFilterExtensions() framework.PreFilterExtensions {
+	return pl
+}
+
+// AddVolume from pre-computed data in cycleState.
+func (pl *PoolCapacityFit) AddVolume(ctx context.Context, cycleState *framework.CycleState,
+	volumeToSchedule *scpv1alpha1.StorageVolume, volumeInfoToAdd *framework.VolumeInfo,
+	poolInfo *framework.PoolInfo) *framework.Status {
+	s, err := getPreFilterState(cycleState)
+	if err != nil {
+		return framework.AsStatus(err)
+	}
+	requested := s.requestedFor(poolInfo)
+	requested.Add(volumeInfoToAdd.Volume.Spec.Capacity)
+	s.requested[framework.GetPoolKey(poolInfo.Pool)] = requested
+	return nil
+}
+
+// RemoveVolume from pre-computed data in cycleState.
+func (pl *PoolCapacityFit) RemoveVolume(ctx context.Context, cycleState *framework.CycleState,
+	volumeToSchedule *scpv1alpha1.StorageVolume, volumeInfoToRemove *framework.VolumeInfo,
+	poolInfo *framework.PoolInfo) *framework.Status {
+	s, err := getPreFilterState(cycleState)
+	if err != nil {
+		return framework.AsStatus(err)
+	}
+	requested := s.requestedFor(poolInfo)
+	requested.Sub(volumeInfoToRemove.Volume.Spec.Capacity)
+	s.requested[framework.GetPoolKey(poolInfo.Pool)] = requested
+	return nil
+}
+
+// Filter invoked at the filter extension point. It checks if the pool has enough free capacity
+// to create the volume.
+func (pl *PoolCapacityFit) Filter(ctx context.Context, cycleState *framework.CycleState,
+	volume *scpv1alpha1.StorageVolume, poolInfo *framework.PoolInfo) *framework.Status {
+	if poolInfo.Pool == nil {
+		return framework.NewStatus(framework.Error, "pool not found")
+	}
+	s, err := getPreFilterState(cycleState)
+	if err != nil {
+		return framework.AsStatus(err)
+	}
+	free := poolInfo.Allocatable.DeepCopy()
+	free.Sub(s.requestedFor(poolInfo))
+	if free.Cmp(s.request) < 0 {
+		return framework.NewStatus(framework.Unschedulable, ErrReasonInsufficientCapacity)
+	}
+	return nil
+}
+
+// requestedFor returns the capacity requested from the pool, falling back to the pool info when
+// the pool was not part of the snapshot used at PreFilter.
+func (s *preFilterState) requestedFor(poolInfo *framework.PoolInfo) resource.Quantity {
+	if requested, ok := s.requested[framework.GetPoolKey(poolInfo.Pool)]; ok {
+		return requested.DeepCopy()
+	}
+	return poolInfo.Requested.DeepCopy()
+}
+
+func getPreFilterState(cycleState *framework.CycleState) (*preFilterState, error) {
+	c, err := cycleState.Read(preFilterStateKey)
+	if err != nil {
+		// preFilterState doesn't exist, likely PreFilter wasn't invoked.
+		return nil, fmt.Errorf("error reading %q from cycleState: %w", preFilterStateKey, err)
+	}
+	s, ok := c.(*preFilterState)
+	if !ok {
+		return nil, fmt.Errorf("%+v convert to poolcapacityfit.preFilterState error", c)
+	}
+	return s, nil
+}
+
+// New initializes a new plugin and returns it.
+func New(_ runtime.Object, handle framework.Handle) (framework.Plugin, error) {
+	return &PoolCapacityFit{handle: handle}, nil
+}
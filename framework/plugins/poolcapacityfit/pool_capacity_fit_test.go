@@ -0,0 +1,166 @@
+package poolcapacityfit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	scpv1alpha1 "github.com/openebs/device-localpv/pkg/apis/openebs.io/scp/v1alpha1"
+	"github.com/shovanmaity/volume-scheduler/framework"
+	"github.com/shovanmaity/volume-scheduler/framework/fake"
+	frameworkruntime "github.com/shovanmaity/volume-scheduler/framework/runtime"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func makeVolume(name, capacity string) *scpv1alpha1.StorageVolume {
+	v := &scpv1alpha1.StorageVolume{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name, UID: types.UID(name)},
+	}
+	v.Spec.Capacity = resource.MustParse(capacity)
+	return v
+}
+
+func makePoolInfo(namespace, name, total string, volumes ...*scpv1alpha1.StorageVolume) *framework.PoolInfo {
+	pool := &scpv1alpha1.StoragePool{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}
+	pool.Status.Capacity.Total = resource.MustParse(total)
+	poolInfo := framework.NewPoolInfo(volumes...)
+	poolInfo.SetPool(pool)
+	return poolInfo
+}
+
+func newPlugin(t *testing.T, poolInfos []*framework.PoolInfo) *PoolCapacityFit {
+	t.Helper()
+	fh, err := frameworkruntime.NewFramework(nil, nil,
+		frameworkruntime.WithSnapshotPoolInfoLister(fake.PoolInfoLister(poolInfos)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := New(nil, fh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p.(*PoolCapacityFit)
+}
+
+func TestPoolCapacityFit(t *testing.T) {
+	tests := []struct {
+		name     string
+		volume   *scpv1alpha1.StorageVolume
+		poolInfo *framework.PoolInfo
+		want     *framework.Status
+	}{
+		{
+			name:     "empty pool",
+			volume:   makeVolume("vol", "5Gi"),
+			poolInfo: makePoolInfo("ns", "pool", "10Gi"),
+		},
+		{
+			name:     "volume fits the free capacity exactly",
+			volume:   makeVolume("vol", "5Gi"),
+			poolInfo: makePoolInfo("ns", "pool", "10Gi", makeVolume("v1", "2Gi"), makeVolume("v2", "3Gi")),
+		},
+		{
+			name:     "insufficient free capacity",
+			volume:   makeVolume("vol", "5Gi"),
+			poolInfo: makePoolInfo("ns", "pool", "10Gi", makeVolume("v1", "6Gi")),
+			want:     framework.NewStatus(framework.Unschedulable, ErrReasonInsufficientCapacity),
+		},
+		{
+			name:     "volume larger than the pool",
+			volume:   makeVolume("vol", "20Gi"),
+			poolInfo: makePoolInfo("ns", "pool", "10Gi"),
+			want:     framework.NewStatus(framework.Unschedulable, ErrReasonInsufficientCapacity),
+		},
+		{
+			name:     "overcommitted pool",
+			volume:   makeVolume("vol", "1Mi"),
+			poolInfo: makePoolInfo("ns", "pool", "10Gi", makeVolume("v1", "12Gi")),
+			want:     framework.NewStatus(framework.Unschedulable, ErrReasonInsufficientCapacity),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newPlugin(t, []*framework.PoolInfo{tt.poolInfo})
+			cycleState := framework.NewCycleState()
+			if status := p.PreFilter(context.Background(), cycleState, tt.volume); !status.IsSuccess() {
+				t.Fatalf("prefilter failed with status: %v", status)
+			}
+			got := p.Filter(context.Background(), cycleState, tt.volume, tt.poolInfo)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("unexpected status (-want, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestPoolCapacityFitErrors(t *testing.T) {
+	volume := makeVolume("vol", "1Gi")
+	p := newPlugin(t, nil)
+	if status := p.Filter(context.Background(), framework.NewCycleState(), volume,
+		makePoolInfo("ns", "pool", "10Gi")); status.Code() != framework.Error {
+		t.Errorf("filter without prefilter: got status %v, want an error", status)
+	}
+
+	cycleState := framework.NewCycleState()
+	if status := p.PreFilter(context.Background(), cycleState, volume); !status.IsSuccess() {
+		t.Fatalf("prefilter failed with status: %v", status)
+	}
+	if status := p.Filter(context.Background(), cycleState, volume,
+		framework.NewPoolInfo()); status.Code() != framework.Error {
+		t.Errorf("filter on a removed pool: got status %v, want an error", status)
+	}
+}
+
+// TestPoolsWithTheSameName checks that the capacity requested from pools is tracked per
+// namespace, and that AddVolume and RemoveVolume only change the pool they are given.
+func TestPoolsWithTheSameName(t *testing.T) {
+	poolA := makePoolInfo("ns", "pool", "10Gi", makeVolume("v1", "4Gi"))
+	poolB := makePoolInfo("other", "pool", "10Gi", makeVolume("v2", "8Gi"))
+	victim := framework.NewVolumeInfo(makeVolume("v2", "8Gi"))
+	volume := makeVolume("vol", "5Gi")
+
+	p := newPlugin(t, []*framework.PoolInfo{poolA, poolB})
+	cycleState := framework.NewCycleState()
+	if status := p.PreFilter(context.Background(), cycleState, volume); !status.IsSuccess() {
+		t.Fatalf("prefilter failed with status: %v", status)
+	}
+	filter := func() []framework.Code {
+		return []framework.Code{
+			p.Filter(context.Background(), cycleState, volume, poolA).Code(),
+			p.Filter(context.Background(), cycleState, volume, poolB).Code(),
+		}
+	}
+	if diff := cmp.Diff([]framework.Code{framework.Success, framework.Unschedulable}, filter()); diff != "" {
+		t.Errorf("unexpected filter codes (-want, +got): %s", diff)
+	}
+
+	// Removing the volume of the second pool makes room for the volume there only.
+	if status := p.RemoveVolume(context.Background(), cycleState, volume, victim, poolB); !status.IsSuccess() {
+		t.Fatalf("remove volume failed with status: %v", status)
+	}
+	if diff := cmp.Diff([]framework.Code{framework.Success, framework.Success}, filter()); diff != "" {
+		t.Errorf("unexpected filter codes after RemoveVolume (-want, +got): %s", diff)
+	}
+
+	// Adding it to the first pool fills that pool instead, and doesn't touch the snapshot.
+	if status := p.AddVolume(context.Background(), cycleState, volume, victim, poolA); !status.IsSuccess() {
+		t.Fatalf("add volume failed with status: %v", status)
+	}
+	if diff := cmp.Diff([]framework.Code{framework.Unschedulable, framework.Success}, filter()); diff != "" {
+		t.Errorf("unexpected filter codes after AddVolume (-want, +got): %s", diff)
+	}
+	if got := poolA.Requested.String(); got != "4Gi" {
+		t.Errorf("AddVolume changed the requested capacity of the snapshot to %s", got)
+	}
+
+	// The clone of the state isn't changed by later updates of the original state.
+	clone := cycleState.Clone()
+	if status := p.RemoveVolume(context.Background(), cycleState, volume, victim, poolA); !status.IsSuccess() {
+		t.Fatalf("remove volume failed with status: %v", status)
+	}
+	if got := p.Filter(context.Background(), clone, volume, poolA).Code(); got != framework.Unschedulable {
+		t.Errorf("got code %v on the cloned state, want %v", got, framework.Unschedulable)
+	}
+}
@@ -0,0 +1,168 @@
+package poolresourcesfit
+
+import (
+	"context"
+	"fmt"
+
+	scpv1alpha1 "github.com/openebs/device-localpv/pkg/apis/openebs.io/scp/v1alpha1"
+	"github.com/shovanmaity/volume-scheduler/apis/config"
+	"github.com/shovanmaity/volume-scheduler/apis/config/v1alpha1"
+	"github.com/shovanmaity/volume-scheduler/apis/config/validation"
+	"github.com/shovanmaity/volume-scheduler/framework"
+	"github.com/shovanmaity/volume-scheduler/framework/plugins/helper"
+	"github.com/shovanmaity/volume-scheduler/framework/plugins/names"
+	frameworkruntime "github.com/shovanmaity/volume-scheduler/framework/runtime"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Name is the name of the plugin used in the plugin registry and configurations.
+const Name = names.PoolResourcesFit
+
+// scorer computes the score of a pool from the capacity requested from it, including the
+// volume being scheduled, and its allocatable capacity.
+type scorer func(requested, capacity int64) int64
+
+// PoolResourcesFit is a plugin that ranks the pools by their capacity, following the
+// configured scoring strategy.
+type PoolResourcesFit struct {
+	handle framework.Handle
+	scorer scorer
+}
+
+var _ framework.ScorePlugin = &PoolResourcesFit{}
+
+// Name returns name of the plugin.
+func (pl *PoolResourcesFit) Name() string {
+	return Name
+}
+
+// Score invoked at the Score extension point. It scores the pool as if the volume was placed
+// on it.
+func (pl *PoolResourcesFit) Score(ctx context.Context, cycleState *framework.CycleState,
+	volume *scpv1alpha1.StorageVolume, pool, cohort *corev1.ObjectReference) (int64, *framework.Status) {
+	lister := pl.handle.SnapshotPoolInfoLister()
+	if lister == nil {
+		return 0, framework.AsStatus(fmt.Errorf("no snapshot pool info lister"))
+	}
+	poolKey := framework.GetReferenceKey(pool, volume.Namespace)
+	poolInfo, err := lister.Get(poolKey)
+	if err != nil {
+		return 0, framework.AsStatus(fmt.Errorf("getting pool %q from Snapshot: %w", poolKey, err))
+	}
+	requested := poolInfo.Requested.DeepCopy()
+	requested.Add(volume.Spec.Capacity)
+	return pl.scorer(requested.Value(), poolInfo.Allocatable.Value()), nil
+}
+
+// NormalizeScore scales the scores so that the best pool gets MaxPoolScore.
+func (pl *PoolResourcesFit) NormalizeScore(ctx context.Context, cycleState *framework.CycleState,
+	volume *scpv1alpha1.StorageVolume, scores framework.PoolScoreList) *framework.Status {
+	return helper.DefaultNormalizeScore(framework.MaxPoolScore, false, scores)
+}
+
+// ScoreExtensions of the Score plugin.
+func (pl *PoolResourcesFit) ScoreExtensions() framework.ScoreExtensions {
+	return pl
+}
+
+// leastRequestedScore favors the pools with fewer requested capacity. It calculates the
+// percentage of capacity still free once the volume is placed on the pool, scaled to
+// MaxPoolScore.
+func leastRequestedScore(requested, capacity int64) int64 {
+	if capacity <= 0 || requested > capacity {
+		return 0
+	}
+	return ((capacity - requested) * framework.MaxPoolScore) / capacity
+}
+
+// mostRequestedScore favors the pools with most requested capacity. It calculates the
+// percentage of capacity requested once the volume is placed on the pool, scaled to
+// MaxPoolScore.
+func mostRequestedScore(requested, capacity int64) int64 {
+	if capacity <= 0 {
+		return 0
+	}
+	if requested > capacity {
+		// The pool is overcommitted, which the Filter plugins are expected to prevent.
+		requested = capacity
+	}
+	return (requested * framework.MaxPoolScore) / capacity
+}
+
+// functionShapePoint is a UtilizationShapePoint with its score scaled to MaxPoolScore.
+type functionShapePoint struct {
+	utilization int64
+	score       int64
+}
+
+// buildRequestedToCapacityRatioScorer returns a scorer following the piecewise linear function
+// defined by the shape. The shape is expected to be validated.
+func buildRequestedToCapacityRatioScorer(shape []config.UtilizationShapePoint) scorer {
+	points := make([]functionShapePoint, 0, len(shape))
+	for _, p := range shape {
+		points = append(points, functionShapePoint{
+			utilization: int64(p.Utilization),
+			score:       int64(p.Score) * (framework.MaxPoolScore / int64(config.MaxCustomPriorityScore)),
+		})
+	}
+	scoreFor := buildBrokenLinearFunction(points)
+	return func(requested, capacity int64) int64 {
+		if capacity <= 0 || requested > capacity {
+			return scoreFor(int64(config.MaxUtilization))
+		}
+		return scoreFor(requested * int64(config.MaxUtilization) / capacity)
+	}
+}
+
+// buildBrokenLinearFunction creates a function which is built using linear segments. Segments
+// are defined via shape array. Shape[i].utilization slice represents points on "utilization"
+// axis where different segments meet. Shape[i].score represents function values at meeting
+// points.
+//
+// function f(p) is defined as:
+//
+//	shape[0].score for p < shape[0].utilization
+//	shape[i].score for p == shape[i].utilization
+//	shape[n-1].score for p > shape[n-1].utilization
+//
+// and linear between points (p < shape[i].utilization)
+func buildBrokenLinearFunction(shape []functionShapePoint) func(int64) int64 {
+	return func(p int64) int64 {
+		for i := 0; i < len(shape); i++ {
+			if p <= shape[i].utilization {
+				if i == 0 {
+					return shape[0].score
+				}
+				return shape[i-1].score + (shape[i].score-shape[i-1].score)*
+					(p-shape[i-1].utilization)/(shape[i].utilization-shape[i-1].utilization)
+			}
+		}
+		return shape[len(shape)-1].score
+	}
+}
+
+// New initializes a new plugin and returns it.
+func New(obj runtime.Object, h framework.Handle) (framework.Plugin, error) {
+	versioned := &v1alpha1.PoolResourcesFitArgs{}
+	if err := frameworkruntime.DecodeInto(obj, versioned); err != nil {
+		return nil, fmt.Errorf("decoding %s args: %w", Name, err)
+	}
+	v1alpha1.SetDefaults_PoolResourcesFitArgs(versioned)
+	args := &config.PoolResourcesFitArgs{}
+	v1alpha1.Convert_v1alpha1_PoolResourcesFitArgs_To_config_PoolResourcesFitArgs(versioned, args)
+	if err := validation.ValidatePoolResourcesFitArgs(nil, args); err != nil {
+		return nil, err
+	}
+
+	pl := &PoolResourcesFit{handle: h}
+	switch args.ScoringStrategy.Type {
+	case config.LeastAllocated:
+		pl.scorer = leastRequestedScore
+	case config.MostAllocated:
+		pl.scorer = mostRequestedScore
+	case config.RequestedToCapacityRatio:
+		pl.scorer = buildRequestedToCapacityRatioScorer(args.ScoringStrategy.RequestedToCapacityRatio.Shape)
+	}
+	return pl, nil
+}
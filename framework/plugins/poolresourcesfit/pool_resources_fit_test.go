@@ -0,0 +1,188 @@
+package poolresourcesfit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	scpv1alpha1 "github.com/openebs/device-localpv/pkg/apis/openebs.io/scp/v1alpha1"
+	"github.com/shovanmaity/volume-scheduler/framework"
+	"github.com/shovanmaity/volume-scheduler/framework/fake"
+	frameworkruntime "github.com/shovanmaity/volume-scheduler/framework/runtime"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func makeVolume(name, capacity string) *scpv1alpha1.StorageVolume {
+	v := &scpv1alpha1.StorageVolume{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name, UID: types.UID(name)},
+	}
+	v.Spec.Capacity = resource.MustParse(capacity)
+	return v
+}
+
+func makePoolInfo(name, total string, volumes ...*scpv1alpha1.StorageVolume) *framework.PoolInfo {
+	pool := &scpv1alpha1.StoragePool{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name}}
+	pool.Status.Capacity.Total = resource.MustParse(total)
+	poolInfo := framework.NewPoolInfo(volumes...)
+	poolInfo.SetPool(pool)
+	return poolInfo
+}
+
+func newPlugin(t *testing.T, args string, lister framework.PoolInfoLister) *PoolResourcesFit {
+	t.Helper()
+	fh, err := frameworkruntime.NewFramework(nil, nil, frameworkruntime.WithSnapshotPoolInfoLister(lister))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var obj runtime.Object
+	if len(args) != 0 {
+		obj = &runtime.Unknown{Raw: []byte(args)}
+	}
+	p, err := New(obj, fh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p.(*PoolResourcesFit)
+}
+
+func TestPoolResourcesFitScore(t *testing.T) {
+	// The volume being scheduled requests 10Gi, which brings the pools to 10%, 50%, 100% and
+	// 110% of their capacity.
+	poolInfos := []*framework.PoolInfo{
+		makePoolInfo("empty", "100Gi"),
+		makePoolInfo("half", "100Gi", makeVolume("v1", "40Gi")),
+		makePoolInfo("full", "100Gi", makeVolume("v2", "90Gi")),
+		makePoolInfo("overcommitted", "100Gi", makeVolume("v3", "100Gi")),
+		makePoolInfo("no-capacity", "0"),
+	}
+	tests := []struct {
+		name string
+		args string
+		want []int64
+	}{
+		{
+			name: "default strategy",
+			want: []int64{90, 50, 0, 0, 0},
+		},
+		{
+			name: "LeastAllocated",
+			args: `{"scoringStrategy":{"type":"LeastAllocated"}}`,
+			want: []int64{90, 50, 0, 0, 0},
+		},
+		{
+			name: "MostAllocated",
+			args: `{"scoringStrategy":{"type":"MostAllocated"}}`,
+			want: []int64{10, 50, 100, 100, 0},
+		},
+		{
+			name: "RequestedToCapacityRatio with the default shape",
+			args: `{"scoringStrategy":{"type":"RequestedToCapacityRatio"}}`,
+			want: []int64{90, 50, 0, 0, 0},
+		},
+		{
+			name: "RequestedToCapacityRatio with a custom shape",
+			args: `{"scoringStrategy":{"type":"RequestedToCapacityRatio","requestedToCapacityRatio":{"shape":[
+				{"utilization":0,"score":0},{"utilization":50,"score":10},{"utilization":100,"score":2}]}}}`,
+			want: []int64{20, 100, 20, 20, 20},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newPlugin(t, tt.args, fake.PoolInfoLister(poolInfos))
+			volume := makeVolume("vol", "10Gi")
+			var got []int64
+			for _, poolInfo := range poolInfos {
+				score, status := p.Score(context.Background(), framework.NewCycleState(), volume,
+					framework.PoolReference(poolInfo.Pool), nil)
+				if !status.IsSuccess() {
+					t.Fatalf("score failed with status: %v", status)
+				}
+				got = append(got, score)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("unexpected scores (-want, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestPoolResourcesFitScoreErrors(t *testing.T) {
+	volume := makeVolume("vol", "10Gi")
+	poolInfo := makePoolInfo("pool", "100Gi")
+
+	p := newPlugin(t, "", nil)
+	if _, status := p.Score(context.Background(), framework.NewCycleState(), volume,
+		framework.PoolReference(poolInfo.Pool), nil); status.Code() != framework.Error {
+		t.Errorf("score without lister: got status %v, want an error", status)
+	}
+
+	// The pool has the same name as the one of the snapshot, in another namespace.
+	p = newPlugin(t, "", fake.PoolInfoLister([]*framework.PoolInfo{poolInfo}))
+	other := poolInfo.Pool.DeepCopy()
+	other.Namespace = "other"
+	if _, status := p.Score(context.Background(), framework.NewCycleState(), volume,
+		framework.PoolReference(other), nil); status.Code() != framework.Error {
+		t.Errorf("score of an unknown pool: got status %v, want an error", status)
+	}
+}
+
+func TestNewInvalidArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		args string
+	}{
+		{
+			name: "unknown strategy",
+			args: `{"scoringStrategy":{"type":"Random"}}`,
+		},
+		{
+			name: "empty shape",
+			args: `{"scoringStrategy":{"type":"RequestedToCapacityRatio","requestedToCapacityRatio":{"shape":[]}}}`,
+		},
+		{
+			name: "decreasing utilization",
+			args: `{"scoringStrategy":{"type":"RequestedToCapacityRatio","requestedToCapacityRatio":{"shape":[
+				{"utilization":50,"score":0},{"utilization":10,"score":10}]}}}`,
+		},
+		{
+			name: "malformed args",
+			args: `{"scoringStrategy":`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(&runtime.Unknown{Raw: []byte(tt.args)}, nil); err == nil {
+				t.Error("got no error, want an error")
+			}
+		})
+	}
+}
+
+func TestBrokenLinearFunction(t *testing.T) {
+	shape := []functionShapePoint{
+		{utilization: 10, score: 10},
+		{utilization: 50, score: 90},
+		{utilization: 80, score: 30},
+	}
+	tests := []struct {
+		utilization int64
+		want        int64
+	}{
+		{utilization: 0, want: 10},
+		{utilization: 10, want: 10},
+		{utilization: 30, want: 50},
+		{utilization: 50, want: 90},
+		{utilization: 65, want: 60},
+		{utilization: 80, want: 30},
+		{utilization: 100, want: 30},
+	}
+	f := buildBrokenLinearFunction(shape)
+	for _, tt := range tests {
+		if got := f(tt.utilization); got != tt.want {
+			t.Errorf("f(%d) = %d, want %d", tt.utilization, got, tt.want)
+		}
+	}
+}
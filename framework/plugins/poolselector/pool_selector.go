@@ -0,0 +1,60 @@
+package poolselector
+
+import (
+	"context"
+
+	scpv1alpha1 "github.com/openebs/device-localpv/pkg/apis/openebs.io/scp/v1alpha1"
+	"github.com/shovanmaity/volume-scheduler/framework"
+	"github.com/shovanmaity/volume-scheduler/framework/plugins/helper"
+	"github.com/shovanmaity/volume-scheduler/framework/plugins/names"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	// Name is the name of the plugin used in the plugin registry and configurations.
+	Name = names.PoolSelector
+
+	// ErrReason returned when pool labels don't match the volume's pool selector.
+	ErrReason = "pool(s) didn't match the volume's pool selector"
+)
+
+// PoolSelector is a plugin that checks if a pool's labels match the volume's pool selector.
+type PoolSelector struct{}
+
+var _ framework.FilterPlugin = &PoolSelector{}
+var _ framework.EnqueueExtensions = &PoolSelector{}
+
+// Name returns name of the plugin.
+func (pl *PoolSelector) Name() string {
+	return Name
+}
+
+// EventsToRegister returns the possible events that may make a volume failed by this plugin
+// schedulable.
+func (pl *PoolSelector) EventsToRegister() []framework.ClusterEvent {
+	return []framework.ClusterEvent{
+		{Resource: framework.StoragePool, ActionType: framework.Add | framework.UpdatePoolLabel},
+	}
+}
+
+// Filter invoked at the filter extension point.
+func (pl *PoolSelector) Filter(ctx context.Context, state *framework.CycleState,
+	volume *scpv1alpha1.StorageVolume, poolInfo *framework.PoolInfo) *framework.Status {
+	if poolInfo.Pool == nil {
+		return framework.NewStatus(framework.Error, "pool not found")
+	}
+	selector, err := helper.GetPoolSelector(volume)
+	if err != nil {
+		return framework.AsStatus(err)
+	}
+	if !selector.Matches(labels.Set(poolInfo.Pool.Labels)) {
+		return framework.NewStatus(framework.UnschedulableAndUnresolvable, ErrReason)
+	}
+	return nil
+}
+
+// New initializes a new plugin and returns it.
+func New(_ runtime.Object, _ framework.Handle) (framework.Plugin, error) {
+	return &PoolSelector{}, nil
+}
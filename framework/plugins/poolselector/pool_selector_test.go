@@ -0,0 +1,94 @@
+package poolselector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	scpv1alpha1 "github.com/openebs/device-localpv/pkg/apis/openebs.io/scp/v1alpha1"
+	"github.com/shovanmaity/volume-scheduler/framework"
+	"github.com/shovanmaity/volume-scheduler/framework/plugins/helper"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPoolSelector(t *testing.T) {
+	tests := []struct {
+		name       string
+		selector   *string
+		poolLabels map[string]string
+		want       *framework.Status
+	}{
+		{
+			name:       "volume without pool selector",
+			poolLabels: map[string]string{"tier": "nvme"},
+		},
+		{
+			name: "pool without labels, volume without pool selector",
+		},
+		{
+			name:       "pool labels match the selector",
+			selector:   stringPtr("tier=nvme"),
+			poolLabels: map[string]string{"tier": "nvme", "zone": "a"},
+		},
+		{
+			name:       "pool labels match every requirement of the selector",
+			selector:   stringPtr("tier=nvme,maintenance!=true"),
+			poolLabels: map[string]string{"tier": "nvme"},
+		},
+		{
+			name:       "pool labels don't match the selector",
+			selector:   stringPtr("tier=nvme"),
+			poolLabels: map[string]string{"tier": "hdd"},
+			want:       framework.NewStatus(framework.UnschedulableAndUnresolvable, ErrReason),
+		},
+		{
+			name:       "pool labels match a negated requirement",
+			selector:   stringPtr("tier=nvme,maintenance!=true"),
+			poolLabels: map[string]string{"tier": "nvme", "maintenance": "true"},
+			want:       framework.NewStatus(framework.UnschedulableAndUnresolvable, ErrReason),
+		},
+		{
+			name:     "pool without labels",
+			selector: stringPtr("tier"),
+			want:     framework.NewStatus(framework.UnschedulableAndUnresolvable, ErrReason),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			volume := &scpv1alpha1.StorageVolume{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "vol"}}
+			if tt.selector != nil {
+				volume.Annotations = map[string]string{helper.PoolSelectorAnnotation: *tt.selector}
+			}
+			poolInfo := framework.NewPoolInfo()
+			poolInfo.SetPool(&scpv1alpha1.StoragePool{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "pool", Labels: tt.poolLabels},
+			})
+			p, _ := New(nil, nil)
+			got := p.(framework.FilterPlugin).Filter(context.Background(), nil, volume, poolInfo)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("unexpected status (-want, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestPoolSelectorErrors(t *testing.T) {
+	p, _ := New(nil, nil)
+	volume := &scpv1alpha1.StorageVolume{ObjectMeta: metav1.ObjectMeta{
+		Namespace:   "ns",
+		Name:        "vol",
+		Annotations: map[string]string{helper.PoolSelectorAnnotation: "tier in (nvme"},
+	}}
+	poolInfo := framework.NewPoolInfo()
+	poolInfo.SetPool(&scpv1alpha1.StoragePool{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "pool"}})
+	if status := p.(framework.FilterPlugin).Filter(context.Background(), nil, volume,
+		poolInfo); status.Code() != framework.Error {
+		t.Errorf("invalid pool selector: got status %v, want an error", status)
+	}
+	if status := p.(framework.FilterPlugin).Filter(context.Background(), nil, volume,
+		framework.NewPoolInfo()); status.Code() != framework.Error {
+		t.Errorf("removed pool: got status %v, want an error", status)
+	}
+}
+
+func stringPtr(s string) *string { return &s }
@@ -0,0 +1,35 @@
+package queuesort
+
+import (
+	"github.com/shovanmaity/volume-scheduler/framework"
+	"github.com/shovanmaity/volume-scheduler/framework/plugins/helper"
+	"github.com/shovanmaity/volume-scheduler/framework/plugins/names"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Name is the name of the plugin used in the plugin registry and configurations.
+const Name = names.PrioritySort
+
+// PrioritySort is a plugin that implements Priority based sorting.
+type PrioritySort struct{}
+
+var _ framework.QueueSortPlugin = &PrioritySort{}
+
+// Name returns name of the plugin.
+func (pl *PrioritySort) Name() string {
+	return Name
+}
+
+// Less is the function used by the activeQ heap algorithm to sort volumes.
+// It sorts volumes based on their priority. When priorities are equal, it uses
+// QueuedVolumeInfo.Timestamp.
+func (pl *PrioritySort) Less(vInfo1, vInfo2 *framework.QueuedVolumeInfo) bool {
+	p1 := helper.VolumePriority(vInfo1.Volume)
+	p2 := helper.VolumePriority(vInfo2.Volume)
+	return (p1 > p2) || (p1 == p2 && vInfo1.Timestamp.Before(vInfo2.Timestamp))
+}
+
+// New initializes a new plugin and returns it.
+func New(_ runtime.Object, handle framework.Handle) (framework.Plugin, error) {
+	return &PrioritySort{}, nil
+}
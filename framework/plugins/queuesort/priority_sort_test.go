@@ -0,0 +1,84 @@
+package queuesort
+
+import (
+	"testing"
+	"time"
+
+	scpv1alpha1 "github.com/openebs/device-localpv/pkg/apis/openebs.io/scp/v1alpha1"
+	"github.com/shovanmaity/volume-scheduler/framework"
+	"github.com/shovanmaity/volume-scheduler/framework/plugins/helper"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func queuedVolume(priority string, timestamp time.Time) *framework.QueuedVolumeInfo {
+	volume := &scpv1alpha1.StorageVolume{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "vol"}}
+	if len(priority) != 0 {
+		volume.Annotations = map[string]string{helper.VolumePriorityAnnotation: priority}
+	}
+	return &framework.QueuedVolumeInfo{
+		VolumeInfo: framework.NewVolumeInfo(volume),
+		Timestamp:  timestamp,
+	}
+}
+
+func TestLess(t *testing.T) {
+	earlier := time.Now()
+	later := earlier.Add(time.Second)
+	tests := []struct {
+		name string
+		v1   *framework.QueuedVolumeInfo
+		v2   *framework.QueuedVolumeInfo
+		want bool
+	}{
+		{
+			name: "v1 has the higher priority",
+			v1:   queuedVolume("100", later),
+			v2:   queuedVolume("10", earlier),
+			want: true,
+		},
+		{
+			name: "v2 has the higher priority",
+			v1:   queuedVolume("10", earlier),
+			v2:   queuedVolume("100", later),
+			want: false,
+		},
+		{
+			name: "equal priority, v1 is added to the queue earlier",
+			v1:   queuedVolume("10", earlier),
+			v2:   queuedVolume("10", later),
+			want: true,
+		},
+		{
+			name: "equal priority, v2 is added to the queue earlier",
+			v1:   queuedVolume("10", later),
+			v2:   queuedVolume("10", earlier),
+			want: false,
+		},
+		{
+			name: "volume without priority sorts after a positive priority",
+			v1:   queuedVolume("", earlier),
+			v2:   queuedVolume("1", later),
+			want: false,
+		},
+		{
+			name: "volume without priority sorts before a negative priority",
+			v1:   queuedVolume("", later),
+			v2:   queuedVolume("-1", earlier),
+			want: true,
+		},
+		{
+			name: "invalid priority counts as the default priority",
+			v1:   queuedVolume("high", earlier),
+			v2:   queuedVolume("0", later),
+			want: true,
+		},
+	}
+	pl := &PrioritySort{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pl.Less(tt.v1, tt.v2); got != tt.want {
+				t.Errorf("Less() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package plugins
+
+import (
+	"github.com/shovanmaity/volume-scheduler/framework/plugins/cohortaffinity"
+	"github.com/shovanmaity/volume-scheduler/framework/plugins/cohorttopologyspread"
+	"github.com/shovanmaity/volume-scheduler/framework/plugins/defaultbinder"
+	"github.com/shovanmaity/volume-scheduler/framework/plugins/defaultpreemption"
+	"github.com/shovanmaity/volume-scheduler/framework/plugins/poolcapacityfit"
+	"github.com/shovanmaity/volume-scheduler/framework/plugins/poolresourcesfit"
+	"github.com/shovanmaity/volume-scheduler/framework/plugins/poolselector"
+	"github.com/shovanmaity/volume-scheduler/framework/plugins/queuesort"
+	"github.com/shovanmaity/volume-scheduler/framework/plugins/tainttoleration"
+	"github.com/shovanmaity/volume-scheduler/framework/plugins/volumeaffinity"
+	"github.com/shovanmaity/volume-scheduler/framework/runtime"
+)
+
+// NewInTreeRegistry builds the registry with all the in-tree plugins.
+// A scheduler that runs out of tree plugins can register additional plugins
+// through the Registry.Merge method.
+func NewInTreeRegistry() runtime.Registry {
+	return runtime.Registry{
+		queuesort.Name:            queuesort.New,
+		poolcapacityfit.Name:      poolcapacityfit.New,
+		cohortaffinity.Name:       cohortaffinity.New,
+		volumeaffinity.Name:       volumeaffinity.New,
+		poolselector.Name:         poolselector.New,
+		tainttoleration.Name:      tainttoleration.New,
+		poolresourcesfit.Name:     poolresourcesfit.New,
+		cohorttopologyspread.Name: cohorttopologyspread.New,
+		defaultpreemption.Name:    defaultpreemption.New,
+		defaultbinder.Name:        defaultbinder.New,
+	}
+}
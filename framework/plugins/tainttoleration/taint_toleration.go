@@ -0,0 +1,192 @@
+package tainttoleration
+
+import (
+	"context"
+	"fmt"
+
+	scpv1alpha1 "github.com/openebs/device-localpv/pkg/apis/openebs.io/scp/v1alpha1"
+	"github.com/shovanmaity/volume-scheduler/framework"
+	"github.com/shovanmaity/volume-scheduler/framework/plugins/helper"
+	"github.com/shovanmaity/volume-scheduler/framework/plugins/names"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	// Name is the name of the plugin used in the plugin registry and configurations.
+	Name = names.TaintToleration
+
+	// preScoreStateKey is the key in CycleState to TaintToleration pre-computed data for Scoring.
+	preScoreStateKey = "PreScore" + Name
+
+	// ErrReasonNotMatch is the Filter reason status when not matching.
+	ErrReasonNotMatch = "pool(s) had taints that the volume didn't tolerate"
+)
+
+// TaintToleration is a plugin that checks if a volume tolerates a pool's taints. Pools with
+// NoSchedule taints the volume doesn't tolerate are filtered out, pools with untolerated
+// PreferNoSchedule taints get a lower score.
+type TaintToleration struct {
+	handle framework.Handle
+}
+
+var _ framework.FilterPlugin = &TaintToleration{}
+var _ framework.PreScorePlugin = &TaintToleration{}
+var _ framework.ScorePlugin = &TaintToleration{}
+var _ framework.EnqueueExtensions = &TaintToleration{}
+
+// preScoreState computed at PreScore and used at Score.
+type preScoreState struct {
+	tolerationsPreferNoSchedule []corev1.Toleration
+}
+
+// Clone implements the mandatory Clone interface. We don't really copy the data since
+// there is no need for that.
+func (s *preScoreState) Clone() framework.StateData {
+	return s
+}
+
+// Name returns name of the plugin.
+func (pl *TaintToleration) Name() string {
+	return Name
+}
+
+// EventsToRegister returns the possible events that may make a volume failed by this plugin
+// schedulable.
+func (pl *TaintToleration) EventsToRegister() []framework.ClusterEvent {
+	return []framework.ClusterEvent{
+		{Resource: framework.StoragePool, ActionType: framework.Add | framework.UpdatePoolTaint},
+	}
+}
+
+// Filter invoked at the filter extension point.
+func (pl *TaintToleration) Filter(ctx context.Context, state *framework.CycleState,
+	volume *scpv1alpha1.StorageVolume, poolInfo *framework.PoolInfo) *framework.Status {
+	if poolInfo.Pool == nil {
+		return framework.NewStatus(framework.Error, "pool not found")
+	}
+	taints, err := helper.GetPoolTaints(poolInfo.Pool)
+	if err != nil {
+		return framework.AsStatus(err)
+	}
+	if len(taints) == 0 {
+		return nil
+	}
+	tolerations, err := helper.GetVolumeTolerations(volume)
+	if err != nil {
+		return framework.AsStatus(err)
+	}
+
+	filterPredicate := func(t *corev1.Taint) bool {
+		// PoolTaintsPolicy is only interested in NoSchedule and NoExecute taints.
+		return t.Effect == corev1.TaintEffectNoSchedule || t.Effect == corev1.TaintEffectNoExecute
+	}
+
+	taint, isUntolerated := helper.FindMatchingUntoleratedTaint(taints, tolerations, filterPredicate)
+	if !isUntolerated {
+		return nil
+	}
+
+	errReason := fmt.Sprintf("pool(s) had taint {%s: %s}, that the volume didn't tolerate",
+		taint.Key, taint.Value)
+	return framework.NewStatus(framework.UnschedulableAndUnresolvable, errReason)
+}
+
+// getAllTolerationPreferNoSchedule gets the list of all Tolerations with Effect PreferNoSchedule
+// or with no effect.
+func getAllTolerationPreferNoSchedule(tolerations []corev1.Toleration) (tolerationList []corev1.Toleration) {
+	for _, toleration := range tolerations {
+		// Empty effect means all effects which includes PreferNoSchedule, so we need to collect it as well.
+		if len(toleration.Effect) == 0 || toleration.Effect == corev1.TaintEffectPreferNoSchedule {
+			tolerationList = append(tolerationList, toleration)
+		}
+	}
+	return
+}
+
+// PreScore builds and writes cycle state used by Score and NormalizeScore.
+func (pl *TaintToleration) PreScore(ctx context.Context, cycleState *framework.CycleState,
+	volume *scpv1alpha1.StorageVolume, pools []*scpv1alpha1.StoragePool) *framework.Status {
+	if len(pools) == 0 {
+		return nil
+	}
+	tolerations, err := helper.GetVolumeTolerations(volume)
+	if err != nil {
+		return framework.AsStatus(err)
+	}
+	state := &preScoreState{
+		tolerationsPreferNoSchedule: getAllTolerationPreferNoSchedule(tolerations),
+	}
+	cycleState.Write(preScoreStateKey, state)
+	return nil
+}
+
+func getPreScoreState(cycleState *framework.CycleState) (*preScoreState, error) {
+	c, err := cycleState.Read(preScoreStateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %q from cycleState: %w", preScoreStateKey, err)
+	}
+
+	s, ok := c.(*preScoreState)
+	if !ok {
+		return nil, fmt.Errorf("%+v convert to tainttoleration.preScoreState error", c)
+	}
+	return s, nil
+}
+
+// countIntolerableTaintsPreferNoSchedule gives the count of intolerable taints of a volume with
+// effect PreferNoSchedule.
+func countIntolerableTaintsPreferNoSchedule(taints []corev1.Taint, tolerations []corev1.Toleration) (intolerableTaints int) {
+	for _, taint := range taints {
+		// check only on taints that have effect PreferNoSchedule
+		if taint.Effect != corev1.TaintEffectPreferNoSchedule {
+			continue
+		}
+
+		if !helper.TolerationsTolerateTaint(tolerations, &taint) {
+			intolerableTaints++
+		}
+	}
+	return
+}
+
+// Score invoked at the Score extension point.
+func (pl *TaintToleration) Score(ctx context.Context, cycleState *framework.CycleState,
+	volume *scpv1alpha1.StorageVolume, pool, cohort *corev1.ObjectReference) (int64, *framework.Status) {
+	poolKey := framework.GetReferenceKey(pool, volume.Namespace)
+	poolInfo, err := pl.handle.SnapshotPoolInfoLister().Get(poolKey)
+	if err != nil {
+		return 0, framework.AsStatus(fmt.Errorf("getting pool %q from Snapshot: %w", poolKey, err))
+	}
+	if poolInfo.Pool == nil {
+		return 0, framework.NewStatus(framework.Error, "pool not found")
+	}
+	taints, err := helper.GetPoolTaints(poolInfo.Pool)
+	if err != nil {
+		return 0, framework.AsStatus(err)
+	}
+
+	s, err := getPreScoreState(cycleState)
+	if err != nil {
+		return 0, framework.AsStatus(err)
+	}
+
+	score := int64(countIntolerableTaintsPreferNoSchedule(taints, s.tolerationsPreferNoSchedule))
+	return score, nil
+}
+
+// NormalizeScore invoked after scoring all pools.
+func (pl *TaintToleration) NormalizeScore(ctx context.Context, cycleState *framework.CycleState,
+	volume *scpv1alpha1.StorageVolume, scores framework.PoolScoreList) *framework.Status {
+	return helper.DefaultNormalizeScore(framework.MaxPoolScore, true, scores)
+}
+
+// ScoreExtensions of the Score plugin.
+func (pl *TaintToleration) ScoreExtensions() framework.ScoreExtensions {
+	return pl
+}
+
+// New initializes a new plugin and returns it.
+func New(_ runtime.Object, h framework.Handle) (framework.Plugin, error) {
+	return &TaintToleration{handle: h}, nil
+}
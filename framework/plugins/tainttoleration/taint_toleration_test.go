@@ -0,0 +1,242 @@
+package tainttoleration
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	scpv1alpha1 "github.com/openebs/device-localpv/pkg/apis/openebs.io/scp/v1alpha1"
+	"github.com/shovanmaity/volume-scheduler/framework"
+	"github.com/shovanmaity/volume-scheduler/framework/fake"
+	"github.com/shovanmaity/volume-scheduler/framework/plugins/helper"
+	frameworkruntime "github.com/shovanmaity/volume-scheduler/framework/runtime"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func annotations(t *testing.T, key string, value interface{}) map[string]string {
+	t.Helper()
+	data, err := json.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return map[string]string{key: string(data)}
+}
+
+func makeVolume(t *testing.T, tolerations []corev1.Toleration) *scpv1alpha1.StorageVolume {
+	volume := &scpv1alpha1.StorageVolume{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "vol"}}
+	if tolerations != nil {
+		volume.Annotations = annotations(t, helper.VolumeTolerationsAnnotation, tolerations)
+	}
+	return volume
+}
+
+func makePoolInfo(t *testing.T, name string, taints []corev1.Taint) *framework.PoolInfo {
+	pool := &scpv1alpha1.StoragePool{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name}}
+	if taints != nil {
+		pool.Annotations = annotations(t, helper.PoolTaintsAnnotation, taints)
+	}
+	poolInfo := framework.NewPoolInfo()
+	poolInfo.SetPool(pool)
+	return poolInfo
+}
+
+func newPlugin(t *testing.T, poolInfos []*framework.PoolInfo) *TaintToleration {
+	t.Helper()
+	fh, err := frameworkruntime.NewFramework(nil, nil,
+		frameworkruntime.WithSnapshotPoolInfoLister(fake.PoolInfoLister(poolInfos)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := New(nil, fh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p.(*TaintToleration)
+}
+
+func TestTaintTolerationFilter(t *testing.T) {
+	noSchedule := corev1.Taint{Key: "dedicated", Value: "db", Effect: corev1.TaintEffectNoSchedule}
+	tests := []struct {
+		name        string
+		tolerations []corev1.Toleration
+		taints      []corev1.Taint
+		want        *framework.Status
+	}{
+		{
+			name: "pool without taints",
+		},
+		{
+			name:   "volume without tolerations, pool with a NoSchedule taint",
+			taints: []corev1.Taint{noSchedule},
+			want: framework.NewStatus(framework.UnschedulableAndUnresolvable,
+				"pool(s) had taint {dedicated: db}, that the volume didn't tolerate"),
+		},
+		{
+			name: "volume tolerates the taint",
+			tolerations: []corev1.Toleration{{
+				Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "db", Effect: corev1.TaintEffectNoSchedule,
+			}},
+			taints: []corev1.Taint{noSchedule},
+		},
+		{
+			name:        "volume tolerates the key of the taint",
+			tolerations: []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpExists}},
+			taints:      []corev1.Taint{noSchedule},
+		},
+		{
+			name: "volume tolerates the taint with another value",
+			tolerations: []corev1.Toleration{{
+				Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "web", Effect: corev1.TaintEffectNoSchedule,
+			}},
+			taints: []corev1.Taint{noSchedule},
+			want: framework.NewStatus(framework.UnschedulableAndUnresolvable,
+				"pool(s) had taint {dedicated: db}, that the volume didn't tolerate"),
+		},
+		{
+			name: "volume tolerates the taint with another effect",
+			tolerations: []corev1.Toleration{{
+				Key: "dedicated", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectPreferNoSchedule,
+			}},
+			taints: []corev1.Taint{noSchedule},
+			want: framework.NewStatus(framework.UnschedulableAndUnresolvable,
+				"pool(s) had taint {dedicated: db}, that the volume didn't tolerate"),
+		},
+		{
+			name: "PreferNoSchedule taints are ignored",
+			taints: []corev1.Taint{
+				{Key: "dedicated", Value: "db", Effect: corev1.TaintEffectPreferNoSchedule},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			poolInfo := makePoolInfo(t, "pool", tt.taints)
+			p := newPlugin(t, []*framework.PoolInfo{poolInfo})
+			got := p.Filter(context.Background(), framework.NewCycleState(), makeVolume(t, tt.tolerations), poolInfo)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("unexpected status (-want, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestTaintTolerationFilterErrors(t *testing.T) {
+	p := newPlugin(t, nil)
+	volume := makeVolume(t, nil)
+	if status := p.Filter(context.Background(), framework.NewCycleState(), volume,
+		framework.NewPoolInfo()); status.Code() != framework.Error {
+		t.Errorf("removed pool: got status %v, want an error", status)
+	}
+
+	poolInfo := framework.NewPoolInfo()
+	poolInfo.SetPool(&scpv1alpha1.StoragePool{ObjectMeta: metav1.ObjectMeta{
+		Namespace:   "ns",
+		Name:        "pool",
+		Annotations: map[string]string{helper.PoolTaintsAnnotation: "{"},
+	}})
+	if status := p.Filter(context.Background(), framework.NewCycleState(), volume,
+		poolInfo); status.Code() != framework.Error {
+		t.Errorf("invalid taints: got status %v, want an error", status)
+	}
+}
+
+func TestTaintTolerationScore(t *testing.T) {
+	preferNoSchedule := func(key string) corev1.Taint {
+		return corev1.Taint{Key: key, Value: "true", Effect: corev1.TaintEffectPreferNoSchedule}
+	}
+	poolInfos := []*framework.PoolInfo{
+		makePoolInfo(t, "no-taints", nil),
+		makePoolInfo(t, "one-taint", []corev1.Taint{preferNoSchedule("ssd")}),
+		makePoolInfo(t, "two-taints", []corev1.Taint{preferNoSchedule("ssd"), preferNoSchedule("backup")}),
+		makePoolInfo(t, "no-schedule", []corev1.Taint{
+			{Key: "ssd", Value: "true", Effect: corev1.TaintEffectNoSchedule},
+		}),
+	}
+	tests := []struct {
+		name        string
+		tolerations []corev1.Toleration
+		want        []int64
+	}{
+		{
+			name: "volume without tolerations",
+			want: []int64{100, 50, 0, 100},
+		},
+		{
+			name: "volume tolerates one of the taints",
+			tolerations: []corev1.Toleration{{
+				Key: "ssd", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectPreferNoSchedule,
+			}},
+			want: []int64{100, 100, 0, 100},
+		},
+		{
+			name:        "toleration without effect tolerates PreferNoSchedule taints",
+			tolerations: []corev1.Toleration{{Key: "ssd", Operator: corev1.TolerationOpExists}},
+			want:        []int64{100, 100, 0, 100},
+		},
+		{
+			name: "NoSchedule tolerations are ignored",
+			tolerations: []corev1.Toleration{{
+				Key: "ssd", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule,
+			}},
+			want: []int64{100, 50, 0, 100},
+		},
+		{
+			name:        "volume tolerates all the taints",
+			tolerations: []corev1.Toleration{{Operator: corev1.TolerationOpExists}},
+			want:        []int64{100, 100, 100, 100},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			volume := makeVolume(t, tt.tolerations)
+			p := newPlugin(t, poolInfos)
+			cycleState := framework.NewCycleState()
+			var pools []*scpv1alpha1.StoragePool
+			for _, poolInfo := range poolInfos {
+				pools = append(pools, poolInfo.Pool)
+			}
+			if status := p.PreScore(context.Background(), cycleState, volume, pools); !status.IsSuccess() {
+				t.Fatalf("prescore failed with status: %v", status)
+			}
+			var scores framework.PoolScoreList
+			for _, pool := range pools {
+				score, status := p.Score(context.Background(), cycleState, volume, framework.PoolReference(pool), nil)
+				if !status.IsSuccess() {
+					t.Fatalf("score failed with status: %v", status)
+				}
+				scores = append(scores, framework.PoolScore{Namespace: pool.Namespace, Name: pool.Name, Score: score})
+			}
+			if status := p.NormalizeScore(context.Background(), cycleState, volume, scores); !status.IsSuccess() {
+				t.Fatalf("normalize score failed with status: %v", status)
+			}
+			var got []int64
+			for _, s := range scores {
+				got = append(got, s.Score)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("unexpected scores (-want, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestTaintTolerationScoreUnknownPool(t *testing.T) {
+	volume := makeVolume(t, nil)
+	poolInfo := makePoolInfo(t, "pool", nil)
+	p := newPlugin(t, []*framework.PoolInfo{poolInfo})
+	cycleState := framework.NewCycleState()
+	if status := p.PreScore(context.Background(), cycleState, volume,
+		[]*scpv1alpha1.StoragePool{poolInfo.Pool}); !status.IsSuccess() {
+		t.Fatalf("prescore failed with status: %v", status)
+	}
+
+	// The pool has the same name as the one of the snapshot, in another namespace.
+	other := poolInfo.Pool.DeepCopy()
+	other.Namespace = "other"
+	_, status := p.Score(context.Background(), cycleState, volume, framework.PoolReference(other), nil)
+	if status.Code() != framework.Error {
+		t.Errorf("got status %v, want an error", status)
+	}
+}
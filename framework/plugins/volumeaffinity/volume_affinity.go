@@ -0,0 +1,398 @@
+package volumeaffinity
+
+import (
+	"context"
+	"fmt"
+
+	scpv1alpha1 "github.com/openebs/device-localpv/pkg/apis/openebs.io/scp/v1alpha1"
+	"github.com/shovanmaity/volume-scheduler/framework"
+	"github.com/shovanmaity/volume-scheduler/framework/plugins/helper"
+	"github.com/shovanmaity/volume-scheduler/framework/plugins/names"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	// Name is the name of the plugin used in the plugin registry and configurations.
+	Name = names.VolumeAffinity
+
+	// preFilterStateKey is the key in CycleState to VolumeAffinity pre-computed data for Filtering.
+	preFilterStateKey = "PreFilter" + Name
+	// preScoreStateKey is the key in CycleState to VolumeAffinity pre-computed data for Scoring.
+	preScoreStateKey = "PreScore" + Name
+
+	// ErrReasonAffinityRulesNotMatch is used for volume affinity rules that are not satisfied.
+	ErrReasonAffinityRulesNotMatch = "pool(s) didn't match volume affinity rules"
+	// ErrReasonAntiAffinityRulesNotMatch is used for volume anti-affinity rules that are not
+	// satisfied.
+	ErrReasonAntiAffinityRulesNotMatch = "pool(s) didn't match volume anti-affinity rules"
+)
+
+// VolumeAffinity is a plugin that places a volume in the same cohort topology as the volumes
+// matching its affinity terms, and away from the ones matching its anti-affinity terms. Only the
+// terms of the volume being scheduled are considered, the terms of the existing volumes are not.
+type VolumeAffinity struct {
+	handle framework.Handle
+}
+
+var _ framework.PreFilterPlugin = &VolumeAffinity{}
+var _ framework.PreFilterExtensions = &VolumeAffinity{}
+var _ framework.FilterPlugin = &VolumeAffinity{}
+var _ framework.PreScorePlugin = &VolumeAffinity{}
+var _ framework.ScorePlugin = &VolumeAffinity{}
+var _ framework.EnqueueExtensions = &VolumeAffinity{}
+
+// affinityTerm is a parsed VolumeAffinityTerm.
+type affinityTerm struct {
+	selector    labels.Selector
+	topologyKey string
+	// weight is only used by the preferred terms, it is negative for anti-affinity.
+	weight int64
+}
+
+// matches returns true if the volume is in the namespace of the volume being scheduled and
+// matches the selector of the term.
+func (t *affinityTerm) matches(namespace string, volume *scpv1alpha1.StorageVolume) bool {
+	return volume.Namespace == namespace && t.selector.Matches(labels.Set(volume.Labels))
+}
+
+// topologyToMatchedVolumes maps a topology value to the number of volumes placed in that
+// topology which match a term.
+type topologyToMatchedVolumes map[string]int64
+
+// preFilterState computed at PreFilter and used at Filter.
+type preFilterState struct {
+	namespace         string
+	affinityTerms     []*affinityTerm
+	antiAffinityTerms []*affinityTerm
+	// affinityCounts[i] counts the volumes matching affinityTerms[i].
+	affinityCounts []topologyToMatchedVolumes
+	// antiAffinityCounts[i] counts the volumes matching antiAffinityTerms[i].
+	antiAffinityCounts []topologyToMatchedVolumes
+}
+
+// Clone the prefilter state. Terms are never mutated, only the counts are copied.
+func (s *preFilterState) Clone() framework.StateData {
+	if s == nil {
+		return nil
+	}
+	copy := *s
+	copy.affinityCounts = cloneCounts(s.affinityCounts)
+	copy.antiAffinityCounts = cloneCounts(s.antiAffinityCounts)
+	return &copy
+}
+
+func cloneCounts(counts []topologyToMatchedVolumes) []topologyToMatchedVolumes {
+	copy := make([]topologyToMatchedVolumes, len(counts))
+	for i, m := range counts {
+		copy[i] = make(topologyToMatchedVolumes, len(m))
+		for k, v := range m {
+			copy[i][k] = v
+		}
+	}
+	return copy
+}
+
+// updateWithVolume updates the counts with the volume placed on the pool, multiplier is 1 for an
+// added volume and -1 for a removed one.
+func (s *preFilterState) updateWithVolume(volume *scpv1alpha1.StorageVolume,
+	cohort *scpv1alpha1.StorageCohort, multiplier int64) {
+	updateCounts(s.affinityTerms, s.affinityCounts, s.namespace, volume, cohort, multiplier)
+	updateCounts(s.antiAffinityTerms, s.antiAffinityCounts, s.namespace, volume, cohort, multiplier)
+}
+
+func updateCounts(terms []*affinityTerm, counts []topologyToMatchedVolumes, namespace string,
+	volume *scpv1alpha1.StorageVolume, cohort *scpv1alpha1.StorageCohort, multiplier int64) {
+	for i, term := range terms {
+		if !term.matches(namespace, volume) {
+			continue
+		}
+		value, ok := helper.TopologyValue(cohort, term.topologyKey)
+		if !ok {
+			continue
+		}
+		counts[i][value] += multiplier
+		if counts[i][value] <= 0 {
+			delete(counts[i], value)
+		}
+	}
+}
+
+// preScoreState computed at PreScore and used at Score.
+type preScoreState struct {
+	// topologyScore maps a topology key to the score of each of its values.
+	topologyScore map[string]map[string]int64
+}
+
+// Clone implements the mandatory Clone interface. We don't really copy the data since
+// there is no need for that.
+func (s *preScoreState) Clone() framework.StateData {
+	return s
+}
+
+// Name returns name of the plugin.
+func (pl *VolumeAffinity) Name() string {
+	return Name
+}
+
+// EventsToRegister returns the possible events that may make a volume failed by this plugin
+// schedulable. Besides the volumes matching the terms, the topology of the pools depends on
+// their cohorts and on the labels of the cohorts.
+func (pl *VolumeAffinity) EventsToRegister() []framework.ClusterEvent {
+	return []framework.ClusterEvent{
+		{Resource: framework.StorageVolume, ActionType: framework.All},
+		{Resource: framework.StoragePool, ActionType: framework.Add | framework.UpdatePoolCohort},
+		{Resource: framework.StorageCohort, ActionType: framework.Add | framework.UpdateCohortLabel},
+	}
+}
+
+// PreFilter counts, for every required affinity and anti-affinity term of the volume, the
+// existing volumes matching the term in each topology.
+func (pl *VolumeAffinity) PreFilter(ctx context.Context, cycleState *framework.CycleState,
+	volume *scpv1alpha1.StorageVolume) *framework.Status {
+	s := &preFilterState{namespace: volume.Namespace}
+	if affinity := volume.Spec.Affinity; affinity != nil {
+		var err error
+		if s.affinityTerms, err = parseTerms(affinity.VolumeAffinity); err != nil {
+			return framework.AsStatus(err)
+		}
+		if s.antiAffinityTerms, err = parseTerms(affinity.VolumeAntiAffinity); err != nil {
+			return framework.AsStatus(err)
+		}
+	}
+	s.affinityCounts = newCounts(len(s.affinityTerms))
+	s.antiAffinityCounts = newCounts(len(s.antiAffinityTerms))
+
+	if len(s.affinityTerms) > 0 || len(s.antiAffinityTerms) > 0 {
+		poolInfos, err := pl.handle.SnapshotPoolInfoLister().List()
+		if err != nil {
+			return framework.AsStatus(fmt.Errorf("listing pools: %w", err))
+		}
+		for _, poolInfo := range poolInfos {
+			for _, v := range poolInfo.Volumes {
+				if v.Volume.UID == volume.UID {
+					continue
+				}
+				s.updateWithVolume(v.Volume, poolInfo.Cohort, 1)
+			}
+		}
+	}
+	cycleState.Write(preFilterStateKey, s)
+	return nil
+}
+
+func parseTerms(terms []scpv1alpha1.VolumeAffinityTerm) ([]*affinityTerm, error) {
+	var result []*affinityTerm
+	for i := range terms {
+		selector, err := metav1.LabelSelectorAsSelector(terms[i].LabelSelector)
+		if err != nil {
+			return nil, fmt.Errorf("parsing volume affinity term: %w", err)
+		}
+		result = append(result, &affinityTerm{selector: selector, topologyKey: terms[i].TopologyKey})
+	}
+	return result, nil
+}
+
+func newCounts(n int) []topologyToMatchedVolumes {
+	counts := make([]topologyToMatchedVolumes, n)
+	for i := range counts {
+		counts[i] = make(topologyToMatchedVolumes)
+	}
+	return counts
+}
+
+// PreFilterExtensions returns prefilter extensions, volume add and remove.
+func (pl *VolumeAffinity) PreFilterExtensions() framework.PreFilterExtensions {
+	return pl
+}
+
+// AddVolume from pre-computed data in cycleState.
+func (pl *VolumeAffinity) AddVolume(ctx context.Context, cycleState *framework.CycleState,
+	volumeToSchedule *scpv1alpha1.StorageVolume, volumeInfoToAdd *framework.VolumeInfo,
+	poolInfo *framework.PoolInfo) *framework.Status {
+	s, err := getPreFilterState(cycleState)
+	if err != nil {
+		return framework.AsStatus(err)
+	}
+	s.updateWithVolume(volumeInfoToAdd.Volume, poolInfo.Cohort, 1)
+	return nil
+}
+
+// RemoveVolume from pre-computed data in cycleState.
+func (pl *VolumeAffinity) RemoveVolume(ctx context.Context, cycleState *framework.CycleState,
+	volumeToSchedule *scpv1alpha1.StorageVolume, volumeInfoToRemove *framework.VolumeInfo,
+	poolInfo *framework.PoolInfo) *framework.Status {
+	s, err := getPreFilterState(cycleState)
+	if err != nil {
+		return framework.AsStatus(err)
+	}
+	s.updateWithVolume(volumeInfoToRemove.Volume, poolInfo.Cohort, -1)
+	return nil
+}
+
+// Filter checks that the pool's cohort is in a topology holding volumes matching every affinity
+// term, and in none holding volumes matching an anti-affinity term. An affinity term no volume
+// matches yet is ignored, so that the first of a group of volumes can be placed anywhere.
+func (pl *VolumeAffinity) Filter(ctx context.Context, cycleState *framework.CycleState,
+	volume *scpv1alpha1.StorageVolume, poolInfo *framework.PoolInfo) *framework.Status {
+	s, err := getPreFilterState(cycleState)
+	if err != nil {
+		return framework.AsStatus(err)
+	}
+	for i, term := range s.affinityTerms {
+		if len(s.affinityCounts[i]) == 0 {
+			continue
+		}
+		value, ok := helper.TopologyValue(poolInfo.Cohort, term.topologyKey)
+		if !ok || s.affinityCounts[i][value] <= 0 {
+			return framework.NewStatus(framework.UnschedulableAndUnresolvable, ErrReasonAffinityRulesNotMatch)
+		}
+	}
+	for i, term := range s.antiAffinityTerms {
+		value, ok := helper.TopologyValue(poolInfo.Cohort, term.topologyKey)
+		if ok && s.antiAffinityCounts[i][value] > 0 {
+			return framework.NewStatus(framework.Unschedulable, ErrReasonAntiAffinityRulesNotMatch)
+		}
+	}
+	return nil
+}
+
+// PreScore computes the score of each topology from the preferred affinity and anti-affinity
+// terms of the volume and the existing volumes matching them.
+func (pl *VolumeAffinity) PreScore(ctx context.Context, cycleState *framework.CycleState,
+	volume *scpv1alpha1.StorageVolume, pools []*scpv1alpha1.StoragePool) *framework.Status {
+	state := &preScoreState{topologyScore: make(map[string]map[string]int64)}
+	defer cycleState.Write(preScoreStateKey, state)
+
+	affinity, err := helper.GetPreferredAffinity(volume)
+	if err != nil {
+		return framework.AsStatus(err)
+	}
+	if affinity == nil {
+		return nil
+	}
+	var terms []*affinityTerm
+	if terms, err = parseWeightedTerms(terms, affinity.VolumeAffinity, 1); err != nil {
+		return framework.AsStatus(err)
+	}
+	if terms, err = parseWeightedTerms(terms, affinity.VolumeAntiAffinity, -1); err != nil {
+		return framework.AsStatus(err)
+	}
+	if len(terms) == 0 {
+		return nil
+	}
+
+	poolInfos, err := pl.handle.SnapshotPoolInfoLister().List()
+	if err != nil {
+		return framework.AsStatus(fmt.Errorf("listing pools: %w", err))
+	}
+	for _, poolInfo := range poolInfos {
+		for _, v := range poolInfo.Volumes {
+			if v.Volume.UID == volume.UID {
+				continue
+			}
+			for _, term := range terms {
+				if !term.matches(volume.Namespace, v.Volume) {
+					continue
+				}
+				value, ok := helper.TopologyValue(poolInfo.Cohort, term.topologyKey)
+				if !ok {
+					continue
+				}
+				if state.topologyScore[term.topologyKey] == nil {
+					state.topologyScore[term.topologyKey] = make(map[string]int64)
+				}
+				state.topologyScore[term.topologyKey][value] += term.weight
+			}
+		}
+	}
+	return nil
+}
+
+func parseWeightedTerms(terms []*affinityTerm, weightedTerms []helper.WeightedVolumeAffinityTerm,
+	multiplier int64) ([]*affinityTerm, error) {
+	for i := range weightedTerms {
+		if weightedTerms[i].Weight == 0 {
+			continue
+		}
+		term := &weightedTerms[i].VolumeAffinityTerm
+		selector, err := metav1.LabelSelectorAsSelector(term.LabelSelector)
+		if err != nil {
+			return nil, fmt.Errorf("parsing preferred volume affinity term: %w", err)
+		}
+		terms = append(terms, &affinityTerm{
+			selector:    selector,
+			topologyKey: term.TopologyKey,
+			weight:      multiplier * int64(weightedTerms[i].Weight),
+		})
+	}
+	return terms, nil
+}
+
+// Score sums the scores of the topologies the pool's cohort belongs to.
+func (pl *VolumeAffinity) Score(ctx context.Context, cycleState *framework.CycleState,
+	volume *scpv1alpha1.StorageVolume, pool, cohort *corev1.ObjectReference) (int64, *framework.Status) {
+	s, err := getPreScoreState(cycleState)
+	if err != nil {
+		return 0, framework.AsStatus(err)
+	}
+	if len(s.topologyScore) == 0 {
+		return 0, nil
+	}
+	poolKey := framework.GetReferenceKey(pool, volume.Namespace)
+	poolInfo, err := pl.handle.SnapshotPoolInfoLister().Get(poolKey)
+	if err != nil {
+		return 0, framework.AsStatus(fmt.Errorf("getting pool %q from Snapshot: %w", poolKey, err))
+	}
+	var score int64
+	for topologyKey, values := range s.topologyScore {
+		if value, ok := helper.TopologyValue(poolInfo.Cohort, topologyKey); ok {
+			score += values[value]
+		}
+	}
+	return score, nil
+}
+
+// NormalizeScore scales the scores into the range [MinPoolScore, MaxPoolScore].
+func (pl *VolumeAffinity) NormalizeScore(ctx context.Context, cycleState *framework.CycleState,
+	volume *scpv1alpha1.StorageVolume, scores framework.PoolScoreList) *framework.Status {
+	helper.MinMaxNormalizeScore(scores)
+	return nil
+}
+
+// ScoreExtensions of the Score plugin.
+func (pl *VolumeAffinity) ScoreExtensions() framework.ScoreExtensions {
+	return pl
+}
+
+func getPreFilterState(cycleState *framework.CycleState) (*preFilterState, error) {
+	c, err := cycleState.Read(preFilterStateKey)
+	if err != nil {
+		// preFilterState doesn't exist, likely PreFilter wasn't invoked.
+		return nil, fmt.Errorf("error reading %q from cycleState: %w", preFilterStateKey, err)
+	}
+	s, ok := c.(*preFilterState)
+	if !ok {
+		return nil, fmt.Errorf("%+v convert to volumeaffinity.preFilterState error", c)
+	}
+	return s, nil
+}
+
+func getPreScoreState(cycleState *framework.CycleState) (*preScoreState, error) {
+	c, err := cycleState.Read(preScoreStateKey)
+	if err != nil {
+		return nil, fmt.Errorf("reading %q from cycleState: %w", preScoreStateKey, err)
+	}
+	s, ok := c.(*preScoreState)
+	if !ok {
+		return nil, fmt.Errorf("%+v convert to volumeaffinity.preScoreState error", c)
+	}
+	return s, nil
+}
+
+// New initializes a new plugin and returns it.
+func New(_ runtime.Object, handle framework.Handle) (framework.Plugin, error) {
+	return &VolumeAffinity{handle: handle}, nil
+}
@@ -0,0 +1,276 @@
+package volumeaffinity
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	scpv1alpha1 "github.com/openebs/device-localpv/pkg/apis/openebs.io/scp/v1alpha1"
+	"github.com/shovanmaity/volume-scheduler/framework"
+	"github.com/shovanmaity/volume-scheduler/framework/fake"
+	"github.com/shovanmaity/volume-scheduler/framework/plugins/helper"
+	frameworkruntime "github.com/shovanmaity/volume-scheduler/framework/runtime"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const zoneKey = "zone"
+
+func makeVolume(namespace, name, app string) *scpv1alpha1.StorageVolume {
+	return &scpv1alpha1.StorageVolume{ObjectMeta: metav1.ObjectMeta{
+		Namespace: namespace,
+		Name:      name,
+		UID:       types.UID(namespace + "/" + name),
+		Labels:    map[string]string{"app": app},
+	}}
+}
+
+// makePoolInfo returns a pool in a cohort of its own name, in the given zone if it isn't empty.
+func makePoolInfo(name, zone string, volumes ...*scpv1alpha1.StorageVolume) *framework.PoolInfo {
+	poolInfo := framework.NewPoolInfo(volumes...)
+	poolInfo.SetPool(&scpv1alpha1.StoragePool{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name}})
+	cohort := &scpv1alpha1.StorageCohort{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name}}
+	if len(zone) != 0 {
+		cohort.Labels = map[string]string{zoneKey: zone}
+	}
+	poolInfo.SetCohort(cohort)
+	return poolInfo
+}
+
+// testPools returns the pools the tests run on: a database volume in zone a, a web volume in
+// zone b and a cache volume of another namespace in zone c.
+func testPools() []*framework.PoolInfo {
+	return []*framework.PoolInfo{
+		makePoolInfo("pool-a1", "a", makeVolume("ns", "db", "db")),
+		makePoolInfo("pool-a2", "a"),
+		makePoolInfo("pool-b", "b", makeVolume("ns", "web", "web")),
+		makePoolInfo("pool-c", "c", makeVolume("other", "cache", "cache")),
+		makePoolInfo("pool-no-zone", ""),
+	}
+}
+
+func term(app, topologyKey string) scpv1alpha1.VolumeAffinityTerm {
+	return scpv1alpha1.VolumeAffinityTerm{
+		TopologyKey:   topologyKey,
+		LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": app}},
+	}
+}
+
+func newPlugin(t *testing.T, poolInfos []*framework.PoolInfo) *VolumeAffinity {
+	t.Helper()
+	fh, err := frameworkruntime.NewFramework(nil, nil,
+		frameworkruntime.WithSnapshotPoolInfoLister(fake.PoolInfoLister(poolInfos)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := New(nil, fh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p.(*VolumeAffinity)
+}
+
+// filter runs the Filter plugin on every pool and returns the codes.
+func filter(p *VolumeAffinity, cycleState *framework.CycleState, volume *scpv1alpha1.StorageVolume,
+	poolInfos []*framework.PoolInfo) []framework.Code {
+	var codes []framework.Code
+	for _, poolInfo := range poolInfos {
+		codes = append(codes, p.Filter(context.Background(), cycleState, volume, poolInfo).Code())
+	}
+	return codes
+}
+
+func TestVolumeAffinityFilter(t *testing.T) {
+	const (
+		ok            = framework.Success
+		unschedulable = framework.Unschedulable
+		unresolvable  = framework.UnschedulableAndUnresolvable
+	)
+	tests := []struct {
+		name     string
+		affinity *scpv1alpha1.Affinity
+		want     []framework.Code
+	}{
+		{
+			name: "volume without affinity",
+			want: []framework.Code{ok, ok, ok, ok, ok},
+		},
+		{
+			name:     "affinity to the zone of a volume",
+			affinity: &scpv1alpha1.Affinity{VolumeAffinity: []scpv1alpha1.VolumeAffinityTerm{term("db", zoneKey)}},
+			want:     []framework.Code{ok, ok, unresolvable, unresolvable, unresolvable},
+		},
+		{
+			name:     "affinity to the cohort of a volume",
+			affinity: &scpv1alpha1.Affinity{VolumeAffinity: []scpv1alpha1.VolumeAffinityTerm{term("db", "")}},
+			want:     []framework.Code{ok, unresolvable, unresolvable, unresolvable, unresolvable},
+		},
+		{
+			name: "affinity to the zones of two volumes",
+			affinity: &scpv1alpha1.Affinity{VolumeAffinity: []scpv1alpha1.VolumeAffinityTerm{
+				term("db", zoneKey), term("web", zoneKey),
+			}},
+			want: []framework.Code{unresolvable, unresolvable, unresolvable, unresolvable, unresolvable},
+		},
+		{
+			name:     "affinity no volume matches is ignored",
+			affinity: &scpv1alpha1.Affinity{VolumeAffinity: []scpv1alpha1.VolumeAffinityTerm{term("queue", zoneKey)}},
+			want:     []framework.Code{ok, ok, ok, ok, ok},
+		},
+		{
+			name:     "volumes of other namespaces don't match",
+			affinity: &scpv1alpha1.Affinity{VolumeAffinity: []scpv1alpha1.VolumeAffinityTerm{term("cache", zoneKey)}},
+			want:     []framework.Code{ok, ok, ok, ok, ok},
+		},
+		{
+			name:     "anti-affinity to the zone of a volume",
+			affinity: &scpv1alpha1.Affinity{VolumeAntiAffinity: []scpv1alpha1.VolumeAffinityTerm{term("db", zoneKey)}},
+			want:     []framework.Code{unschedulable, unschedulable, ok, ok, ok},
+		},
+		{
+			name: "affinity and anti-affinity",
+			affinity: &scpv1alpha1.Affinity{
+				VolumeAffinity:     []scpv1alpha1.VolumeAffinityTerm{term("db", zoneKey)},
+				VolumeAntiAffinity: []scpv1alpha1.VolumeAffinityTerm{term("db", "")},
+			},
+			want: []framework.Code{unschedulable, ok, unresolvable, unresolvable, unresolvable},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			volume := makeVolume("ns", "vol", "db")
+			volume.Spec.Affinity = tt.affinity
+			poolInfos := testPools()
+			p := newPlugin(t, poolInfos)
+			cycleState := framework.NewCycleState()
+			if status := p.PreFilter(context.Background(), cycleState, volume); !status.IsSuccess() {
+				t.Fatalf("prefilter failed with status: %v", status)
+			}
+			if diff := cmp.Diff(tt.want, filter(p, cycleState, volume, poolInfos)); diff != "" {
+				t.Errorf("unexpected filter codes (-want, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestVolumeAffinityAddRemoveVolume(t *testing.T) {
+	volume := makeVolume("ns", "vol", "app")
+	volume.Spec.Affinity = &scpv1alpha1.Affinity{
+		VolumeAffinity: []scpv1alpha1.VolumeAffinityTerm{term("db", zoneKey)},
+	}
+	poolInfos := testPools()
+	p := newPlugin(t, poolInfos)
+	cycleState := framework.NewCycleState()
+	if status := p.PreFilter(context.Background(), cycleState, volume); !status.IsSuccess() {
+		t.Fatalf("prefilter failed with status: %v", status)
+	}
+
+	// Without the database volume the affinity term is ignored.
+	db := poolInfos[0].Volumes[0]
+	if status := p.RemoveVolume(context.Background(), cycleState, volume, db, poolInfos[0]); !status.IsSuccess() {
+		t.Fatalf("remove volume failed with status: %v", status)
+	}
+	want := []framework.Code{framework.Success, framework.Success, framework.Success, framework.Success,
+		framework.Success}
+	if diff := cmp.Diff(want, filter(p, cycleState, volume, poolInfos)); diff != "" {
+		t.Errorf("unexpected filter codes after RemoveVolume (-want, +got): %s", diff)
+	}
+
+	// Adding it in zone b moves the affinity there.
+	if status := p.AddVolume(context.Background(), cycleState, volume, db, poolInfos[2]); !status.IsSuccess() {
+		t.Fatalf("add volume failed with status: %v", status)
+	}
+	want = []framework.Code{framework.UnschedulableAndUnresolvable, framework.UnschedulableAndUnresolvable,
+		framework.Success, framework.UnschedulableAndUnresolvable, framework.UnschedulableAndUnresolvable}
+	if diff := cmp.Diff(want, filter(p, cycleState, volume, poolInfos)); diff != "" {
+		t.Errorf("unexpected filter codes after AddVolume (-want, +got): %s", diff)
+	}
+}
+
+func TestVolumeAffinityScore(t *testing.T) {
+	tests := []struct {
+		name     string
+		affinity *helper.PreferredAffinity
+		want     []int64
+	}{
+		{
+			name: "no preferred affinity",
+			want: []int64{0, 0, 0, 0, 0},
+		},
+		{
+			name: "preferred affinity to the zone of a volume",
+			affinity: &helper.PreferredAffinity{VolumeAffinity: []helper.WeightedVolumeAffinityTerm{
+				{Weight: 10, VolumeAffinityTerm: term("db", zoneKey)},
+			}},
+			want: []int64{100, 100, 0, 0, 0},
+		},
+		{
+			name: "preferred affinity and anti-affinity",
+			affinity: &helper.PreferredAffinity{
+				VolumeAffinity: []helper.WeightedVolumeAffinityTerm{
+					{Weight: 10, VolumeAffinityTerm: term("db", zoneKey)},
+				},
+				VolumeAntiAffinity: []helper.WeightedVolumeAffinityTerm{
+					{Weight: 5, VolumeAffinityTerm: term("web", zoneKey)},
+				},
+			},
+			want: []int64{100, 100, 0, 33, 33},
+		},
+		{
+			name: "preferred affinity to the cohort of a volume",
+			affinity: &helper.PreferredAffinity{VolumeAffinity: []helper.WeightedVolumeAffinityTerm{
+				{Weight: 10, VolumeAffinityTerm: term("db", "")},
+			}},
+			want: []int64{100, 0, 0, 0, 0},
+		},
+		{
+			name: "volumes of other namespaces don't match",
+			affinity: &helper.PreferredAffinity{VolumeAffinity: []helper.WeightedVolumeAffinityTerm{
+				{Weight: 10, VolumeAffinityTerm: term("cache", zoneKey)},
+			}},
+			want: []int64{0, 0, 0, 0, 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			volume := makeVolume("ns", "vol", "app")
+			if tt.affinity != nil {
+				data, err := json.Marshal(tt.affinity)
+				if err != nil {
+					t.Fatal(err)
+				}
+				volume.Annotations = map[string]string{helper.PreferredAffinityAnnotation: string(data)}
+			}
+			poolInfos := testPools()
+			p := newPlugin(t, poolInfos)
+			cycleState := framework.NewCycleState()
+			var pools []*scpv1alpha1.StoragePool
+			for _, poolInfo := range poolInfos {
+				pools = append(pools, poolInfo.Pool)
+			}
+			if status := p.PreScore(context.Background(), cycleState, volume, pools); !status.IsSuccess() {
+				t.Fatalf("prescore failed with status: %v", status)
+			}
+			var scores framework.PoolScoreList
+			for _, pool := range pools {
+				score, status := p.Score(context.Background(), cycleState, volume,
+					framework.PoolReference(pool), pool.Spec.StorageCohortReference)
+				if !status.IsSuccess() {
+					t.Fatalf("score failed with status: %v", status)
+				}
+				scores = append(scores, framework.PoolScore{Namespace: pool.Namespace, Name: pool.Name, Score: score})
+			}
+			if status := p.NormalizeScore(context.Background(), cycleState, volume, scores); !status.IsSuccess() {
+				t.Fatalf("normalize score failed with status: %v", status)
+			}
+			var got []int64
+			for _, s := range scores {
+				got = append(got, s.Score)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("unexpected scores (-want, +got): %s", diff)
+			}
+		})
+	}
+}
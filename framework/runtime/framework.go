@@ -3,32 +3,379 @@ package runtime
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"time"
 
 	scpv1alpha1 "github.com/openebs/device-localpv/pkg/apis/openebs.io/scp/v1alpha1"
+	clientset "github.com/openebs/device-localpv/pkg/generated/clientset/scp/internalclientset"
+	"github.com/shovanmaity/volume-scheduler/apis/config"
 	"github.com/shovanmaity/volume-scheduler/framework"
 	"github.com/shovanmaity/volume-scheduler/framework/parallelize"
+	"github.com/shovanmaity/volume-scheduler/scheduler/metrics"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog/v2"
 )
 
+// Framework is the component responsible for initializing and running scheduler plugins.
 type Framework struct {
-	//queueSortPlugins     []framework.QueueSortPlugin
+	registry          Registry
+	queueSortPlugins  []framework.QueueSortPlugin
 	preFilterPlugins  []framework.PreFilterPlugin
 	filterPlugins     []framework.FilterPlugin
 	postFilterPlugins []framework.PostFilterPlugin
 	preScorePlugins   []framework.PreScorePlugin
 	scorePlugins      []framework.ScorePlugin
 	reservePlugins    []framework.ReservePlugin
+	permitPlugins     []framework.PermitPlugin
 	preBindPlugins    []framework.PreBindPlugin
 	bindPlugins       []framework.BindPlugin
 	postBindPlugins   []framework.PostBindPlugin
-	prallelizer       parallelize.Parallelizer
+	scorePluginWeight map[string]int
+	waitingVolumes    *waitingVolumesMap
+	parallelizer      parallelize.Parallelizer
+	profileName       string
+	clientSet         clientset.Interface
+	metricsRecorder   *metrics.MetricAsyncRecorder
+
+	snapshotPoolInfoLister framework.PoolInfoLister
+}
+
+var _ framework.Handle = &Framework{}
+
+// maxTimeout is the maximum time a Permit plugin can ask a volume to wait for.
+const maxTimeout = 15 * time.Minute
+
+// maxTotalScore is the limit of the weighted sum of the Score plugins' maximum scores. It is a
+// variable so that tests can reach it without configuring millions of plugins.
+var maxTotalScore = framework.MaxTotalScore
+
+// Extension points, as they are labelled in the metrics.
+const (
+	preFilter                = "PreFilter"
+	preFilterExtensionAdd    = "PreFilterExtensionAddVolume"
+	preFilterExtensionRemove = "PreFilterExtensionRemoveVolume"
+	filter                   = "Filter"
+	postFilter               = "PostFilter"
+	preScore                 = "PreScore"
+	score                    = "Score"
+	scoreExtensionNormalize  = "ScoreExtensionNormalize"
+	reserve                  = "Reserve"
+	unreserve                = "Unreserve"
+	permit                   = "Permit"
+	preBind                  = "PreBind"
+	bind                     = "Bind"
+	postBind                 = "PostBind"
+)
+
+// extensionPoint encapsulates desired and applied set of plugins at a specific extension
+// point. This is used to simplify iterating over all extension points supported by the
+// Framework.
+type extensionPoint struct {
+	// the set of plugins to be configured at this extension point.
+	plugins *config.PluginSet
+	// a pointer to the slice storing plugins implementations that will run at this
+	// extension point.
+	slicePtr interface{}
+}
+
+func (f *Framework) getExtensionPoints(plugins *config.Plugins) []extensionPoint {
+	return []extensionPoint{
+		{&plugins.QueueSort, &f.queueSortPlugins},
+		{&plugins.PreFilter, &f.preFilterPlugins},
+		{&plugins.Filter, &f.filterPlugins},
+		{&plugins.PostFilter, &f.postFilterPlugins},
+		{&plugins.PreScore, &f.preScorePlugins},
+		{&plugins.Score, &f.scorePlugins},
+		{&plugins.Reserve, &f.reservePlugins},
+		{&plugins.Permit, &f.permitPlugins},
+		{&plugins.PreBind, &f.preBindPlugins},
+		{&plugins.Bind, &f.bindPlugins},
+		{&plugins.PostBind, &f.postBindPlugins},
+	}
+}
+
+type frameworkOptions struct {
+	parallelism            int
+	clientSet              clientset.Interface
+	snapshotPoolInfoLister framework.PoolInfoLister
+	clusterEventMap        map[framework.ClusterEvent]sets.String
+	metricsRecorder        *metrics.MetricAsyncRecorder
+}
+
+// Option for the Framework.
+type Option func(*frameworkOptions)
+
+// WithParallelism sets parallelism for the scheduling framework.
+func WithParallelism(parallelism int) Option {
+	return func(o *frameworkOptions) {
+		o.parallelism = parallelism
+	}
+}
+
+// WithClientSet sets clientSet for the scheduling framework.
+func WithClientSet(clientSet clientset.Interface) Option {
+	return func(o *frameworkOptions) {
+		o.clientSet = clientSet
+	}
+}
+
+// WithSnapshotPoolInfoLister sets the lister of the snapshot the scheduling cycles run on.
+func WithSnapshotPoolInfoLister(snapshotPoolInfoLister framework.PoolInfoLister) Option {
+	return func(o *frameworkOptions) {
+		o.snapshotPoolInfoLister = snapshotPoolInfoLister
+	}
+}
+
+// WithClusterEventMap sets clusterEventMap for the scheduling framework. The framework records
+// in it the plugins interested in each event.
+func WithClusterEventMap(m map[framework.ClusterEvent]sets.String) Option {
+	return func(o *frameworkOptions) {
+		o.clusterEventMap = m
+	}
+}
+
+// WithMetricsRecorder sets the recorder the plugin metrics of the sampled cycles are recorded
+// with. Without it, the metrics are recorded synchronously.
+func WithMetricsRecorder(recorder *metrics.MetricAsyncRecorder) Option {
+	return func(o *frameworkOptions) {
+		o.metricsRecorder = recorder
+	}
+}
+
+func defaultFrameworkOptions() frameworkOptions {
+	return frameworkOptions{
+		parallelism: parallelize.DefaultParallelism,
+	}
+}
+
+// NewFramework initializes plugins given the configuration and the registry. Each plugin enabled
+// in the profile is instantiated exactly once, even if it is enabled at several extension points.
+func NewFramework(r Registry, profile *config.VolumeSchedulerProfile, opts ...Option) (*Framework, error) {
+	options := defaultFrameworkOptions()
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	f := &Framework{
+		registry:          r,
+		scorePluginWeight: make(map[string]int),
+		waitingVolumes:    newWaitingVolumesMap(),
+		parallelizer:      parallelize.NewParallelizer(options.parallelism),
+		clientSet:         options.clientSet,
+		metricsRecorder:   options.metricsRecorder,
+
+		snapshotPoolInfoLister: options.snapshotPoolInfoLister,
+	}
+	if profile == nil {
+		return f, nil
+	}
+	f.profileName = profile.SchedulerName
+	if profile.Plugins == nil {
+		return nil, fmt.Errorf("no queue sort plugin is enabled for profile %q", profile.SchedulerName)
+	}
+
+	// get needed plugins from config
+	pg := f.pluginsNeeded(profile.Plugins)
+
+	pluginConfig := make(map[string]runtime.Object, len(profile.PluginConfig))
+	for i := range profile.PluginConfig {
+		name := profile.PluginConfig[i].Name
+		if _, ok := pluginConfig[name]; ok {
+			return nil, fmt.Errorf("repeated config for plugin %s", name)
+		}
+		pluginConfig[name] = profile.PluginConfig[i].Args
+	}
+
+	pluginsMap := make(map[string]framework.Plugin)
+	for name := range pg {
+		factory, ok := r[name]
+		if !ok {
+			return nil, fmt.Errorf("plugin %q does not exist", name)
+		}
+		p, err := factory(pluginConfig[name], f)
+		if err != nil {
+			return nil, fmt.Errorf("initializing plugin %q: %w", name, err)
+		}
+		pluginsMap[name] = p
+
+		// Update ClusterEventMap in place.
+		if options.clusterEventMap != nil {
+			fillEventToPluginMap(p, options.clusterEventMap)
+		}
+	}
+
+	if err := f.setScorePluginWeight(profile.Plugins.Score); err != nil {
+		return nil, err
+	}
+
+	for _, e := range f.getExtensionPoints(profile.Plugins) {
+		if err := updatePluginList(e.slicePtr, *e.plugins, pluginsMap); err != nil {
+			return nil, err
+		}
+	}
+
+	// Verifying the queue sort plugin.
+	if len(f.queueSortPlugins) != 1 {
+		return nil, fmt.Errorf("only one queue sort plugin required for profile %q, got %d",
+			profile.SchedulerName, len(f.queueSortPlugins))
+	}
+	return f, nil
+}
+
+// allClusterEvents is the event a plugin which doesn't implement EnqueueExtensions is interested
+// in.
+var allClusterEvents = []framework.ClusterEvent{{Resource: framework.WildCard, ActionType: framework.All}}
+
+// fillEventToPluginMap records the plugin against each of the events it registered.
+func fillEventToPluginMap(p framework.Plugin, eventToPlugins map[framework.ClusterEvent]sets.String) {
+	ext, ok := p.(framework.EnqueueExtensions)
+	if !ok {
+		registerClusterEvents(p.Name(), eventToPlugins, allClusterEvents)
+		return
+	}
+
+	events := ext.EventsToRegister()
+	// A plugin which registers no event is not interested in any event, the volumes it rejected
+	// are only retried once they time out of the unschedulable queue.
+	if len(events) == 0 {
+		klog.InfoS("Plugin's EventsToRegister() returned nil", "plugin", p.Name())
+		return
+	}
+	registerClusterEvents(p.Name(), eventToPlugins, events)
+}
+
+func registerClusterEvents(name string, eventToPlugins map[framework.ClusterEvent]sets.String,
+	evts []framework.ClusterEvent) {
+	for _, evt := range evts {
+		if eventToPlugins[evt] == nil {
+			eventToPlugins[evt] = sets.NewString(name)
+		} else {
+			eventToPlugins[evt].Insert(name)
+		}
+	}
+}
+
+// setScorePluginWeight records the weight of each Score plugin. An unset weight defaults to 1.
+// It fails if a weight is negative or if the weighted sum of the plugins' maximum scores could
+// overflow.
+func (f *Framework) setScorePluginWeight(scorePlugins config.PluginSet) error {
+	totalPriority := int64(0)
+	for _, e := range scorePlugins.Enabled {
+		if e.Weight < 0 {
+			return fmt.Errorf("score plugin %q has a negative weight %d", e.Name, e.Weight)
+		}
+		f.scorePluginWeight[e.Name] = int(e.Weight)
+		if f.scorePluginWeight[e.Name] == 0 {
+			f.scorePluginWeight[e.Name] = 1
+		}
+
+		// Checks totalPriority against MaxTotalScore to avoid overflow.
+		if int64(f.scorePluginWeight[e.Name])*framework.MaxPoolScore > maxTotalScore-totalPriority {
+			return fmt.Errorf("total score of Score plugins could overflow")
+		}
+		totalPriority += int64(f.scorePluginWeight[e.Name]) * framework.MaxPoolScore
+	}
+	return nil
+}
+
+func updatePluginList(pluginList interface{}, pluginSet config.PluginSet,
+	pluginsMap map[string]framework.Plugin) error {
+	plugins := reflect.ValueOf(pluginList).Elem()
+	pluginType := plugins.Type().Elem()
+	set := make(map[string]struct{})
+	for _, ep := range pluginSet.Enabled {
+		pg, ok := pluginsMap[ep.Name]
+		if !ok {
+			return fmt.Errorf("%s %q does not exist", pluginType.Name(), ep.Name)
+		}
+
+		if !reflect.TypeOf(pg).Implements(pluginType) {
+			return fmt.Errorf("plugin %q does not extend %s plugin", ep.Name, pluginType.Name())
+		}
+
+		if _, ok := set[ep.Name]; ok {
+			return fmt.Errorf("plugin %q already registered as %q", ep.Name, pluginType.Name())
+		}
+
+		set[ep.Name] = struct{}{}
+
+		newPlugins := reflect.Append(plugins, reflect.ValueOf(pg))
+		plugins.Set(newPlugins)
+	}
+	return nil
+}
+
+func (f *Framework) pluginsNeeded(plugins *config.Plugins) map[string]struct{} {
+	pgSet := make(map[string]struct{})
+
+	if plugins == nil {
+		return pgSet
+	}
+
+	find := func(pgs *config.PluginSet) {
+		for _, pg := range pgs.Enabled {
+			pgSet[pg.Name] = struct{}{}
+		}
+	}
+	for _, e := range f.getExtensionPoints(plugins) {
+		find(e.plugins)
+	}
+	return pgSet
+}
+
+// ProfileName returns the profile name associated to this framework.
+func (f *Framework) ProfileName() string {
+	return f.profileName
+}
+
+// SnapshotPoolInfoLister returns the lister of the pools of the current scheduling cycle.
+func (f *Framework) SnapshotPoolInfoLister() framework.PoolInfoLister {
+	return f.snapshotPoolInfoLister
+}
+
+// ClientSet returns a clientset of the StorageVolume, StoragePool and StorageCohort API.
+func (f *Framework) ClientSet() clientset.Interface {
+	return f.clientSet
+}
+
+// Parallelizer returns a parallelizer holding parallelism for scheduler.
+func (f *Framework) Parallelizer() parallelize.Parallelizer {
+	return f.parallelizer
+}
+
+// QueueSortFunc returns the function to sort volumes in scheduling queue. A framework built
+// without a profile has no queue sort plugin, volumes which were queued first are scheduled
+// first then.
+func (f *Framework) QueueSortFunc() framework.LessFunc {
+	if len(f.queueSortPlugins) == 0 {
+		return func(volumeInfo1, volumeInfo2 *framework.QueuedVolumeInfo) bool {
+			return volumeInfo1.Timestamp.Before(volumeInfo2.Timestamp)
+		}
+	}
+
+	// Only one QueueSort plugin can be enabled.
+	return f.queueSortPlugins[0].Less
+}
+
+// HasFilterPlugins returns true if at least one filter plugin is defined.
+func (f *Framework) HasFilterPlugins() bool {
+	return len(f.filterPlugins) > 0
 }
 
 // RunPreFilterPlugins runs set of configured PreFilter plugins. If a non-success status is
-// returned, then the scheduling cycle is aborted.
+// returned, then the scheduling cycle is aborted. An Unschedulable or
+// UnschedulableAndUnresolvable status is returned as is, so that it applies to every pool, any
+// other status is turned into an Error.
 func (f *Framework) RunPreFilterPlugins(ctx context.Context, state *framework.CycleState,
 	volume *scpv1alpha1.StorageVolume) (status *framework.Status) {
+	startTime := time.Now()
+	defer func() {
+		metrics.FrameworkExtensionPointDuration.WithLabelValues(preFilter, status.Code().String(),
+			f.profileName).Observe(metrics.SinceInSeconds(startTime))
+	}()
 	for _, pl := range f.preFilterPlugins {
 		status = f.runPreFilterPlugin(ctx, pl, state, volume)
 		if !status.IsSuccess() {
@@ -45,26 +392,117 @@ func (f *Framework) RunPreFilterPlugins(ctx context.Context, state *framework.Cy
 
 func (f *Framework) runPreFilterPlugin(ctx context.Context, pl framework.PreFilterPlugin,
 	state *framework.CycleState, volume *scpv1alpha1.StorageVolume) *framework.Status {
-	return pl.PreFilter(ctx, state, volume)
+	if !state.ShouldRecordPluginMetrics() {
+		return pl.PreFilter(ctx, state, volume)
+	}
+	startTime := time.Now()
+	status := pl.PreFilter(ctx, state, volume)
+	f.metricsRecorder.ObservePluginDurationAsync(preFilter, pl.Name(), status.Code().String(),
+		metrics.SinceInSeconds(startTime))
+	return status
+}
+
+// RunPreFilterExtensionAddVolume calls the AddVolume interface for the set of configured
+// PreFilter plugins. It returns directly if any of the plugins return any status other than
+// Success.
+func (f *Framework) RunPreFilterExtensionAddVolume(ctx context.Context, state *framework.CycleState,
+	volumeToSchedule *scpv1alpha1.StorageVolume, volumeInfoToAdd *framework.VolumeInfo,
+	poolInfo *framework.PoolInfo) (status *framework.Status) {
+	for _, pl := range f.preFilterPlugins {
+		if pl.PreFilterExtensions() == nil {
+			continue
+		}
+		status = f.runPreFilterExtensionAddVolume(ctx, pl, state, volumeToSchedule, volumeInfoToAdd, poolInfo)
+		if !status.IsSuccess() {
+			err := status.AsError()
+			klog.ErrorS(err, "Failed running AddVolume on PreFilter plugin", "plugin", pl.Name(),
+				"volume", klog.KObj(volumeToSchedule))
+			return framework.AsStatus(fmt.Errorf("running AddVolume on PreFilter plugin %q: %w", pl.Name(), err))
+		}
+	}
+	return nil
+}
+
+func (f *Framework) runPreFilterExtensionAddVolume(ctx context.Context, pl framework.PreFilterPlugin,
+	state *framework.CycleState, volumeToSchedule *scpv1alpha1.StorageVolume,
+	volumeInfoToAdd *framework.VolumeInfo, poolInfo *framework.PoolInfo) *framework.Status {
+	if !state.ShouldRecordPluginMetrics() {
+		return pl.PreFilterExtensions().AddVolume(ctx, state, volumeToSchedule, volumeInfoToAdd, poolInfo)
+	}
+	startTime := time.Now()
+	status := pl.PreFilterExtensions().AddVolume(ctx, state, volumeToSchedule, volumeInfoToAdd, poolInfo)
+	f.metricsRecorder.ObservePluginDurationAsync(preFilterExtensionAdd, pl.Name(), status.Code().String(),
+		metrics.SinceInSeconds(startTime))
+	return status
+}
+
+// RunPreFilterExtensionRemoveVolume calls the RemoveVolume interface for the set of configured
+// PreFilter plugins. It returns directly if any of the plugins return any status other than
+// Success.
+func (f *Framework) RunPreFilterExtensionRemoveVolume(ctx context.Context, state *framework.CycleState,
+	volumeToSchedule *scpv1alpha1.StorageVolume, volumeInfoToRemove *framework.VolumeInfo,
+	poolInfo *framework.PoolInfo) (status *framework.Status) {
+	for _, pl := range f.preFilterPlugins {
+		if pl.PreFilterExtensions() == nil {
+			continue
+		}
+		status = f.runPreFilterExtensionRemoveVolume(ctx, pl, state, volumeToSchedule, volumeInfoToRemove,
+			poolInfo)
+		if !status.IsSuccess() {
+			err := status.AsError()
+			klog.ErrorS(err, "Failed running RemoveVolume on PreFilter plugin", "plugin", pl.Name(),
+				"volume", klog.KObj(volumeToSchedule))
+			return framework.AsStatus(fmt.Errorf("running RemoveVolume on PreFilter plugin %q: %w", pl.Name(), err))
+		}
+	}
+	return nil
+}
+
+func (f *Framework) runPreFilterExtensionRemoveVolume(ctx context.Context, pl framework.PreFilterPlugin,
+	state *framework.CycleState, volumeToSchedule *scpv1alpha1.StorageVolume,
+	volumeInfoToRemove *framework.VolumeInfo, poolInfo *framework.PoolInfo) *framework.Status {
+	if !state.ShouldRecordPluginMetrics() {
+		return pl.PreFilterExtensions().RemoveVolume(ctx, state, volumeToSchedule, volumeInfoToRemove, poolInfo)
+	}
+	startTime := time.Now()
+	status := pl.PreFilterExtensions().RemoveVolume(ctx, state, volumeToSchedule, volumeInfoToRemove, poolInfo)
+	f.metricsRecorder.ObservePluginDurationAsync(preFilterExtensionRemove, pl.Name(), status.Code().String(),
+		metrics.SinceInSeconds(startTime))
+	return status
 }
 
 // RunFilterPlugins runs the set of configured Filter plugins for volume on the given pool. If any
 // of these plugins doesn't return "Success", the given pool is not suitable for the volume.
-// Meanwhile, the failure message and status are set for the given pool.
+// Meanwhile, the failure message and status are set for the given pool. The remaining plugins
+// are skipped once a plugin returns UnschedulableAndUnresolvable, as nothing can make the pool
+// suitable then.
 func (f *Framework) RunFilterPlugins(ctx context.Context, state *framework.CycleState,
-	volume *scpv1alpha1.StorageVolume, poolInfo *framework.PoolInfo) framework.PluginToStatus {
-	statuses := make(framework.PluginToStatus)
+	volume *scpv1alpha1.StorageVolume, poolInfo *framework.PoolInfo) (statuses framework.PluginToStatus) {
+	// Filter plugins run for every pool, so the extension point is only recorded in the
+	// sampled cycles, like the plugins.
+	if state.ShouldRecordPluginMetrics() {
+		startTime := time.Now()
+		defer func() {
+			f.metricsRecorder.ObserveExtensionPointDurationAsync(filter, statuses.Merge().Code().String(),
+				f.profileName, metrics.SinceInSeconds(startTime))
+		}()
+	}
+	statuses = make(framework.PluginToStatus)
 	for _, pl := range f.filterPlugins {
 		pluginStatus := f.runFilterPlugin(ctx, pl, state, volume, poolInfo)
 		if !pluginStatus.IsSuccess() {
 			if !pluginStatus.IsUnschedulable() {
-				// Filter plugins are not supposed to return any status other than Success or Unschedulable.
+				// Filter plugins are not supposed to return any status other than Success,
+				// Unschedulable or UnschedulableAndUnresolvable.
 				errStatus := framework.AsStatus(fmt.Errorf("running %q filter plugin: %w", pl.Name(),
 					pluginStatus.AsError())).WithPluginName(pl.Name())
 				return map[string]*framework.Status{pl.Name(): errStatus}
 			}
 			pluginStatus.SetPluginName(pl.Name())
 			statuses[pl.Name()] = pluginStatus
+			if pluginStatus.Code() == framework.UnschedulableAndUnresolvable {
+				return statuses
+			}
 		}
 	}
 	return statuses
@@ -73,14 +511,26 @@ func (f *Framework) RunFilterPlugins(ctx context.Context, state *framework.Cycle
 func (f *Framework) runFilterPlugin(ctx context.Context, pl framework.FilterPlugin,
 	state *framework.CycleState, volume *scpv1alpha1.StorageVolume,
 	poolInfo *framework.PoolInfo) *framework.Status {
-	return pl.Filter(ctx, state, volume, poolInfo)
+	if !state.ShouldRecordPluginMetrics() {
+		return pl.Filter(ctx, state, volume, poolInfo)
+	}
+	startTime := time.Now()
+	status := pl.Filter(ctx, state, volume, poolInfo)
+	f.metricsRecorder.ObservePluginDurationAsync(filter, pl.Name(), status.Code().String(),
+		metrics.SinceInSeconds(startTime))
+	return status
 }
 
 // RunPostFilterPlugins runs the set of configured PostFilter plugins until the first
 // Success or Error is met, otherwise continues to execute all plugins.
 func (f *Framework) RunPostFilterPlugins(ctx context.Context, state *framework.CycleState,
 	volume *scpv1alpha1.StorageVolume, filteredPoolStatusMap framework.PoolToStatusMap) (
-	poolName string, status *framework.Status) {
+	_ *framework.PostFilterResult, status *framework.Status) {
+	startTime := time.Now()
+	defer func() {
+		metrics.FrameworkExtensionPointDuration.WithLabelValues(postFilter, status.Code().String(),
+			f.profileName).Observe(metrics.SinceInSeconds(startTime))
+	}()
 	statuses := make(framework.PluginToStatus)
 	for _, pl := range f.postFilterPlugins {
 		r, s := f.runPostFilterPlugin(ctx, pl, state, volume, filteredPoolStatusMap)
@@ -88,24 +538,36 @@ func (f *Framework) RunPostFilterPlugins(ctx context.Context, state *framework.C
 			return r, s
 		} else if !s.IsUnschedulable() {
 			// Any status other than Success or Unschedulable is Error.
-			return "", framework.AsStatus(s.AsError())
+			return nil, framework.AsStatus(s.AsError())
 		}
 		statuses[pl.Name()] = s
 	}
 
-	return "", statuses.Merge()
+	return nil, statuses.Merge()
 }
 
 func (f *Framework) runPostFilterPlugin(ctx context.Context, pl framework.PostFilterPlugin,
 	state *framework.CycleState, volume *scpv1alpha1.StorageVolume,
-	filteredPoolStatusMap framework.PoolToStatusMap) (string, *framework.Status) {
-	return pl.PostFilter(ctx, state, volume, filteredPoolStatusMap)
+	filteredPoolStatusMap framework.PoolToStatusMap) (*framework.PostFilterResult, *framework.Status) {
+	if !state.ShouldRecordPluginMetrics() {
+		return pl.PostFilter(ctx, state, volume, filteredPoolStatusMap)
+	}
+	startTime := time.Now()
+	r, s := pl.PostFilter(ctx, state, volume, filteredPoolStatusMap)
+	f.metricsRecorder.ObservePluginDurationAsync(postFilter, pl.Name(), s.Code().String(),
+		metrics.SinceInSeconds(startTime))
+	return r, s
 }
 
 // RunPreScorePlugins runs the set of configured pre-score plugins. If any of these plugins returns
 // any status other than "Success", the given pod is rejected.
 func (f *Framework) RunPreScorePlugins(ctx context.Context, state *framework.CycleState,
 	volume *scpv1alpha1.StorageVolume, pools []*scpv1alpha1.StoragePool) (status *framework.Status) {
+	startTime := time.Now()
+	defer func() {
+		metrics.FrameworkExtensionPointDuration.WithLabelValues(preScore, status.Code().String(),
+			f.profileName).Observe(metrics.SinceInSeconds(startTime))
+	}()
 	for _, pl := range f.preScorePlugins {
 		status = f.runPreScorePlugin(ctx, pl, state, volume, pools)
 		if !status.IsSuccess() {
@@ -118,29 +580,43 @@ func (f *Framework) RunPreScorePlugins(ctx context.Context, state *framework.Cyc
 func (f *Framework) runPreScorePlugin(ctx context.Context, pl framework.PreScorePlugin,
 	state *framework.CycleState, volume *scpv1alpha1.StorageVolume,
 	pools []*scpv1alpha1.StoragePool) *framework.Status {
-	return pl.PreScore(ctx, state, volume, pools)
+	if !state.ShouldRecordPluginMetrics() {
+		return pl.PreScore(ctx, state, volume, pools)
+	}
+	startTime := time.Now()
+	status := pl.PreScore(ctx, state, volume, pools)
+	f.metricsRecorder.ObservePluginDurationAsync(preScore, pl.Name(), status.Code().String(),
+		metrics.SinceInSeconds(startTime))
+	return status
 }
 
 // RunScorePlugins runs the set of configured scoring plugins. It returns a list that stores for
-// each scoring plugin name the corresponding PoolScoreList(s). It also returns *Status, which is
-// set to non-success if any of the plugins returns a non-success status.
+// each scoring plugin name the corresponding PoolScoreList(s), normalized and multiplied by the
+// plugin weight. The lists hold the pools in the given order, so they can be added up with
+// PluginToPoolScores.Sum. It also returns *Status, which is set to non-success if any of the
+// plugins returns a non-success status.
 func (f *Framework) RunScorePlugins(ctx context.Context, state *framework.CycleState,
 	volume *scpv1alpha1.StorageVolume, pools []*scpv1alpha1.StoragePool) (
 	ps framework.PluginToPoolScores, status *framework.Status) {
+	startTime := time.Now()
+	defer func() {
+		metrics.FrameworkExtensionPointDuration.WithLabelValues(score, status.Code().String(),
+			f.profileName).Observe(metrics.SinceInSeconds(startTime))
+	}()
 	pluginToPoolScores := make(framework.PluginToPoolScores, len(f.scorePlugins))
 	for _, pl := range f.scorePlugins {
 		pluginToPoolScores[pl.Name()] = make(framework.PoolScoreList, len(pools))
 	}
 
 	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 	errCh := parallelize.NewErrorChannel()
 
 	// Run Score method for each node in parallel.
-	f.prallelizer.Until(ctx, len(pools), func(index int) {
+	f.parallelizer.UntilWithErrorChannel(ctx, len(pools), func(index int) {
 		for _, pl := range f.scorePlugins {
 			pool := pools[index]
-			// TODO update pool referance
-			s, status := f.runScorePlugin(ctx, pl, state, volume, &corev1.ObjectReference{},
+			s, status := f.runScorePlugin(ctx, pl, state, volume, framework.PoolReference(pool),
 				pool.Spec.StorageCohortReference)
 			if !status.IsSuccess() {
 				err := fmt.Errorf("plugin %q failed with: %w", pl.Name(), status.AsError())
@@ -153,50 +629,46 @@ func (f *Framework) RunScorePlugins(ctx context.Context, state *framework.CycleS
 				Score:     s,
 			}
 		}
-	})
+	}, errCh)
 	if err := errCh.ReceiveError(); err != nil {
 		return nil, framework.AsStatus(fmt.Errorf("running Score plugins: %w", err))
 	}
-	/*
-		// Run NormalizeScore method for each ScorePlugin in parallel.
-		f.prallelizer.Until(ctx, len(f.scorePlugins), func(index int) {
-			pl := f.scorePlugins[index]
-			poolScoreList := pluginToPoolScores[pl.Name()]
-			if pl.ScoreExtensions() == nil {
-				return
-			}
-			status := f.runScoreExtension(ctx, pl, state, pod, poolScoreList)
-			if !status.IsSuccess() {
-				err := fmt.Errorf("plugin %q failed with: %w", pl.Name(), status.AsError())
-				errCh.SendErrorWithCancel(err, cancel)
-				return
-			}
-		})
-		if err := errCh.ReceiveError(); err != nil {
-			return nil, framework.AsStatus(fmt.Errorf("running Normalize on Score plugins: %w", err))
+	// Run NormalizeScore method for each ScorePlugin in parallel.
+	f.parallelizer.UntilWithErrorChannel(ctx, len(f.scorePlugins), func(index int) {
+		pl := f.scorePlugins[index]
+		poolScoreList := pluginToPoolScores[pl.Name()]
+		if pl.ScoreExtensions() == nil {
+			return
+		}
+		status := f.runScoreExtension(ctx, pl, state, volume, poolScoreList)
+		if !status.IsSuccess() {
+			err := fmt.Errorf("plugin %q failed with: %w", pl.Name(), status.AsError())
+			errCh.SendErrorWithCancel(err, cancel)
+			return
 		}
-	*/
+	}, errCh)
+	if err := errCh.ReceiveError(); err != nil {
+		return nil, framework.AsStatus(fmt.Errorf("running Normalize on Score plugins: %w", err))
+	}
+
 	// Apply score defaultWeights for each ScorePlugin in parallel.
-	f.prallelizer.Until(ctx, len(f.scorePlugins), func(index int) {
+	f.parallelizer.UntilWithErrorChannel(ctx, len(f.scorePlugins), func(index int) {
 		pl := f.scorePlugins[index]
 		// Score plugins' weight has been checked when they are initialized.
-		//weight := f.scorePluginWeight[pl.Name()]
+		weight := f.scorePluginWeight[pl.Name()]
 		poolScoreList := pluginToPoolScores[pl.Name()]
 
-		for i, nodeScore := range poolScoreList {
-			fmt.Println(i)
-			fmt.Println(nodeScore)
+		for i, poolScore := range poolScoreList {
 			// return error if score plugin returns invalid score.
-			/*
-				if nodeScore.Score > framework.MaxNodeScore || nodeScore.Score < framework.MinNodeScore {
-					err := fmt.Errorf("plugin %q returns an invalid score %v, it should in the range of [%v, %v] after normalizing", pl.Name(), nodeScore.Score, framework.MinNodeScore, framework.MaxNodeScore)
-					errCh.SendErrorWithCancel(err, cancel)
-					return
-				}
-				nodeScoreList[i].Score = nodeScore.Score * int64(weight)
-			*/
+			if poolScore.Score > framework.MaxPoolScore || poolScore.Score < framework.MinPoolScore {
+				err := fmt.Errorf("plugin %q returns an invalid score %v, it should in the range of [%v, %v] after normalizing",
+					pl.Name(), poolScore.Score, framework.MinPoolScore, framework.MaxPoolScore)
+				errCh.SendErrorWithCancel(err, cancel)
+				return
+			}
+			poolScoreList[i].Score = poolScore.Score * int64(weight)
 		}
-	})
+	}, errCh)
 	if err := errCh.ReceiveError(); err != nil {
 		return nil, framework.AsStatus(fmt.Errorf("applying score defaultWeights on Score plugins: %w", err))
 	}
@@ -206,14 +678,28 @@ func (f *Framework) RunScorePlugins(ctx context.Context, state *framework.CycleS
 func (f *Framework) runScorePlugin(ctx context.Context, pl framework.ScorePlugin,
 	state *framework.CycleState, volume *scpv1alpha1.StorageVolume, pool,
 	cohort *corev1.ObjectReference) (int64, *framework.Status) {
-	return pl.Score(ctx, state, volume, pool, cohort)
+	if !state.ShouldRecordPluginMetrics() {
+		return pl.Score(ctx, state, volume, pool, cohort)
+	}
+	startTime := time.Now()
+	s, status := pl.Score(ctx, state, volume, pool, cohort)
+	f.metricsRecorder.ObservePluginDurationAsync(score, pl.Name(), status.Code().String(),
+		metrics.SinceInSeconds(startTime))
+	return s, status
 }
 
-/*
-func (f *Framework) runScoreExtension(ctx context.Context, pl framework.ScorePlugin, state *framework.CycleState, pod *v1.Pod, nodeScoreList framework.NodeScoreList) *framework.Status {
-	return pl.ScoreExtensions().NormalizeScore(ctx, state, pod, nodeScoreList)
+func (f *Framework) runScoreExtension(ctx context.Context, pl framework.ScorePlugin,
+	state *framework.CycleState, volume *scpv1alpha1.StorageVolume,
+	poolScoreList framework.PoolScoreList) *framework.Status {
+	if !state.ShouldRecordPluginMetrics() {
+		return pl.ScoreExtensions().NormalizeScore(ctx, state, volume, poolScoreList)
+	}
+	startTime := time.Now()
+	status := pl.ScoreExtensions().NormalizeScore(ctx, state, volume, poolScoreList)
+	f.metricsRecorder.ObservePluginDurationAsync(scoreExtensionNormalize, pl.Name(), status.Code().String(),
+		metrics.SinceInSeconds(startTime))
+	return status
 }
-*/
 
 // RunReservePluginsReserve runs the Reserve method in the set of configured reserve plugins.
 // If any of these plugins returns an error, it does not continue running the remaining ones and
@@ -221,6 +707,11 @@ func (f *Framework) runScoreExtension(ctx context.Context, pl framework.ScorePlu
 // to call RunReservePluginsUnreserve.
 func (f *Framework) RunReservePluginsReserve(ctx context.Context, state *framework.CycleState,
 	volume *scpv1alpha1.StorageVolume, pool, cohort *corev1.ObjectReference) (status *framework.Status) {
+	startTime := time.Now()
+	defer func() {
+		metrics.FrameworkExtensionPointDuration.WithLabelValues(reserve, status.Code().String(),
+			f.profileName).Observe(metrics.SinceInSeconds(startTime))
+	}()
 	for _, pl := range f.reservePlugins {
 		status = f.runReservePluginReserve(ctx, pl, state, volume, pool, cohort)
 		if !status.IsSuccess() {
@@ -235,12 +726,24 @@ func (f *Framework) RunReservePluginsReserve(ctx context.Context, state *framewo
 func (f *Framework) runReservePluginReserve(ctx context.Context, pl framework.ReservePlugin,
 	state *framework.CycleState, volume *scpv1alpha1.StorageVolume, pool,
 	cohort *corev1.ObjectReference) *framework.Status {
-	return pl.Reserve(ctx, state, volume, pool, cohort)
+	if !state.ShouldRecordPluginMetrics() {
+		return pl.Reserve(ctx, state, volume, pool, cohort)
+	}
+	startTime := time.Now()
+	status := pl.Reserve(ctx, state, volume, pool, cohort)
+	f.metricsRecorder.ObservePluginDurationAsync(reserve, pl.Name(), status.Code().String(),
+		metrics.SinceInSeconds(startTime))
+	return status
 }
 
 // RunReservePluginsUnreserve runs the Unreserve method in the set of configured reserve plugins.
 func (f *Framework) RunReservePluginsUnreserve(ctx context.Context, state *framework.CycleState,
 	volume *scpv1alpha1.StorageVolume, pool, cohort *corev1.ObjectReference) {
+	startTime := time.Now()
+	defer func() {
+		metrics.FrameworkExtensionPointDuration.WithLabelValues(unreserve, framework.Success.String(),
+			f.profileName).Observe(metrics.SinceInSeconds(startTime))
+	}()
 	// Execute the Unreserve operation of each reserve plugin in the *reverse* order in which the
 	// Reserve operation was executed.
 	for i := len(f.reservePlugins) - 1; i >= 0; i-- {
@@ -250,7 +753,131 @@ func (f *Framework) RunReservePluginsUnreserve(ctx context.Context, state *frame
 
 func (f *Framework) runReservePluginUnreserve(ctx context.Context, pl framework.ReservePlugin,
 	state *framework.CycleState, volume *scpv1alpha1.StorageVolume, pool, cohort *corev1.ObjectReference) {
+	if !state.ShouldRecordPluginMetrics() {
+		pl.Unreserve(ctx, state, volume, pool, cohort)
+		return
+	}
+	startTime := time.Now()
 	pl.Unreserve(ctx, state, volume, pool, cohort)
+	f.metricsRecorder.ObservePluginDurationAsync(unreserve, pl.Name(), framework.Success.String(),
+		metrics.SinceInSeconds(startTime))
+}
+
+// RunPermitPlugins runs the set of configured permit plugins. If any of these plugins returns a
+// status other than "Success" or "Wait", it does not continue running the remaining plugins and
+// returns an error. Otherwise, if any of the plugins returns "Wait", then this function will
+// create and add waiting volume to a map of currently waiting volumes and return status with
+// "Wait" code. Volume will remain waiting volume for the minimum duration returned by the permit
+// plugins.
+func (f *Framework) RunPermitPlugins(ctx context.Context, state *framework.CycleState,
+	volume *scpv1alpha1.StorageVolume, pool, cohort *corev1.ObjectReference) (status *framework.Status) {
+	startTime := time.Now()
+	defer func() {
+		metrics.FrameworkExtensionPointDuration.WithLabelValues(permit, status.Code().String(),
+			f.profileName).Observe(metrics.SinceInSeconds(startTime))
+	}()
+	pluginsWaitTime := make(map[string]time.Duration)
+	statusCode := framework.Success
+	for _, pl := range f.permitPlugins {
+		status, timeout := f.runPermitPlugin(ctx, pl, state, volume, pool, cohort)
+		if !status.IsSuccess() {
+			if status.IsUnschedulable() {
+				klog.V(4).InfoS("Volume rejected by permit plugin", "volume", klog.KObj(volume),
+					"plugin", pl.Name(), "status", status.Message())
+				status.SetPluginName(pl.Name())
+				return status
+			}
+			if status.Code() == framework.Wait {
+				// Not allowed to be greater than maxTimeout.
+				if timeout > maxTimeout {
+					timeout = maxTimeout
+				}
+				pluginsWaitTime[pl.Name()] = timeout
+				statusCode = framework.Wait
+			} else {
+				err := status.AsError()
+				klog.ErrorS(err, "Failed running Permit plugin", "plugin", pl.Name(), "volume", klog.KObj(volume))
+				return framework.AsStatus(fmt.Errorf("running Permit plugin %q: %w", pl.Name(),
+					err)).WithPluginName(pl.Name())
+			}
+		}
+	}
+	if statusCode == framework.Wait {
+		waitingVolume := newWaitingVolume(volume, pluginsWaitTime)
+		f.waitingVolumes.add(waitingVolume)
+		msg := fmt.Sprintf("one or more plugins asked to wait and no plugin rejected volume %q",
+			volume.Name)
+		klog.V(4).InfoS("One or more plugins asked to wait and no plugin rejected volume",
+			"volume", klog.KObj(volume))
+		return framework.NewStatus(framework.Wait, msg)
+	}
+	return nil
+}
+
+func (f *Framework) runPermitPlugin(ctx context.Context, pl framework.PermitPlugin,
+	state *framework.CycleState, volume *scpv1alpha1.StorageVolume, pool,
+	cohort *corev1.ObjectReference) (*framework.Status, time.Duration) {
+	if !state.ShouldRecordPluginMetrics() {
+		return pl.Permit(ctx, state, volume, pool, cohort)
+	}
+	startTime := time.Now()
+	status, timeout := pl.Permit(ctx, state, volume, pool, cohort)
+	f.metricsRecorder.ObservePluginDurationAsync(permit, pl.Name(), status.Code().String(),
+		metrics.SinceInSeconds(startTime))
+	return status, timeout
+}
+
+// WaitOnPermit will block, if the volume is a waiting volume, until the waiting volume is
+// rejected, allowed by all the plugins it waits on, or the shortest timeout of those plugins
+// expires. It also returns when ctx is done.
+func (f *Framework) WaitOnPermit(ctx context.Context, volume *scpv1alpha1.StorageVolume) *framework.Status {
+	waitingVolume := f.waitingVolumes.get(volume.UID)
+	if waitingVolume == nil {
+		return nil
+	}
+	defer f.waitingVolumes.remove(volume.UID)
+	klog.V(4).InfoS("Volume waiting on permit", "volume", klog.KObj(volume))
+
+	var s *framework.Status
+	select {
+	case s = <-waitingVolume.s:
+	case <-ctx.Done():
+		waitingVolume.Reject(ctx.Err().Error())
+		s = <-waitingVolume.s
+	}
+	if !s.IsSuccess() {
+		if s.IsUnschedulable() {
+			klog.V(4).InfoS("Volume rejected while waiting on permit", "volume", klog.KObj(volume),
+				"status", s.Message())
+			return s
+		}
+		err := s.AsError()
+		klog.ErrorS(err, "Failed waiting on permit for volume", "volume", klog.KObj(volume))
+		return framework.AsStatus(fmt.Errorf("waiting on permit for volume: %w", err))
+	}
+	return nil
+}
+
+// IterateOverWaitingVolumes acquires a read lock and iterates over the WaitingVolumes map.
+func (f *Framework) IterateOverWaitingVolumes(callback func(framework.WaitingVolume)) {
+	f.waitingVolumes.iterate(callback)
+}
+
+// GetWaitingVolume returns a reference to a WaitingVolume given its UID.
+func (f *Framework) GetWaitingVolume(uid types.UID) framework.WaitingVolume {
+	if wv := f.waitingVolumes.get(uid); wv != nil {
+		return wv
+	}
+	return nil // Returning nil instead of *waitingVolume(nil).
+}
+
+// RejectWaitingVolume rejects a WaitingVolume given its UID.
+func (f *Framework) RejectWaitingVolume(uid types.UID) bool {
+	if waitingVolume := f.waitingVolumes.get(uid); waitingVolume != nil {
+		waitingVolume.Reject("removed")
+		return true
+	}
+	return false
 }
 
 // RunPreBindPlugins runs the set of configured prebind plugins. It returns a failure (bool) if any
@@ -258,6 +885,11 @@ func (f *Framework) runReservePluginUnreserve(ctx context.Context, pl framework.
 // error occurred in the plugin.
 func (f *Framework) RunPreBindPlugins(ctx context.Context, state *framework.CycleState,
 	volume *scpv1alpha1.StorageVolume, pool, cohort *corev1.ObjectReference) (status *framework.Status) {
+	startTime := time.Now()
+	defer func() {
+		metrics.FrameworkExtensionPointDuration.WithLabelValues(preBind, status.Code().String(),
+			f.profileName).Observe(metrics.SinceInSeconds(startTime))
+	}()
 	for _, pl := range f.preBindPlugins {
 		status = f.runPreBindPlugin(ctx, pl, state, volume, pool, cohort)
 		if !status.IsSuccess() {
@@ -272,12 +904,24 @@ func (f *Framework) RunPreBindPlugins(ctx context.Context, state *framework.Cycl
 func (f *Framework) runPreBindPlugin(ctx context.Context, pl framework.PreBindPlugin,
 	state *framework.CycleState, volume *scpv1alpha1.StorageVolume, pool,
 	cohort *corev1.ObjectReference) *framework.Status {
-	return pl.PreBind(ctx, state, volume, pool, cohort)
+	if !state.ShouldRecordPluginMetrics() {
+		return pl.PreBind(ctx, state, volume, pool, cohort)
+	}
+	startTime := time.Now()
+	status := pl.PreBind(ctx, state, volume, pool, cohort)
+	f.metricsRecorder.ObservePluginDurationAsync(preBind, pl.Name(), status.Code().String(),
+		metrics.SinceInSeconds(startTime))
+	return status
 }
 
 // RunBindPlugins runs the set of configured bind plugins until one returns a non `Skip` status.
 func (f *Framework) RunBindPlugins(ctx context.Context, state *framework.CycleState,
 	volume *scpv1alpha1.StorageVolume, pool, cohort *corev1.ObjectReference) (status *framework.Status) {
+	startTime := time.Now()
+	defer func() {
+		metrics.FrameworkExtensionPointDuration.WithLabelValues(bind, status.Code().String(),
+			f.profileName).Observe(metrics.SinceInSeconds(startTime))
+	}()
 	if len(f.bindPlugins) == 0 {
 		return framework.NewStatus(framework.Skip, "")
 	}
@@ -299,12 +943,24 @@ func (f *Framework) RunBindPlugins(ctx context.Context, state *framework.CycleSt
 func (f *Framework) runBindPlugin(ctx context.Context, bp framework.BindPlugin,
 	state *framework.CycleState, volume *scpv1alpha1.StorageVolume, pool,
 	cohort *corev1.ObjectReference) *framework.Status {
-	return bp.Bind(ctx, state, volume, pool, cohort)
+	if !state.ShouldRecordPluginMetrics() {
+		return bp.Bind(ctx, state, volume, pool, cohort)
+	}
+	startTime := time.Now()
+	status := bp.Bind(ctx, state, volume, pool, cohort)
+	f.metricsRecorder.ObservePluginDurationAsync(bind, bp.Name(), status.Code().String(),
+		metrics.SinceInSeconds(startTime))
+	return status
 }
 
 // RunPostBindPlugins runs the set of configured postbind plugins.
 func (f *Framework) RunPostBindPlugins(ctx context.Context, state *framework.CycleState,
 	volume *scpv1alpha1.StorageVolume, pool, cohort *corev1.ObjectReference) {
+	startTime := time.Now()
+	defer func() {
+		metrics.FrameworkExtensionPointDuration.WithLabelValues(postBind, framework.Success.String(),
+			f.profileName).Observe(metrics.SinceInSeconds(startTime))
+	}()
 	for _, pl := range f.postBindPlugins {
 		f.runPostBindPlugin(ctx, pl, state, volume, pool, cohort)
 	}
@@ -312,5 +968,12 @@ func (f *Framework) RunPostBindPlugins(ctx context.Context, state *framework.Cyc
 
 func (f *Framework) runPostBindPlugin(ctx context.Context, pl framework.PostBindPlugin,
 	state *framework.CycleState, volume *scpv1alpha1.StorageVolume, pool, cohort *corev1.ObjectReference) {
+	if !state.ShouldRecordPluginMetrics() {
+		pl.PostBind(ctx, state, volume, pool, cohort)
+		return
+	}
+	startTime := time.Now()
 	pl.PostBind(ctx, state, volume, pool, cohort)
+	f.metricsRecorder.ObservePluginDurationAsync(postBind, pl.Name(), framework.Success.String(),
+		metrics.SinceInSeconds(startTime))
 }
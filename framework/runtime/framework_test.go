@@ -0,0 +1,164 @@
+package runtime
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	scpv1alpha1 "github.com/openebs/device-localpv/pkg/apis/openebs.io/scp/v1alpha1"
+	"github.com/shovanmaity/volume-scheduler/apis/config"
+	"github.com/shovanmaity/volume-scheduler/framework"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	queueSortPlugin = "test-queue-sort"
+	scorePlugin1    = "test-score-1"
+	scorePlugin2    = "test-score-2"
+)
+
+type testQueueSortPlugin struct{}
+
+func (pl *testQueueSortPlugin) Name() string { return queueSortPlugin }
+
+func (pl *testQueueSortPlugin) Less(_, _ *framework.QueuedVolumeInfo) bool { return false }
+
+// testScorePlugin scores every pool with the same score.
+type testScorePlugin struct {
+	name  string
+	score int64
+}
+
+func (pl *testScorePlugin) Name() string { return pl.name }
+
+func (pl *testScorePlugin) Score(_ context.Context, _ *framework.CycleState, _ *scpv1alpha1.StorageVolume,
+	_, _ *corev1.ObjectReference) (int64, *framework.Status) {
+	return pl.score, nil
+}
+
+func (pl *testScorePlugin) ScoreExtensions() framework.ScoreExtensions { return nil }
+
+func newTestRegistry() Registry {
+	return Registry{
+		queueSortPlugin: func(_ runtime.Object, _ framework.Handle) (framework.Plugin, error) {
+			return &testQueueSortPlugin{}, nil
+		},
+		scorePlugin1: func(_ runtime.Object, _ framework.Handle) (framework.Plugin, error) {
+			return &testScorePlugin{name: scorePlugin1, score: 10}, nil
+		},
+		scorePlugin2: func(_ runtime.Object, _ framework.Handle) (framework.Plugin, error) {
+			return &testScorePlugin{name: scorePlugin2, score: 30}, nil
+		},
+	}
+}
+
+func TestSetScorePluginWeight(t *testing.T) {
+	tests := []struct {
+		name    string
+		plugins []config.Plugin
+		want    map[string]int
+		wantErr bool
+	}{
+		{
+			name:    "unset weight defaults to 1",
+			plugins: []config.Plugin{{Name: scorePlugin1}, {Name: scorePlugin2, Weight: 3}},
+			want:    map[string]int{scorePlugin1: 1, scorePlugin2: 3},
+		},
+		{
+			name:    "negative weight",
+			plugins: []config.Plugin{{Name: scorePlugin1, Weight: -1}},
+			wantErr: true,
+		},
+		{
+			name:    "largest weight which doesn't overflow",
+			plugins: []config.Plugin{{Name: scorePlugin1, Weight: math.MaxInt32}},
+			want:    map[string]int{scorePlugin1: math.MaxInt32},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &Framework{scorePluginWeight: make(map[string]int)}
+			err := f.setScorePluginWeight(config.PluginSet{Enabled: tt.plugins})
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if diff := cmp.Diff(tt.want, f.scorePluginWeight); diff != "" {
+				t.Errorf("unexpected weights (-want, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestSetScorePluginWeightOverflow(t *testing.T) {
+	defer func(old int64) { maxTotalScore = old }(maxTotalScore)
+	maxTotalScore = 5 * framework.MaxPoolScore
+
+	tests := []struct {
+		name    string
+		plugins []config.Plugin
+		wantErr bool
+	}{
+		{
+			name:    "weights add up to the limit",
+			plugins: []config.Plugin{{Name: scorePlugin1, Weight: 2}, {Name: scorePlugin2, Weight: 3}},
+		},
+		{
+			name:    "weights add up past the limit",
+			plugins: []config.Plugin{{Name: scorePlugin1, Weight: 2}, {Name: scorePlugin2, Weight: 4}},
+			wantErr: true,
+		},
+		{
+			name:    "single weight past the limit",
+			plugins: []config.Plugin{{Name: scorePlugin1, Weight: 6}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &Framework{scorePluginWeight: make(map[string]int)}
+			err := f.setScorePluginWeight(config.PluginSet{Enabled: tt.plugins})
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Errorf("got error %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunScorePluginsAppliesWeights(t *testing.T) {
+	profile := &config.VolumeSchedulerProfile{
+		SchedulerName: "test",
+		Plugins: &config.Plugins{
+			QueueSort: config.PluginSet{Enabled: []config.Plugin{{Name: queueSortPlugin}}},
+			Score: config.PluginSet{Enabled: []config.Plugin{
+				{Name: scorePlugin1, Weight: 2},
+				{Name: scorePlugin2},
+			}},
+		},
+	}
+	f, err := NewFramework(newTestRegistry(), profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pools := []*scpv1alpha1.StoragePool{
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "pool-a"}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "pool-b"}},
+	}
+	got, status := f.RunScorePlugins(context.Background(), framework.NewCycleState(),
+		&scpv1alpha1.StorageVolume{}, pools)
+	if !status.IsSuccess() {
+		t.Fatalf("unexpected status: %v", status)
+	}
+	want := framework.PluginToPoolScores{
+		scorePlugin1: {{Namespace: "ns", Name: "pool-a", Score: 20}, {Namespace: "ns", Name: "pool-b", Score: 20}},
+		scorePlugin2: {{Namespace: "ns", Name: "pool-a", Score: 30}, {Namespace: "ns", Name: "pool-b", Score: 30}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected scores (-want, +got): %s", diff)
+	}
+}
@@ -0,0 +1,149 @@
+package runtime
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	scpv1alpha1 "github.com/openebs/device-localpv/pkg/apis/openebs.io/scp/v1alpha1"
+	"github.com/shovanmaity/volume-scheduler/framework"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// waitingVolumesMap a thread-safe map used to maintain volumes waiting in the permit phase.
+type waitingVolumesMap struct {
+	volumes map[types.UID]*waitingVolume
+	mu      sync.RWMutex
+}
+
+// newWaitingVolumesMap returns a new waitingVolumesMap.
+func newWaitingVolumesMap() *waitingVolumesMap {
+	return &waitingVolumesMap{
+		volumes: make(map[types.UID]*waitingVolume),
+	}
+}
+
+// add a new WaitingVolume to the map.
+func (m *waitingVolumesMap) add(wv *waitingVolume) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.volumes[wv.GetVolume().UID] = wv
+}
+
+// remove a WaitingVolume from the map.
+func (m *waitingVolumesMap) remove(uid types.UID) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.volumes, uid)
+}
+
+// get a WaitingVolume from the map.
+func (m *waitingVolumesMap) get(uid types.UID) *waitingVolume {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.volumes[uid]
+}
+
+// iterate acquires a read lock and iterates over the WaitingVolumes map.
+func (m *waitingVolumesMap) iterate(callback func(framework.WaitingVolume)) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, v := range m.volumes {
+		callback(v)
+	}
+}
+
+// waitingVolume represents a volume waiting in the permit phase.
+type waitingVolume struct {
+	volume         *scpv1alpha1.StorageVolume
+	pendingPlugins map[string]*time.Timer
+	s              chan *framework.Status
+	mu             sync.RWMutex
+}
+
+var _ framework.WaitingVolume = &waitingVolume{}
+
+// newWaitingVolume returns a new waitingVolume instance.
+func newWaitingVolume(volume *scpv1alpha1.StorageVolume, pluginsMaxWaitTime map[string]time.Duration) *waitingVolume {
+	wv := &waitingVolume{
+		volume: volume,
+		// Allow() and Reject() calls are non-blocking. This property is guaranteed
+		// by using non-blocking send to this channel. This channel has a buffer of size 1
+		// to ensure that non-blocking send will not be ignored - possible situation when
+		// receiving from this channel happens after non-blocking send.
+		s: make(chan *framework.Status, 1),
+	}
+
+	wv.pendingPlugins = make(map[string]*time.Timer, len(pluginsMaxWaitTime))
+	// The time.AfterFunc calls wv.Reject which iterates through pendingPlugins map. Acquire the
+	// lock here so that time.AfterFunc can only execute after newWaitingVolume finishes.
+	wv.mu.Lock()
+	defer wv.mu.Unlock()
+	for k, v := range pluginsMaxWaitTime {
+		plugin, waitTime := k, v
+		wv.pendingPlugins[plugin] = time.AfterFunc(waitTime, func() {
+			msg := fmt.Sprintf("rejected due to timeout after waiting %v at plugin %v",
+				waitTime, plugin)
+			wv.Reject(msg)
+		})
+	}
+
+	return wv
+}
+
+// GetVolume returns a reference to the waiting volume.
+func (wv *waitingVolume) GetVolume() *scpv1alpha1.StorageVolume {
+	return wv.volume
+}
+
+// GetPendingPlugins returns a list of pending permit plugin's name.
+func (wv *waitingVolume) GetPendingPlugins() []string {
+	wv.mu.RLock()
+	defer wv.mu.RUnlock()
+	plugins := make([]string, 0, len(wv.pendingPlugins))
+	for p := range wv.pendingPlugins {
+		plugins = append(plugins, p)
+	}
+
+	return plugins
+}
+
+// Allow declares the waiting volume is allowed to be scheduled by plugin pluginName.
+// If this is the last remaining plugin to allow, then a success signal is delivered
+// to unblock the volume.
+func (wv *waitingVolume) Allow(pluginName string) {
+	wv.mu.Lock()
+	defer wv.mu.Unlock()
+	if timer, exist := wv.pendingPlugins[pluginName]; exist {
+		timer.Stop()
+		delete(wv.pendingPlugins, pluginName)
+	}
+
+	// Only signal success status after all plugins have allowed
+	if len(wv.pendingPlugins) != 0 {
+		return
+	}
+
+	// The select clause works as a non-blocking send.
+	// If there is no receiver, it's a no-op (default case).
+	select {
+	case wv.s <- framework.NewStatus(framework.Success, ""):
+	default:
+	}
+}
+
+// Reject declares the waiting volume unschedulable.
+func (wv *waitingVolume) Reject(msg string) {
+	wv.mu.RLock()
+	defer wv.mu.RUnlock()
+	for _, timer := range wv.pendingPlugins {
+		timer.Stop()
+	}
+
+	// The select clause works as a non-blocking send.
+	// If there is no receiver, it's a no-op (default case).
+	select {
+	case wv.s <- framework.NewStatus(framework.Unschedulable, msg):
+	default:
+	}
+}
@@ -21,7 +21,25 @@ type PoolScore struct {
 // PluginToPoolScores declares a map from plugin name to its PoolScoreList.
 type PluginToPoolScores map[string]PoolScoreList
 
-// PoolToStatusMap declares map from pool name to its status.
+// Sum adds up the scores every plugin gave to each pool. All the lists are expected to hold the
+// pools in the same order, which is the case for the result of RunScorePlugins.
+func (p PluginToPoolScores) Sum() PoolScoreList {
+	var result PoolScoreList
+	for _, scoreList := range p {
+		if result == nil {
+			result = make(PoolScoreList, len(scoreList))
+			for i := range scoreList {
+				result[i] = PoolScore{Name: scoreList[i].Name, Namespace: scoreList[i].Namespace}
+			}
+		}
+		for i := range scoreList {
+			result[i].Score += scoreList[i].Score
+		}
+	}
+	return result
+}
+
+// PoolToStatusMap declares map from pool key to its status, see GetPoolKey.
 type PoolToStatusMap map[string]*Status
 
 // Code is the Status code/type which is returned from plugins.
@@ -38,6 +56,11 @@ const (
 	// attempt to preempt other pods to get this pod scheduled. The accompanying status message
 	// should explain why the pod is unschedulable.
 	Unschedulable
+	// UnschedulableAndUnresolvable is used when a plugin finds a volume unschedulable and
+	// preemption would not change anything. Plugins should return Unschedulable if it is possible
+	// that the volume can get scheduled with preemption. The accompanying status message should
+	// explain why the volume is unschedulable.
+	UnschedulableAndUnresolvable
 	// Wait is used when a Permit plugin finds a pod scheduling should wait.
 	Wait
 	// Skip is used when a Bind plugin chooses to skip binding.
@@ -45,12 +68,18 @@ const (
 )
 
 // This list should be exactly the same as the codes iota defined above in the same order.
-var codes = []string{"Success", "Error", "Unschedulable", "Wait", "Skip"}
+var codes = []string{"Success", "Error", "Unschedulable", "UnschedulableAndUnresolvable", "Wait", "Skip"}
+
+// String returns the name of the Code.
+func (c Code) String() string {
+	return codes[c]
+}
 
 // statusPrecedence defines a map from status to its precedence, larger value means higher precedent.
 var statusPrecedence = map[Code]int{
-	Error:         2,
-	Unschedulable: 1,
+	Error:                        3,
+	UnschedulableAndUnresolvable: 2,
+	Unschedulable:                1,
 	// Any other statuses we know today, `Skip` or `Wait`, will take precedence over `Success`.
 	Success: -1,
 }
@@ -113,10 +142,11 @@ func (s *Status) IsSuccess() bool {
 	return s.Code() == Success
 }
 
-// IsUnschedulable returns true if "Status" is Unschedulable Unschedulable.
+// IsUnschedulable returns true if "Status" is Unschedulable (Unschedulable or
+// UnschedulableAndUnresolvable).
 func (s *Status) IsUnschedulable() bool {
 	code := s.Code()
-	return code == Unschedulable //|| code == UnschedulableAndUnresolvable
+	return code == Unschedulable || code == UnschedulableAndUnresolvable
 }
 
 // AsError returns nil if the status is a success; otherwise returns an "error" object with a
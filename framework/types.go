@@ -0,0 +1,291 @@
+package framework
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	scpv1alpha1 "github.com/openebs/device-localpv/pkg/apis/openebs.io/scp/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// ActionType is an integer to represent one type of resource change. Different ActionTypes can
+// be bit-wised to compose new semantics.
+type ActionType int64
+
+// Constants for ActionTypes.
+const (
+	Add ActionType = 1 << iota
+	Delete
+	// UpdatePoolCapacity is used when the capacity of a pool changes.
+	UpdatePoolCapacity
+	// UpdatePoolLabel is used when the labels of a pool change.
+	UpdatePoolLabel
+	// UpdatePoolTaint is used when the taints of a pool change.
+	UpdatePoolTaint
+	// UpdatePoolCohort is used when a pool moves to another cohort.
+	UpdatePoolCohort
+	// UpdateCohortLabel is used when the labels of a cohort change.
+	UpdateCohortLabel
+
+	// All is a shortcut for all ActionTypes.
+	All ActionType = 1<<iota - 1
+
+	// Update is a shortcut for all the update ActionTypes.
+	Update = UpdatePoolCapacity | UpdatePoolLabel | UpdatePoolTaint | UpdatePoolCohort | UpdateCohortLabel
+)
+
+// GVK is short for group/version/kind, which can uniquely represent a particular API resource.
+type GVK string
+
+// Constants for GVKs.
+const (
+	StorageVolume GVK = "StorageVolume"
+	StoragePool   GVK = "StoragePool"
+	StorageCohort GVK = "StorageCohort"
+	// WildCard matches all the resources.
+	WildCard GVK = "*"
+)
+
+// ClusterEvent abstracts how a system resource's state gets changed. Resource represents the
+// standard API resources such as StorageVolume, StoragePool, etc. ActionType denotes the
+// specific change such as Add, Update or Delete.
+type ClusterEvent struct {
+	Resource   GVK
+	ActionType ActionType
+	Label      string
+}
+
+// IsWildCard returns true if ClusterEvent follows WildCard semantics.
+func (ce ClusterEvent) IsWildCard() bool {
+	return ce.Resource == WildCard && ce.ActionType == All
+}
+
+var generation int64
+
+// nextGeneration is used to indicate a change of PoolInfo, the counter is shared by all the
+// PoolInfos so that a snapshot only needs to remember the largest generation it has seen.
+func nextGeneration() int64 {
+	return atomic.AddInt64(&generation, 1)
+}
+
+// VolumeInfo is a wrapper to a StorageVolume with additional pre-computed information to
+// accelerate processing.
+type VolumeInfo struct {
+	Volume *scpv1alpha1.StorageVolume
+}
+
+// NewVolumeInfo returns a new VolumeInfo.
+func NewVolumeInfo(volume *scpv1alpha1.StorageVolume) *VolumeInfo {
+	return &VolumeInfo{Volume: volume}
+}
+
+// QueuedVolumeInfo is a VolumeInfo wrapper with additional information related to the volume's
+// status in the scheduling queue, such as the timestamp when it's added to the queue.
+type QueuedVolumeInfo struct {
+	*VolumeInfo
+	// The time volume added to the scheduling queue.
+	Timestamp time.Time
+	// Number of schedule attempts before successfully scheduled. It's used to record the
+	// attempts metric and to compute the backoff of the volume.
+	Attempts int
+	// The time when the volume is added to the queue for the first time. The volume may be
+	// added back to the queue multiple times before it's successfully scheduled. It shouldn't
+	// be updated once initialized.
+	InitialAttemptTimestamp time.Time
+	// NominatedPoolName is the key of the pool the PostFilter plugins nominated for the volume
+	// in its last scheduling attempt, empty if the volume wasn't nominated.
+	NominatedPoolName string
+	// UnschedulablePlugins records the plugins that rejected the volume in its last scheduling
+	// attempt. Only the events these plugins registered move the volume out of the
+	// unschedulable queue.
+	UnschedulablePlugins sets.String
+}
+
+// DeepCopy returns a deep copy of the QueuedVolumeInfo object.
+func (qvi *QueuedVolumeInfo) DeepCopy() *QueuedVolumeInfo {
+	return &QueuedVolumeInfo{
+		VolumeInfo:              NewVolumeInfo(qvi.Volume.DeepCopy()),
+		Timestamp:               qvi.Timestamp,
+		Attempts:                qvi.Attempts,
+		InitialAttemptTimestamp: qvi.InitialAttemptTimestamp,
+		NominatedPoolName:       qvi.NominatedPoolName,
+		UnschedulablePlugins:    qvi.UnschedulablePlugins.Union(nil),
+	}
+}
+
+// PoolInfo is pool level aggregated information.
+type PoolInfo struct {
+	// Pool is the overall information about the pool, nil if the pool was removed while some
+	// volumes are still placed on it.
+	Pool *scpv1alpha1.StoragePool
+
+	// Cohort is the StorageCohort the pool belongs to. It is nil while the cohort is not known
+	// to the scheduler.
+	Cohort *scpv1alpha1.StorageCohort
+
+	// Volumes are the bound and assumed volumes placed on the pool.
+	Volumes []*VolumeInfo
+
+	// Requested is the total capacity requested by all the volumes on this pool.
+	Requested resource.Quantity
+
+	// Allocatable is the total capacity of the pool volumes can be placed on.
+	Allocatable resource.Quantity
+
+	// Generation is bumped whenever any field of the PoolInfo changes. It is used by the
+	// scheduler cache to only clone the PoolInfos which changed since the last snapshot.
+	Generation int64
+}
+
+// NewPoolInfo returns a ready to use empty PoolInfo object. If any volumes are given in
+// arguments, their information will be aggregated in the returned object.
+func NewPoolInfo(volumes ...*scpv1alpha1.StorageVolume) *PoolInfo {
+	pi := &PoolInfo{
+		Generation: nextGeneration(),
+	}
+	for _, volume := range volumes {
+		pi.AddVolume(volume)
+	}
+	return pi
+}
+
+// Clone returns a copy of this pool. Volumes are shared with the original PoolInfo, as they are
+// never mutated in place.
+func (p *PoolInfo) Clone() *PoolInfo {
+	clone := &PoolInfo{
+		Pool:        p.Pool,
+		Cohort:      p.Cohort,
+		Requested:   p.Requested.DeepCopy(),
+		Allocatable: p.Allocatable.DeepCopy(),
+		Generation:  p.Generation,
+	}
+	if len(p.Volumes) > 0 {
+		clone.Volumes = append([]*VolumeInfo(nil), p.Volumes...)
+	}
+	return clone
+}
+
+// Free returns the capacity of the pool which is not requested by any volume yet. It is negative
+// when the pool is overcommitted.
+func (p *PoolInfo) Free() resource.Quantity {
+	free := p.Allocatable.DeepCopy()
+	free.Sub(p.Requested)
+	return free
+}
+
+// String returns representation of human readable format of this PoolInfo.
+func (p *PoolInfo) String() string {
+	volumeKeys := make([]string, len(p.Volumes))
+	for i, v := range p.Volumes {
+		volumeKeys[i] = v.Volume.Name
+	}
+	return fmt.Sprintf("&PoolInfo{Volumes:%v, Requested:%s, Allocatable:%s}",
+		volumeKeys, p.Requested.String(), p.Allocatable.String())
+}
+
+// AddVolume adds volume information to this PoolInfo.
+func (p *PoolInfo) AddVolume(volume *scpv1alpha1.StorageVolume) {
+	p.AddVolumeInfo(NewVolumeInfo(volume))
+}
+
+// AddVolumeInfo adds volume information to this PoolInfo.
+func (p *PoolInfo) AddVolumeInfo(volumeInfo *VolumeInfo) {
+	p.Volumes = append(p.Volumes, volumeInfo)
+	p.Requested.Add(volumeInfo.Volume.Spec.Capacity)
+	p.Generation = nextGeneration()
+}
+
+// RemoveVolume subtracts volume information from this PoolInfo.
+func (p *PoolInfo) RemoveVolume(volume *scpv1alpha1.StorageVolume) error {
+	for i := range p.Volumes {
+		if p.Volumes[i].Volume.UID != volume.UID {
+			continue
+		}
+		// delete the element
+		p.Volumes[i] = p.Volumes[len(p.Volumes)-1]
+		p.Volumes[len(p.Volumes)-1] = nil
+		p.Volumes = p.Volumes[:len(p.Volumes)-1]
+
+		p.Requested.Sub(volume.Spec.Capacity)
+		p.Generation = nextGeneration()
+		return nil
+	}
+	return fmt.Errorf("no corresponding volume %s in volumes of pool %s", volume.Name, p.poolName())
+}
+
+// SetPool sets the overall pool information.
+func (p *PoolInfo) SetPool(pool *scpv1alpha1.StoragePool) {
+	p.Pool = pool
+	p.Allocatable = pool.Status.Capacity.Total.DeepCopy()
+	p.Generation = nextGeneration()
+}
+
+// SetCohort sets the cohort the pool belongs to, nil if the cohort is not known.
+func (p *PoolInfo) SetCohort(cohort *scpv1alpha1.StorageCohort) {
+	p.Cohort = cohort
+	p.Generation = nextGeneration()
+}
+
+// CohortKey returns the namespace/name key of the cohort referenced by the pool, or an empty
+// string if the pool doesn't reference any cohort. A reference without namespace refers to a
+// cohort in the namespace of the pool.
+func (p *PoolInfo) CohortKey() string {
+	if p.Pool == nil || p.Pool.Spec.StorageCohortReference == nil {
+		return ""
+	}
+	return GetReferenceKey(p.Pool.Spec.StorageCohortReference, p.Pool.Namespace)
+}
+
+// RemovePool removes the pool object, leaving all other tracking information.
+func (p *PoolInfo) RemovePool() {
+	p.Pool = nil
+	p.Allocatable = resource.Quantity{}
+	p.Generation = nextGeneration()
+}
+
+func (p *PoolInfo) poolName() string {
+	if p.Pool == nil {
+		return "<nil>"
+	}
+	return p.Pool.Name
+}
+
+// GetPoolKey returns the namespace/name key of the pool, which indexes the pools in the
+// scheduler cache and its snapshots.
+func GetPoolKey(pool *scpv1alpha1.StoragePool) string {
+	return types.NamespacedName{Namespace: pool.GetNamespace(), Name: pool.GetName()}.String()
+}
+
+// GetCohortKey returns the namespace/name key of the cohort, which indexes the cohorts in the
+// scheduler cache.
+func GetCohortKey(cohort *scpv1alpha1.StorageCohort) string {
+	return types.NamespacedName{Namespace: cohort.GetNamespace(), Name: cohort.GetName()}.String()
+}
+
+// GetReferenceKey returns the namespace/name key of the referenced object. A reference without
+// namespace is resolved in the given namespace, the one of the object holding the reference.
+func GetReferenceKey(ref *corev1.ObjectReference, namespace string) string {
+	if len(ref.Namespace) != 0 {
+		namespace = ref.Namespace
+	}
+	return types.NamespacedName{Namespace: namespace, Name: ref.Name}.String()
+}
+
+// PoolReference returns a reference to the given pool, as passed to the plugins which run after
+// a pool has been selected.
+func PoolReference(pool *scpv1alpha1.StoragePool) *corev1.ObjectReference {
+	if pool == nil {
+		return nil
+	}
+	return &corev1.ObjectReference{
+		APIVersion: scpv1alpha1.SchemeGroupVersion.String(),
+		Kind:       "StoragePool",
+		Namespace:  pool.GetNamespace(),
+		Name:       pool.GetName(),
+		UID:        pool.GetUID(),
+	}
+}
@@ -6,8 +6,12 @@ require (
 	github.com/google/go-cmp v0.5.5
 	github.com/openebs/device-localpv v0.5.1-0.20211022170548-c622de0fd078
 	github.com/pkg/errors v0.9.1
-	golang.org/x/time v0.0.0-20210723032227-1f47c861a9ac // indirect
+	github.com/prometheus/client_golang v1.11.0
 	k8s.io/api v0.22.2
+	k8s.io/apimachinery v0.22.2
 	k8s.io/client-go v11.0.1-0.20190409021438-1a26190bd76a+incompatible
 	k8s.io/klog/v2 v2.9.0
+	sigs.k8s.io/yaml v1.2.0
 )
+
+replace k8s.io/client-go => k8s.io/client-go v0.22.2
@@ -0,0 +1,43 @@
+// Package profile holds the definition of a scheduling Profile.
+package profile
+
+import (
+	"fmt"
+
+	"github.com/shovanmaity/volume-scheduler/apis/config"
+	"github.com/shovanmaity/volume-scheduler/apis/config/validation"
+	frameworkruntime "github.com/shovanmaity/volume-scheduler/framework/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// Map holds frameworks indexed by scheduler name.
+type Map map[string]*frameworkruntime.Framework
+
+// NewMap validates the given configuration against the registry and builds a framework for
+// each of its profiles.
+func NewMap(cfg *config.VolumeSchedulerConfiguration, r frameworkruntime.Registry,
+	opts ...frameworkruntime.Option) (Map, error) {
+	if err := validation.ValidateVolumeSchedulerConfiguration(cfg, sets.NewString(r.Names()...)); err != nil {
+		return nil, err
+	}
+
+	opts = append([]frameworkruntime.Option{
+		frameworkruntime.WithParallelism(int(cfg.Parallelism)),
+	}, opts...)
+	m := make(Map, len(cfg.Profiles))
+	for i := range cfg.Profiles {
+		p := &cfg.Profiles[i]
+		fwk, err := frameworkruntime.NewFramework(r, p, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("creating profile for scheduler name %s: %w", p.SchedulerName, err)
+		}
+		m[p.SchedulerName] = fwk
+	}
+	return m, nil
+}
+
+// HandlesSchedulerName returns whether a profile handles the given scheduler name.
+func (m Map) HandlesSchedulerName(name string) bool {
+	_, ok := m[name]
+	return ok
+}
@@ -0,0 +1,353 @@
+package scheduler
+
+import (
+	"fmt"
+
+	scpv1alpha1 "github.com/openebs/device-localpv/pkg/apis/openebs.io/scp/v1alpha1"
+	informers "github.com/openebs/device-localpv/pkg/generated/informer/scp/externalversions"
+	"github.com/shovanmaity/volume-scheduler/framework"
+	"github.com/shovanmaity/volume-scheduler/framework/plugins/helper"
+	internalqueue "github.com/shovanmaity/volume-scheduler/scheduler/internal/queue"
+	"k8s.io/apimachinery/pkg/api/equality"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+// addAllEventHandlers adds the event handlers of the scheduler to the informers of the factory.
+// The volumes placed on a pool feed the cache, the others feed the scheduling queue.
+func addAllEventHandlers(sched *Scheduler, informerFactory informers.SharedInformerFactory) {
+	volumeInformer := informerFactory.Scp().V1alpha1().StorageVolumes().Informer()
+
+	// scheduled volume cache
+	volumeInformer.AddEventHandler(
+		cache.FilteringResourceEventHandler{
+			FilterFunc: func(obj interface{}) bool {
+				switch t := obj.(type) {
+				case *scpv1alpha1.StorageVolume:
+					return assignedVolume(t)
+				case cache.DeletedFinalStateUnknown:
+					if volume, ok := t.Obj.(*scpv1alpha1.StorageVolume); ok {
+						return assignedVolume(volume)
+					}
+					utilruntime.HandleError(fmt.Errorf("unable to convert object %T to *scpv1alpha1.StorageVolume in %T", obj, sched))
+					return false
+				default:
+					utilruntime.HandleError(fmt.Errorf("unable to handle object in %T: %T", sched, obj))
+					return false
+				}
+			},
+			Handler: cache.ResourceEventHandlerFuncs{
+				AddFunc:    sched.addVolumeToCache,
+				UpdateFunc: sched.updateVolumeInCache,
+				DeleteFunc: sched.deleteVolumeFromCache,
+			},
+		},
+	)
+
+	// unscheduled volume queue
+	volumeInformer.AddEventHandler(
+		cache.FilteringResourceEventHandler{
+			FilterFunc: func(obj interface{}) bool {
+				switch t := obj.(type) {
+				case *scpv1alpha1.StorageVolume:
+					return !assignedVolume(t) && sched.responsibleForVolume(t)
+				case cache.DeletedFinalStateUnknown:
+					if volume, ok := t.Obj.(*scpv1alpha1.StorageVolume); ok {
+						return !assignedVolume(volume) && sched.responsibleForVolume(volume)
+					}
+					utilruntime.HandleError(fmt.Errorf("unable to convert object %T to *scpv1alpha1.StorageVolume in %T", obj, sched))
+					return false
+				default:
+					utilruntime.HandleError(fmt.Errorf("unable to handle object in %T: %T", sched, obj))
+					return false
+				}
+			},
+			Handler: cache.ResourceEventHandlerFuncs{
+				AddFunc:    sched.addVolumeToSchedulingQueue,
+				UpdateFunc: sched.updateVolumeInSchedulingQueue,
+				DeleteFunc: sched.deleteVolumeFromSchedulingQueue,
+			},
+		},
+	)
+
+	informerFactory.Scp().V1alpha1().StoragePools().Informer().AddEventHandler(
+		cache.ResourceEventHandlerFuncs{
+			AddFunc:    sched.addPoolToCache,
+			UpdateFunc: sched.updatePoolInCache,
+			DeleteFunc: sched.deletePoolFromCache,
+		},
+	)
+
+	informerFactory.Scp().V1alpha1().StorageCohorts().Informer().AddEventHandler(
+		cache.ResourceEventHandlerFuncs{
+			AddFunc:    sched.addCohortToCache,
+			UpdateFunc: sched.updateCohortInCache,
+			DeleteFunc: sched.deleteCohortFromCache,
+		},
+	)
+}
+
+func (sched *Scheduler) addPoolToCache(obj interface{}) {
+	pool, ok := obj.(*scpv1alpha1.StoragePool)
+	if !ok {
+		klog.ErrorS(nil, "Cannot convert to *scpv1alpha1.StoragePool", "obj", obj)
+		return
+	}
+
+	sched.Cache.AddPool(pool)
+	klog.V(3).InfoS("Add event for pool", "pool", klog.KObj(pool))
+	sched.SchedulingQueue.MoveAllToActiveOrBackoffQueue(internalqueue.PoolAdd)
+}
+
+func (sched *Scheduler) updatePoolInCache(oldObj, newObj interface{}) {
+	oldPool, ok := oldObj.(*scpv1alpha1.StoragePool)
+	if !ok {
+		klog.ErrorS(nil, "Cannot convert oldObj to *scpv1alpha1.StoragePool", "oldObj", oldObj)
+		return
+	}
+	newPool, ok := newObj.(*scpv1alpha1.StoragePool)
+	if !ok {
+		klog.ErrorS(nil, "Cannot convert newObj to *scpv1alpha1.StoragePool", "newObj", newObj)
+		return
+	}
+
+	sched.Cache.UpdatePool(oldPool, newPool)
+	// Only requeue unschedulable volumes if the pool changed in a way that may make them
+	// schedulable, resyncs and status heartbeats don't.
+	for _, event := range poolSchedulingPropertiesChange(newPool, oldPool) {
+		sched.SchedulingQueue.MoveAllToActiveOrBackoffQueue(event)
+	}
+}
+
+func (sched *Scheduler) deletePoolFromCache(obj interface{}) {
+	var pool *scpv1alpha1.StoragePool
+	switch t := obj.(type) {
+	case *scpv1alpha1.StoragePool:
+		pool = t
+	case cache.DeletedFinalStateUnknown:
+		var ok bool
+		pool, ok = t.Obj.(*scpv1alpha1.StoragePool)
+		if !ok {
+			klog.ErrorS(nil, "Cannot convert to *scpv1alpha1.StoragePool", "obj", t.Obj)
+			return
+		}
+	default:
+		klog.ErrorS(nil, "Cannot convert to *scpv1alpha1.StoragePool", "obj", t)
+		return
+	}
+	klog.V(3).InfoS("Delete event for pool", "pool", klog.KObj(pool))
+	if err := sched.Cache.RemovePool(pool); err != nil {
+		klog.ErrorS(err, "Scheduler cache RemovePool failed")
+	}
+}
+
+func (sched *Scheduler) addCohortToCache(obj interface{}) {
+	cohort, ok := obj.(*scpv1alpha1.StorageCohort)
+	if !ok {
+		klog.ErrorS(nil, "Cannot convert to *scpv1alpha1.StorageCohort", "obj", obj)
+		return
+	}
+
+	sched.Cache.AddCohort(cohort)
+	klog.V(3).InfoS("Add event for cohort", "cohort", klog.KObj(cohort))
+	sched.SchedulingQueue.MoveAllToActiveOrBackoffQueue(internalqueue.CohortAdd)
+}
+
+func (sched *Scheduler) updateCohortInCache(oldObj, newObj interface{}) {
+	oldCohort, ok := oldObj.(*scpv1alpha1.StorageCohort)
+	if !ok {
+		klog.ErrorS(nil, "Cannot convert oldObj to *scpv1alpha1.StorageCohort", "oldObj", oldObj)
+		return
+	}
+	newCohort, ok := newObj.(*scpv1alpha1.StorageCohort)
+	if !ok {
+		klog.ErrorS(nil, "Cannot convert newObj to *scpv1alpha1.StorageCohort", "newObj", newObj)
+		return
+	}
+
+	sched.Cache.UpdateCohort(oldCohort, newCohort)
+	// The plugins only look at the labels of the cohorts.
+	if !equality.Semantic.DeepEqual(oldCohort.GetLabels(), newCohort.GetLabels()) {
+		sched.SchedulingQueue.MoveAllToActiveOrBackoffQueue(internalqueue.CohortLabelChange)
+	}
+}
+
+func (sched *Scheduler) deleteCohortFromCache(obj interface{}) {
+	var cohort *scpv1alpha1.StorageCohort
+	switch t := obj.(type) {
+	case *scpv1alpha1.StorageCohort:
+		cohort = t
+	case cache.DeletedFinalStateUnknown:
+		var ok bool
+		cohort, ok = t.Obj.(*scpv1alpha1.StorageCohort)
+		if !ok {
+			klog.ErrorS(nil, "Cannot convert to *scpv1alpha1.StorageCohort", "obj", t.Obj)
+			return
+		}
+	default:
+		klog.ErrorS(nil, "Cannot convert to *scpv1alpha1.StorageCohort", "obj", t)
+		return
+	}
+	klog.V(3).InfoS("Delete event for cohort", "cohort", klog.KObj(cohort))
+	if err := sched.Cache.RemoveCohort(cohort); err != nil {
+		klog.ErrorS(err, "Scheduler cache RemoveCohort failed")
+	}
+}
+
+func (sched *Scheduler) addVolumeToSchedulingQueue(obj interface{}) {
+	volume := obj.(*scpv1alpha1.StorageVolume)
+	klog.V(3).InfoS("Add event for unscheduled volume", "volume", klog.KObj(volume))
+	if err := sched.SchedulingQueue.Add(volume); err != nil {
+		utilruntime.HandleError(fmt.Errorf("unable to queue %T: %v", obj, err))
+	}
+}
+
+func (sched *Scheduler) updateVolumeInSchedulingQueue(oldObj, newObj interface{}) {
+	oldVolume, newVolume := oldObj.(*scpv1alpha1.StorageVolume), newObj.(*scpv1alpha1.StorageVolume)
+	// Bypass update event that carries identical objects; otherwise, a duplicated volume may
+	// get enqueued.
+	if oldVolume.ResourceVersion == newVolume.ResourceVersion {
+		return
+	}
+
+	isAssumed, err := sched.Cache.IsAssumedVolume(newVolume)
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("failed to check whether volume %s/%s is assumed: %v",
+			newVolume.Namespace, newVolume.Name, err))
+	}
+	if isAssumed {
+		return
+	}
+
+	if err := sched.SchedulingQueue.Update(oldVolume, newVolume); err != nil {
+		utilruntime.HandleError(fmt.Errorf("unable to update %T: %v", newObj, err))
+	}
+}
+
+func (sched *Scheduler) deleteVolumeFromSchedulingQueue(obj interface{}) {
+	var volume *scpv1alpha1.StorageVolume
+	switch t := obj.(type) {
+	case *scpv1alpha1.StorageVolume:
+		volume = obj.(*scpv1alpha1.StorageVolume)
+	case cache.DeletedFinalStateUnknown:
+		var ok bool
+		volume, ok = t.Obj.(*scpv1alpha1.StorageVolume)
+		if !ok {
+			utilruntime.HandleError(fmt.Errorf("unable to convert object %T to *scpv1alpha1.StorageVolume in %T", obj, sched))
+			return
+		}
+	default:
+		utilruntime.HandleError(fmt.Errorf("unable to handle object in %T: %T", sched, obj))
+		return
+	}
+	klog.V(3).InfoS("Delete event for unscheduled volume", "volume", klog.KObj(volume))
+	if err := sched.SchedulingQueue.Delete(volume); err != nil {
+		utilruntime.HandleError(fmt.Errorf("unable to dequeue %T: %v", obj, err))
+	}
+	fwk, err := sched.frameworkForVolume(volume)
+	if err != nil {
+		// This shouldn't happen, because we only accept for scheduling the volumes which
+		// specify a scheduler name that matches one of the profiles.
+		klog.ErrorS(err, "Unable to get profile", "volume", klog.KObj(volume))
+		return
+	}
+	// If a waiting volume is rejected, it indicates it's previously assumed and we're removing
+	// it from the scheduler cache. In this case, signal an AssignedVolumeDelete event to
+	// immediately retry some unscheduled volumes.
+	if fwk.RejectWaitingVolume(volume.UID) {
+		sched.SchedulingQueue.MoveAllToActiveOrBackoffQueue(internalqueue.AssignedVolumeDelete)
+	}
+}
+
+// addVolumeToCache adds a volume placed on a pool to the cache. A volume the scheduler assumed
+// is confirmed, its binding showed up.
+func (sched *Scheduler) addVolumeToCache(obj interface{}) {
+	volume, ok := obj.(*scpv1alpha1.StorageVolume)
+	if !ok {
+		klog.ErrorS(nil, "Cannot convert to *scpv1alpha1.StorageVolume", "obj", obj)
+		return
+	}
+	klog.V(3).InfoS("Add event for scheduled volume", "volume", klog.KObj(volume))
+
+	if err := sched.Cache.AddVolume(volume); err != nil {
+		klog.ErrorS(err, "Scheduler cache AddVolume failed", "volume", klog.KObj(volume))
+	}
+
+	sched.SchedulingQueue.MoveAllToActiveOrBackoffQueue(internalqueue.AssignedVolumeAdd)
+}
+
+func (sched *Scheduler) updateVolumeInCache(oldObj, newObj interface{}) {
+	oldVolume, ok := oldObj.(*scpv1alpha1.StorageVolume)
+	if !ok {
+		klog.ErrorS(nil, "Cannot convert oldObj to *scpv1alpha1.StorageVolume", "oldObj", oldObj)
+		return
+	}
+	newVolume, ok := newObj.(*scpv1alpha1.StorageVolume)
+	if !ok {
+		klog.ErrorS(nil, "Cannot convert newObj to *scpv1alpha1.StorageVolume", "newObj", newObj)
+		return
+	}
+	klog.V(4).InfoS("Update event for scheduled volume", "volume", klog.KObj(oldVolume))
+
+	if err := sched.Cache.UpdateVolume(oldVolume, newVolume); err != nil {
+		klog.ErrorS(err, "Scheduler cache UpdateVolume failed", "volume", klog.KObj(oldVolume))
+	}
+
+	sched.SchedulingQueue.MoveAllToActiveOrBackoffQueue(internalqueue.AssignedVolumeUpdate)
+}
+
+func (sched *Scheduler) deleteVolumeFromCache(obj interface{}) {
+	var volume *scpv1alpha1.StorageVolume
+	switch t := obj.(type) {
+	case *scpv1alpha1.StorageVolume:
+		volume = t
+	case cache.DeletedFinalStateUnknown:
+		var ok bool
+		volume, ok = t.Obj.(*scpv1alpha1.StorageVolume)
+		if !ok {
+			klog.ErrorS(nil, "Cannot convert to *scpv1alpha1.StorageVolume", "obj", t.Obj)
+			return
+		}
+	default:
+		klog.ErrorS(nil, "Cannot convert to *scpv1alpha1.StorageVolume", "obj", t)
+		return
+	}
+	klog.V(3).InfoS("Delete event for scheduled volume", "volume", klog.KObj(volume))
+	if err := sched.Cache.RemoveVolume(volume); err != nil {
+		klog.ErrorS(err, "Scheduler cache RemoveVolume failed", "volume", klog.KObj(volume))
+	}
+
+	sched.SchedulingQueue.MoveAllToActiveOrBackoffQueue(internalqueue.AssignedVolumeDelete)
+}
+
+// assignedVolume selects volumes that are assigned (scheduled and running).
+func assignedVolume(volume *scpv1alpha1.StorageVolume) bool {
+	ref := volume.Spec.StoragePoolReference
+	return ref != nil && len(ref.Name) != 0
+}
+
+// responsibleForVolume returns true if the volume asks for one of the profiles of the scheduler.
+func (sched *Scheduler) responsibleForVolume(volume *scpv1alpha1.StorageVolume) bool {
+	_, ok := sched.Profiles[profileNameForVolume(volume)]
+	return ok
+}
+
+// poolSchedulingPropertiesChange returns the events for the changes of the pool the plugins look
+// at: its capacity, its labels, its taints and its cohort.
+func poolSchedulingPropertiesChange(newPool, oldPool *scpv1alpha1.StoragePool) []framework.ClusterEvent {
+	var events []framework.ClusterEvent
+	if !equality.Semantic.DeepEqual(oldPool.Status.Capacity, newPool.Status.Capacity) {
+		events = append(events, internalqueue.PoolCapacityChange)
+	}
+	if !equality.Semantic.DeepEqual(oldPool.GetLabels(), newPool.GetLabels()) {
+		events = append(events, internalqueue.PoolLabelChange)
+	}
+	if oldPool.GetAnnotations()[helper.PoolTaintsAnnotation] != newPool.GetAnnotations()[helper.PoolTaintsAnnotation] {
+		events = append(events, internalqueue.PoolTaintChange)
+	}
+	if !equality.Semantic.DeepEqual(oldPool.Spec.StorageCohortReference, newPool.Spec.StorageCohortReference) {
+		events = append(events, internalqueue.PoolCohortChange)
+	}
+	return events
+}
@@ -0,0 +1,192 @@
+package scheduler
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	scpv1alpha1 "github.com/openebs/device-localpv/pkg/apis/openebs.io/scp/v1alpha1"
+	"github.com/openebs/device-localpv/pkg/generated/clientset/scp/internalclientset/fake"
+	informers "github.com/openebs/device-localpv/pkg/generated/informer/scp/externalversions"
+	"github.com/shovanmaity/volume-scheduler/apis/config"
+	"github.com/shovanmaity/volume-scheduler/framework"
+	"github.com/shovanmaity/volume-scheduler/framework/plugins"
+	"github.com/shovanmaity/volume-scheduler/framework/plugins/defaultbinder"
+	"github.com/shovanmaity/volume-scheduler/framework/plugins/helper"
+	"github.com/shovanmaity/volume-scheduler/framework/plugins/queuesort"
+	internalqueue "github.com/shovanmaity/volume-scheduler/scheduler/internal/queue"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+)
+
+func makeTestVolume(name, poolName, schedulerName string) *scpv1alpha1.StorageVolume {
+	v := &scpv1alpha1.StorageVolume{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name, UID: types.UID(name)},
+	}
+	v.Spec.Capacity = resource.MustParse("1Gi")
+	if len(poolName) != 0 {
+		v.Spec.StoragePoolReference = &corev1.ObjectReference{Namespace: "ns", Name: poolName}
+	}
+	if len(schedulerName) != 0 {
+		v.Annotations = map[string]string{SchedulerNameAnnotation: schedulerName}
+	}
+	return v
+}
+
+func makeTestPool(name string) *scpv1alpha1.StoragePool {
+	p := &scpv1alpha1.StoragePool{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name}}
+	p.Status.Capacity.Total = resource.MustParse("10Gi")
+	return p
+}
+
+// pendingVolumeNames returns the sorted names of the volumes in the scheduling queue.
+func pendingVolumeNames(sched *Scheduler) []string {
+	var names []string
+	for _, v := range sched.SchedulingQueue.PendingVolumes() {
+		names = append(names, v.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestAddAllEventHandlers(t *testing.T) {
+	objects := []runtime.Object{
+		makeTestPool("pool-a"),
+		makeTestVolume("bound", "pool-a", ""),
+		makeTestVolume("pending", "", ""),
+		makeTestVolume("other", "", "other-scheduler"),
+	}
+	client := fake.NewSimpleClientset(objects...)
+	informerFactory := informers.NewSharedInformerFactory(client, 0)
+	cfg := &config.VolumeSchedulerConfiguration{
+		Parallelism: 16,
+		Profiles: []config.VolumeSchedulerProfile{{
+			SchedulerName: config.DefaultSchedulerName,
+			Plugins: &config.Plugins{
+				QueueSort: config.PluginSet{Enabled: []config.Plugin{{Name: queuesort.Name}}},
+				Bind:      config.PluginSet{Enabled: []config.Plugin{{Name: defaultbinder.Name}}},
+			},
+		}},
+	}
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	sched, err := New(client, informerFactory, record.NewFakeRecorder(100), cfg,
+		plugins.NewInTreeRegistry(), stopCh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	informerFactory.Start(stopCh)
+	informerFactory.WaitForCacheSync(stopCh)
+
+	// waitFor polls until the cache holds the given volumes and pools, and the queue the given
+	// pending volumes.
+	waitFor := func(step string, poolCount int, cached, pending []string) {
+		t.Helper()
+		err := wait.Poll(10*time.Millisecond, wait.ForeverTestTimeout, func() (bool, error) {
+			if sched.Cache.PoolCount() != poolCount {
+				return false, nil
+			}
+			if count, _ := sched.Cache.VolumeCount(); count != len(cached) {
+				return false, nil
+			}
+			for _, name := range cached {
+				if _, err := sched.Cache.GetVolume(makeTestVolume(name, "", "")); err != nil {
+					return false, nil
+				}
+			}
+			return cmp.Equal(pending, pendingVolumeNames(sched)), nil
+		})
+		if err != nil {
+			count, _ := sched.Cache.VolumeCount()
+			t.Fatalf("%s: got %d pools and %d volumes in the cache and pending volumes %v, want %d pools, "+
+				"volumes %v and pending volumes %v", step, sched.Cache.PoolCount(), count,
+				pendingVolumeNames(sched), poolCount, cached, pending)
+		}
+	}
+
+	// The volume for another scheduler is neither cached nor queued.
+	waitFor("initial list", 1, []string{"bound"}, []string{"pending"})
+
+	ctx := context.Background()
+	volumes := client.ScpV1alpha1().StorageVolumes("ns")
+	if _, err := client.ScpV1alpha1().StoragePools("ns").Create(ctx, makeTestPool("pool-b"),
+		metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := volumes.Create(ctx, makeTestVolume("new", "", ""), metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	waitFor("pool and volume created", 2, []string{"bound"}, []string{"new", "pending"})
+
+	// Binding the volume moves it from the queue to the cache.
+	if _, err := volumes.Update(ctx, makeTestVolume("pending", "pool-b", ""), metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	waitFor("volume bound", 2, []string{"bound", "pending"}, []string{"new"})
+
+	for _, name := range []string{"bound", "new"} {
+		if err := volumes.Delete(ctx, name, metav1.DeleteOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	waitFor("volumes deleted", 2, []string{"pending"}, nil)
+}
+
+func TestPoolSchedulingPropertiesChange(t *testing.T) {
+	tests := []struct {
+		name   string
+		update func(pool *scpv1alpha1.StoragePool)
+		want   []framework.ClusterEvent
+	}{
+		{
+			name:   "no change",
+			update: func(pool *scpv1alpha1.StoragePool) {},
+		},
+		{
+			name: "resync with another resource version",
+			update: func(pool *scpv1alpha1.StoragePool) {
+				pool.ResourceVersion = "2"
+			},
+		},
+		{
+			name: "capacity changed",
+			update: func(pool *scpv1alpha1.StoragePool) {
+				pool.Status.Capacity.Total = resource.MustParse("20Gi")
+			},
+			want: []framework.ClusterEvent{internalqueue.PoolCapacityChange},
+		},
+		{
+			name: "labels and taints changed",
+			update: func(pool *scpv1alpha1.StoragePool) {
+				pool.Labels = map[string]string{"tier": "fast"}
+				pool.Annotations = map[string]string{helper.PoolTaintsAnnotation: "[]"}
+			},
+			want: []framework.ClusterEvent{internalqueue.PoolLabelChange, internalqueue.PoolTaintChange},
+		},
+		{
+			name: "cohort changed",
+			update: func(pool *scpv1alpha1.StoragePool) {
+				pool.Spec.StorageCohortReference = &corev1.ObjectReference{Namespace: "ns", Name: "cohort-a"}
+			},
+			want: []framework.ClusterEvent{internalqueue.PoolCohortChange},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldPool := makeTestPool("pool-a")
+			newPool := oldPool.DeepCopy()
+			tt.update(newPool)
+			got := poolSchedulingPropertiesChange(newPool, oldPool)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("unexpected events (-want, +got): %s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,498 @@
+package cache
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	scpv1alpha1 "github.com/openebs/device-localpv/pkg/apis/openebs.io/scp/v1alpha1"
+	"github.com/shovanmaity/volume-scheduler/framework"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+)
+
+var (
+	cleanAssumedPeriod = 1 * time.Second
+)
+
+// New returns a Cache implementation.
+// It automatically starts a go routine that manages expiration of assumed volumes.
+// "ttl" is how long the assumed volume will get expired.
+// "stop" is the channel that would close the background goroutine.
+func New(ttl time.Duration, stop <-chan struct{}) Cache {
+	cache := newCache(ttl, cleanAssumedPeriod, stop)
+	cache.run()
+	return cache
+}
+
+type cacheImpl struct {
+	stop   <-chan struct{}
+	ttl    time.Duration
+	period time.Duration
+
+	// This mutex guards all fields within this cache struct.
+	mu sync.RWMutex
+	// a set of assumed volume keys.
+	// The key could further be used to get an entry in volumeStates.
+	assumedVolumes sets.String
+	// a map from volume key to volumeState.
+	volumeStates map[string]*volumeState
+	// a map from pool namespace/name key to the aggregated information of the pool.
+	pools map[string]*framework.PoolInfo
+	// a map from cohort namespace/name key to the cohort, used to fill the cohort of the pools.
+	cohorts map[string]*scpv1alpha1.StorageCohort
+}
+
+type volumeState struct {
+	volume *scpv1alpha1.StorageVolume
+	// Used by assumedVolume to determinate expiration.
+	deadline *time.Time
+	// Used to block cache from expiring assumedVolume if binding still runs
+	bindingFinished bool
+}
+
+func newCache(ttl, period time.Duration, stop <-chan struct{}) *cacheImpl {
+	return &cacheImpl{
+		ttl:    ttl,
+		period: period,
+		stop:   stop,
+
+		pools:          make(map[string]*framework.PoolInfo),
+		cohorts:        make(map[string]*scpv1alpha1.StorageCohort),
+		assumedVolumes: make(sets.String),
+		volumeStates:   make(map[string]*volumeState),
+	}
+}
+
+// getVolumeKey returns the string key of a volume.
+func getVolumeKey(volume *scpv1alpha1.StorageVolume) (string, error) {
+	uid := string(volume.UID)
+	if len(uid) == 0 {
+		return "", fmt.Errorf("cannot get cache key for volume with empty UID")
+	}
+	return uid, nil
+}
+
+// poolKeyOf returns the namespace/name key of the pool the volume is placed on. A pool
+// reference without namespace refers to a pool in the namespace of the volume.
+func poolKeyOf(volume *scpv1alpha1.StorageVolume) (string, error) {
+	ref := volume.Spec.StoragePoolReference
+	if ref == nil || len(ref.Name) == 0 {
+		return "", fmt.Errorf("volume %v is not placed on a pool", klog.KObj(volume))
+	}
+	return framework.GetReferenceKey(ref, volume.Namespace), nil
+}
+
+// UpdateSnapshot takes a snapshot of cached pool info. This should be called at the beginning
+// of every scheduling cycle. Only the PoolInfos whose generation is newer than the snapshot are
+// cloned, the others are still up to date in the snapshot.
+func (cache *cacheImpl) UpdateSnapshot(poolSnapshot *Snapshot) error {
+	cache.mu.RLock()
+	defer cache.mu.RUnlock()
+
+	// Get the last generation of the snapshot.
+	snapshotGeneration := poolSnapshot.generation
+	updateAllLists := false
+
+	for key, poolInfo := range cache.pools {
+		if poolInfo.Generation > poolSnapshot.generation {
+			poolSnapshot.generation = poolInfo.Generation
+		}
+		if poolInfo.Generation <= snapshotGeneration {
+			continue
+		}
+		if poolInfo.Pool == nil {
+			// The pool was removed but still holds volumes, it is not part of the snapshot.
+			if _, ok := poolSnapshot.poolInfoMap[key]; ok {
+				delete(poolSnapshot.poolInfoMap, key)
+				updateAllLists = true
+			}
+			continue
+		}
+		if existing, ok := poolSnapshot.poolInfoMap[key]; ok {
+			// We need to preserve the original pointer of the PoolInfo struct since it is used
+			// in the poolInfoList.
+			*existing = *poolInfo.Clone()
+		} else {
+			poolSnapshot.poolInfoMap[key] = poolInfo.Clone()
+			updateAllLists = true
+		}
+	}
+
+	// Pools deleted from the cache have no generation left to compare with, remove them from
+	// the snapshot here.
+	for key := range poolSnapshot.poolInfoMap {
+		if poolInfo, ok := cache.pools[key]; !ok || poolInfo.Pool == nil {
+			delete(poolSnapshot.poolInfoMap, key)
+			updateAllLists = true
+		}
+	}
+
+	if updateAllLists || len(poolSnapshot.poolInfoList) != len(poolSnapshot.poolInfoMap) {
+		poolSnapshot.updatePoolInfoList()
+	}
+	return nil
+}
+
+func (cache *cacheImpl) PoolCount() int {
+	cache.mu.RLock()
+	defer cache.mu.RUnlock()
+	return len(cache.pools)
+}
+
+func (cache *cacheImpl) VolumeCount() (int, error) {
+	cache.mu.RLock()
+	defer cache.mu.RUnlock()
+	count := 0
+	for _, p := range cache.pools {
+		count += len(p.Volumes)
+	}
+	return count, nil
+}
+
+func (cache *cacheImpl) AssumeVolume(volume *scpv1alpha1.StorageVolume) error {
+	key, err := getVolumeKey(volume)
+	if err != nil {
+		return err
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if _, ok := cache.volumeStates[key]; ok {
+		return fmt.Errorf("volume %v is in the cache, so can't be assumed", key)
+	}
+
+	if err := cache.addVolume(key, volume); err != nil {
+		return err
+	}
+	cache.volumeStates[key] = &volumeState{
+		volume: volume,
+	}
+	cache.assumedVolumes.Insert(key)
+	return nil
+}
+
+func (cache *cacheImpl) FinishBinding(volume *scpv1alpha1.StorageVolume) error {
+	return cache.finishBinding(volume, time.Now())
+}
+
+// finishBinding exists to make tests deterministic by injecting now as an argument
+func (cache *cacheImpl) finishBinding(volume *scpv1alpha1.StorageVolume, now time.Time) error {
+	key, err := getVolumeKey(volume)
+	if err != nil {
+		return err
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	klog.V(5).InfoS("Finished binding for volume, can be expired", "volume", klog.KObj(volume))
+	currState, ok := cache.volumeStates[key]
+	if ok && cache.assumedVolumes.Has(key) {
+		dl := now.Add(cache.ttl)
+		currState.bindingFinished = true
+		currState.deadline = &dl
+	}
+	return nil
+}
+
+func (cache *cacheImpl) ForgetVolume(volume *scpv1alpha1.StorageVolume) error {
+	key, err := getVolumeKey(volume)
+	if err != nil {
+		return err
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	currState, ok := cache.volumeStates[key]
+	if ok && !samePool(currState.volume, volume) {
+		return fmt.Errorf("volume %v was assumed on %v but assigned to %v",
+			key, poolKeyOrEmpty(volume), poolKeyOrEmpty(currState.volume))
+	}
+
+	// Only assumed volume can be forgotten.
+	if ok && cache.assumedVolumes.Has(key) {
+		return cache.removeVolume(key, volume)
+	}
+	return fmt.Errorf("volume %v wasn't assumed so cannot be forgotten", key)
+}
+
+// Assumes that lock is already acquired.
+func (cache *cacheImpl) addVolume(key string, volume *scpv1alpha1.StorageVolume) error {
+	poolKey, err := poolKeyOf(volume)
+	if err != nil {
+		return err
+	}
+	p, ok := cache.pools[poolKey]
+	if !ok {
+		p = framework.NewPoolInfo()
+		cache.pools[poolKey] = p
+	}
+	p.AddVolume(volume)
+	return nil
+}
+
+// Assumes that lock is already acquired.
+func (cache *cacheImpl) updateVolume(key string, oldVolume, newVolume *scpv1alpha1.StorageVolume) error {
+	if err := cache.removeVolume(key, oldVolume); err != nil {
+		return err
+	}
+	if err := cache.addVolume(key, newVolume); err != nil {
+		return err
+	}
+	cache.volumeStates[key] = &volumeState{volume: newVolume}
+	return nil
+}
+
+// Assumes that lock is already acquired.
+// Removes a volume from the cached pool info. If the pool information was already removed and
+// there are no more volumes left in the pool, cleans up the pool from the cache.
+func (cache *cacheImpl) removeVolume(key string, volume *scpv1alpha1.StorageVolume) error {
+	poolKey, err := poolKeyOf(volume)
+	if err != nil {
+		return err
+	}
+	p, ok := cache.pools[poolKey]
+	if !ok {
+		klog.ErrorS(nil, "Pool not found when trying to remove volume", "pool", poolKey,
+			"volume", klog.KObj(volume))
+	} else {
+		if err := p.RemoveVolume(volume); err != nil {
+			return err
+		}
+		if len(p.Volumes) == 0 && p.Pool == nil {
+			delete(cache.pools, poolKey)
+		}
+	}
+
+	delete(cache.volumeStates, key)
+	delete(cache.assumedVolumes, key)
+	return nil
+}
+
+func (cache *cacheImpl) AddVolume(volume *scpv1alpha1.StorageVolume) error {
+	key, err := getVolumeKey(volume)
+	if err != nil {
+		return err
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	currState, ok := cache.volumeStates[key]
+	switch {
+	case ok && cache.assumedVolumes.Has(key):
+		if !samePool(currState.volume, volume) {
+			// The volume was added to a different pool than it was assumed to.
+			klog.InfoS("Volume was added to a different pool than it was assumed",
+				"volume", klog.KObj(volume), "assumedPool", poolKeyOrEmpty(currState.volume),
+				"currentPool", poolKeyOrEmpty(volume))
+		}
+		if err = cache.updateVolume(key, currState.volume, volume); err != nil {
+			klog.ErrorS(err, "Error occurred while updating volume")
+		}
+	case !ok:
+		// Volume was expired. We should add it back.
+		if err = cache.addVolume(key, volume); err != nil {
+			klog.ErrorS(err, "Error occurred while adding volume")
+			return err
+		}
+		cache.volumeStates[key] = &volumeState{volume: volume}
+	default:
+		return fmt.Errorf("volume %v was already in added state", key)
+	}
+	return nil
+}
+
+func (cache *cacheImpl) UpdateVolume(oldVolume, newVolume *scpv1alpha1.StorageVolume) error {
+	key, err := getVolumeKey(oldVolume)
+	if err != nil {
+		return err
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	currState, ok := cache.volumeStates[key]
+	// An assumed volume won't have Update/Remove event. It needs to have Add event
+	// before Update event, in which case the state would change from Assumed to Added.
+	if ok && !cache.assumedVolumes.Has(key) {
+		if !samePool(currState.volume, newVolume) {
+			klog.ErrorS(nil, "Volume updated on a different pool than previously added to",
+				"volume", klog.KObj(oldVolume))
+		}
+		return cache.updateVolume(key, currState.volume, newVolume)
+	}
+	return fmt.Errorf("volume %v is not added to scheduler cache, so cannot be updated", key)
+}
+
+func (cache *cacheImpl) RemoveVolume(volume *scpv1alpha1.StorageVolume) error {
+	key, err := getVolumeKey(volume)
+	if err != nil {
+		return err
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	currState, ok := cache.volumeStates[key]
+	if !ok {
+		return fmt.Errorf("volume %v is not found in scheduler cache, so cannot be removed from it", key)
+	}
+	if !samePool(currState.volume, volume) {
+		klog.ErrorS(nil, "Volume was added to a different pool than it was assumed",
+			"volume", klog.KObj(volume), "assumedPool", poolKeyOrEmpty(volume),
+			"currentPool", poolKeyOrEmpty(currState.volume))
+	}
+	return cache.removeVolume(key, currState.volume)
+}
+
+func (cache *cacheImpl) IsAssumedVolume(volume *scpv1alpha1.StorageVolume) (bool, error) {
+	key, err := getVolumeKey(volume)
+	if err != nil {
+		return false, err
+	}
+
+	cache.mu.RLock()
+	defer cache.mu.RUnlock()
+
+	return cache.assumedVolumes.Has(key), nil
+}
+
+// GetVolume might return a volume for which its pool has already been deleted from
+// the main cache. This is useful to properly process volume update events.
+func (cache *cacheImpl) GetVolume(volume *scpv1alpha1.StorageVolume) (*scpv1alpha1.StorageVolume, error) {
+	key, err := getVolumeKey(volume)
+	if err != nil {
+		return nil, err
+	}
+
+	cache.mu.RLock()
+	defer cache.mu.RUnlock()
+
+	volumeState, ok := cache.volumeStates[key]
+	if !ok {
+		return nil, fmt.Errorf("volume %v does not exist in scheduler cache", key)
+	}
+
+	return volumeState.volume, nil
+}
+
+func (cache *cacheImpl) AddPool(pool *scpv1alpha1.StoragePool) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	key := framework.GetPoolKey(pool)
+	p, ok := cache.pools[key]
+	if !ok {
+		p = framework.NewPoolInfo()
+		cache.pools[key] = p
+	}
+	p.SetPool(pool)
+	p.SetCohort(cache.cohorts[p.CohortKey()])
+}
+
+func (cache *cacheImpl) UpdatePool(oldPool, newPool *scpv1alpha1.StoragePool) {
+	cache.AddPool(newPool)
+}
+
+// RemovePool removes a pool from the cache. Some volumes might still be placed on the removed
+// pool. In that case the pool is only dropped from the snapshots, and is deleted once all its
+// volumes are removed.
+func (cache *cacheImpl) RemovePool(pool *scpv1alpha1.StoragePool) error {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	key := framework.GetPoolKey(pool)
+	p, ok := cache.pools[key]
+	if !ok {
+		return fmt.Errorf("pool %v is not found", key)
+	}
+	p.RemovePool()
+	if len(p.Volumes) == 0 {
+		delete(cache.pools, key)
+	}
+	return nil
+}
+
+func (cache *cacheImpl) AddCohort(cohort *scpv1alpha1.StorageCohort) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	key := framework.GetCohortKey(cohort)
+	cache.cohorts[key] = cohort
+	cache.setPoolsCohort(key, cohort)
+}
+
+func (cache *cacheImpl) UpdateCohort(oldCohort, newCohort *scpv1alpha1.StorageCohort) {
+	cache.AddCohort(newCohort)
+}
+
+func (cache *cacheImpl) RemoveCohort(cohort *scpv1alpha1.StorageCohort) error {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	key := framework.GetCohortKey(cohort)
+	if _, ok := cache.cohorts[key]; !ok {
+		return fmt.Errorf("cohort %v is not found", key)
+	}
+	delete(cache.cohorts, key)
+	cache.setPoolsCohort(key, nil)
+	return nil
+}
+
+// Assumes that lock is already acquired.
+// Sets the cohort of all the pools which reference the cohort with the given key.
+func (cache *cacheImpl) setPoolsCohort(key string, cohort *scpv1alpha1.StorageCohort) {
+	for _, p := range cache.pools {
+		if p.CohortKey() == key {
+			p.SetCohort(cohort)
+		}
+	}
+}
+
+func (cache *cacheImpl) run() {
+	go wait.Until(cache.cleanupExpiredAssumedVolumes, cache.period, cache.stop)
+}
+
+func (cache *cacheImpl) cleanupExpiredAssumedVolumes() {
+	cache.cleanupAssumedVolumes(time.Now())
+}
+
+// cleanupAssumedVolumes exists for making test deterministic by taking time as input argument.
+func (cache *cacheImpl) cleanupAssumedVolumes(now time.Time) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	// The size of assumedVolumes should be small
+	for key := range cache.assumedVolumes {
+		vs, ok := cache.volumeStates[key]
+		if !ok {
+			klog.ErrorS(nil, "Key found in assumed set but not in volumeStates, potentially a logical error")
+			continue
+		}
+		if !vs.bindingFinished {
+			klog.V(5).InfoS("Could not expire cache for volume as binding is still in progress",
+				"volume", klog.KObj(vs.volume))
+			continue
+		}
+		if now.After(*vs.deadline) {
+			klog.InfoS("Volume expired", "volume", klog.KObj(vs.volume))
+			if err := cache.removeVolume(key, vs.volume); err != nil {
+				klog.ErrorS(err, "ExpireVolume failed", "volume", klog.KObj(vs.volume))
+			}
+		}
+	}
+}
+
+// samePool returns whether both volumes are placed on the same pool.
+func samePool(a, b *scpv1alpha1.StorageVolume) bool {
+	return poolKeyOrEmpty(a) == poolKeyOrEmpty(b)
+}
+
+func poolKeyOrEmpty(volume *scpv1alpha1.StorageVolume) string {
+	key, _ := poolKeyOf(volume)
+	return key
+}
@@ -0,0 +1,401 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	scpv1alpha1 "github.com/openebs/device-localpv/pkg/apis/openebs.io/scp/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func makeVolume(uid, poolName, capacity string) *scpv1alpha1.StorageVolume {
+	v := &scpv1alpha1.StorageVolume{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: uid, UID: types.UID(uid)},
+	}
+	v.Spec.Capacity = resource.MustParse(capacity)
+	if len(poolName) != 0 {
+		v.Spec.StoragePoolReference = &corev1.ObjectReference{Name: poolName}
+	}
+	return v
+}
+
+func makePool(name, capacity string) *scpv1alpha1.StoragePool {
+	p := &scpv1alpha1.StoragePool{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name}}
+	p.Status.Capacity.Total = resource.MustParse(capacity)
+	return p
+}
+
+// requested returns the capacity requested on the pool in the cache, and whether the cache
+// knows the pool.
+func requested(cache *cacheImpl, poolKey string) (string, bool) {
+	p, ok := cache.pools[poolKey]
+	if !ok {
+		return "", false
+	}
+	return p.Requested.String(), true
+}
+
+func TestAssumeVolume(t *testing.T) {
+	cache := newCache(time.Second, time.Second, nil)
+	cache.AddPool(makePool("pool-a", "10Gi"))
+
+	v1, v2 := makeVolume("v1", "pool-a", "1Gi"), makeVolume("v2", "pool-a", "2Gi")
+	for _, v := range []*scpv1alpha1.StorageVolume{v1, v2} {
+		if err := cache.AssumeVolume(v); err != nil {
+			t.Fatalf("assuming %s: %v", v.Name, err)
+		}
+	}
+	if got, _ := requested(cache, "ns/pool-a"); got != "3Gi" {
+		t.Errorf("got %s requested on the pool, want 3Gi", got)
+	}
+	if assumed, _ := cache.IsAssumedVolume(v1); !assumed {
+		t.Errorf("volume %s isn't assumed", v1.Name)
+	}
+	if err := cache.AssumeVolume(v1); err == nil {
+		t.Errorf("assuming %s twice: got no error", v1.Name)
+	}
+	if err := cache.AssumeVolume(makeVolume("v3", "", "1Gi")); err == nil {
+		t.Errorf("assuming a volume without pool: got no error")
+	}
+	if err := cache.AssumeVolume(makeVolume("", "pool-a", "1Gi")); err == nil {
+		t.Errorf("assuming a volume without UID: got no error")
+	}
+}
+
+func TestForgetVolume(t *testing.T) {
+	cache := newCache(time.Second, time.Second, nil)
+	cache.AddPool(makePool("pool-a", "10Gi"))
+	assumed := makeVolume("v1", "pool-a", "1Gi")
+	if err := cache.AssumeVolume(assumed); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cache.ForgetVolume(makeVolume("v1", "pool-b", "1Gi")); err == nil {
+		t.Errorf("forgetting the volume on another pool: got no error")
+	}
+	if err := cache.ForgetVolume(assumed); err != nil {
+		t.Fatalf("forgetting the volume: %v", err)
+	}
+	if got, _ := requested(cache, "ns/pool-a"); got != "0" {
+		t.Errorf("got %s requested on the pool, want 0", got)
+	}
+	if isAssumed, _ := cache.IsAssumedVolume(assumed); isAssumed {
+		t.Errorf("forgotten volume is still assumed")
+	}
+	if err := cache.ForgetVolume(assumed); err == nil {
+		t.Errorf("forgetting the volume twice: got no error")
+	}
+
+	// A volume which was added, not assumed, can't be forgotten.
+	added := makeVolume("v2", "pool-a", "1Gi")
+	if err := cache.AddVolume(added); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.ForgetVolume(added); err == nil {
+		t.Errorf("forgetting an added volume: got no error")
+	}
+}
+
+func TestExpireAssumedVolume(t *testing.T) {
+	ttl := 10 * time.Second
+	now := time.Now()
+	tests := []struct {
+		name string
+		// finishBinding tells whether the binding of the volume finished.
+		finishBinding bool
+		// add tells whether the Add event of the volume arrived.
+		add bool
+		// cleanupAfter is how long after the binding finished the cleanup runs.
+		cleanupAfter time.Duration
+		wantExpired  bool
+	}{
+		{
+			name:          "binding finished and ttl passed",
+			finishBinding: true,
+			cleanupAfter:  ttl + time.Second,
+			wantExpired:   true,
+		},
+		{
+			name:          "binding finished, ttl not passed yet",
+			finishBinding: true,
+			cleanupAfter:  ttl / 2,
+		},
+		{
+			name:         "binding still in progress",
+			cleanupAfter: 2 * ttl,
+		},
+		{
+			name:          "add event confirmed the volume",
+			finishBinding: true,
+			add:           true,
+			cleanupAfter:  2 * ttl,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := newCache(ttl, time.Second, nil)
+			cache.AddPool(makePool("pool-a", "10Gi"))
+			v := makeVolume("v1", "pool-a", "1Gi")
+			if err := cache.AssumeVolume(v); err != nil {
+				t.Fatal(err)
+			}
+			if tt.finishBinding {
+				if err := cache.finishBinding(v, now); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.add {
+				if err := cache.AddVolume(v); err != nil {
+					t.Fatal(err)
+				}
+			}
+			cache.cleanupAssumedVolumes(now.Add(tt.cleanupAfter))
+
+			_, err := cache.GetVolume(v)
+			if gotExpired := err != nil; gotExpired != tt.wantExpired {
+				t.Errorf("got expired %v, want %v", gotExpired, tt.wantExpired)
+			}
+			wantRequested := "1Gi"
+			if tt.wantExpired {
+				wantRequested = "0"
+			}
+			if got, _ := requested(cache, "ns/pool-a"); got != wantRequested {
+				t.Errorf("got %s requested on the pool, want %s", got, wantRequested)
+			}
+		})
+	}
+}
+
+func TestAddExpiredVolume(t *testing.T) {
+	ttl := 10 * time.Second
+	now := time.Now()
+	cache := newCache(ttl, time.Second, nil)
+	cache.AddPool(makePool("pool-a", "10Gi"))
+	v := makeVolume("v1", "pool-a", "1Gi")
+	if err := cache.AssumeVolume(v); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.finishBinding(v, now); err != nil {
+		t.Fatal(err)
+	}
+	cache.cleanupAssumedVolumes(now.Add(2 * ttl))
+
+	// The Add event of an expired volume adds it back.
+	if err := cache.AddVolume(v); err != nil {
+		t.Fatalf("adding the expired volume: %v", err)
+	}
+	if got, _ := requested(cache, "ns/pool-a"); got != "1Gi" {
+		t.Errorf("got %s requested on the pool, want 1Gi", got)
+	}
+	if isAssumed, _ := cache.IsAssumedVolume(v); isAssumed {
+		t.Errorf("added volume is still assumed")
+	}
+}
+
+func TestRemovePoolWithVolumes(t *testing.T) {
+	cache := newCache(time.Second, time.Second, nil)
+	pool := makePool("pool-a", "10Gi")
+	cache.AddPool(pool)
+	v := makeVolume("v1", "pool-a", "1Gi")
+	if err := cache.AddVolume(v); err != nil {
+		t.Fatal(err)
+	}
+
+	// The pool is kept as long as volumes are placed on it.
+	if err := cache.RemovePool(pool); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := requested(cache, "ns/pool-a"); !ok {
+		t.Fatalf("pool holding a volume was deleted from the cache")
+	}
+	if err := cache.RemoveVolume(v); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := requested(cache, "ns/pool-a"); ok {
+		t.Errorf("removed pool without volumes is still in the cache")
+	}
+}
+
+// snapshotPoolNames returns the names of the pools in the snapshot's list, in order.
+func snapshotPoolNames(t *testing.T, s *Snapshot) []string {
+	t.Helper()
+	list, err := s.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, p := range list {
+		names = append(names, p.Pool.Name)
+	}
+	if len(list) != len(s.poolInfoMap) {
+		t.Errorf("snapshot list holds %d pools, map holds %d", len(list), len(s.poolInfoMap))
+	}
+	return names
+}
+
+func TestUpdateSnapshot(t *testing.T) {
+	cache := newCache(time.Second, time.Second, nil)
+	poolA, poolB, poolC := makePool("pool-a", "10Gi"), makePool("pool-b", "10Gi"), makePool("pool-c", "10Gi")
+	for _, p := range []*scpv1alpha1.StoragePool{poolC, poolA, poolB} {
+		cache.AddPool(p)
+	}
+	snapshot := NewEmptySnapshot()
+	if err := cache.UpdateSnapshot(snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"pool-a", "pool-b", "pool-c"}, snapshotPoolNames(t, snapshot)); diff != "" {
+		t.Errorf("unexpected pools (-want, +got): %s", diff)
+	}
+	var want int64
+	for _, p := range cache.pools {
+		if p.Generation > want {
+			want = p.Generation
+		}
+	}
+	if snapshot.generation != want {
+		t.Errorf("got snapshot generation %d, want %d", snapshot.generation, want)
+	}
+
+	// Only the pool which changed since the last update is cloned again, into the same
+	// PoolInfo so that the list still points to it.
+	infoA, infoB := snapshot.poolInfoMap["ns/pool-a"], snapshot.poolInfoMap["ns/pool-b"]
+	staleB := *infoB
+	if err := cache.AddVolume(makeVolume("v1", "pool-b", "1Gi")); err != nil {
+		t.Fatal(err)
+	}
+	lastGeneration := snapshot.generation
+	if err := cache.UpdateSnapshot(snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.generation <= lastGeneration {
+		t.Errorf("snapshot generation didn't advance past %d", lastGeneration)
+	}
+	if snapshot.poolInfoMap["ns/pool-a"] != infoA || snapshot.poolInfoMap["ns/pool-b"] != infoB {
+		t.Errorf("PoolInfo pointers of the snapshot weren't preserved")
+	}
+	if infoB.Generation == staleB.Generation || len(infoB.Volumes) != 1 {
+		t.Errorf("changed pool wasn't updated in the snapshot: %d volumes", len(infoB.Volumes))
+	}
+	if got := snapshot.poolInfoList[1]; got != infoB {
+		t.Errorf("snapshot list doesn't point to the updated PoolInfo")
+	}
+
+	// A removed pool leaves the snapshot, and so does a pool removed while it still holds
+	// volumes.
+	if err := cache.RemovePool(poolA); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.RemovePool(poolB); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.UpdateSnapshot(snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"pool-c"}, snapshotPoolNames(t, snapshot)); diff != "" {
+		t.Errorf("unexpected pools (-want, +got): %s", diff)
+	}
+	if _, err := snapshot.Get("ns/pool-b"); err == nil {
+		t.Errorf("removed pool holding volumes is still in the snapshot")
+	}
+
+	// The pool comes back once it is added again.
+	cache.AddPool(poolB)
+	if err := cache.UpdateSnapshot(snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"pool-b", "pool-c"}, snapshotPoolNames(t, snapshot)); diff != "" {
+		t.Errorf("unexpected pools (-want, +got): %s", diff)
+	}
+	if got, err := snapshot.Get("ns/pool-b"); err != nil || len(got.Volumes) != 1 {
+		t.Errorf("re-added pool lost its volumes: %v", err)
+	}
+}
+
+func TestPoolsInNamespaces(t *testing.T) {
+	cache := newCache(time.Second, time.Second, nil)
+	other := makePool("pool-a", "10Gi")
+	other.Namespace = "other"
+	cache.AddPool(makePool("pool-a", "10Gi"))
+	cache.AddPool(other)
+
+	// A reference without namespace resolves in the namespace of the volume.
+	if err := cache.AddVolume(makeVolume("v1", "pool-a", "1Gi")); err != nil {
+		t.Fatal(err)
+	}
+	v2 := makeVolume("v2", "pool-a", "2Gi")
+	v2.Spec.StoragePoolReference.Namespace = "other"
+	if err := cache.AddVolume(v2); err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]string{"ns/pool-a": "1Gi", "other/pool-a": "2Gi"} {
+		if got, _ := requested(cache, key); got != want {
+			t.Errorf("got %s requested on pool %s, want %s", got, key, want)
+		}
+	}
+
+	snapshot := NewEmptySnapshot()
+	if err := cache.UpdateSnapshot(snapshot); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	pools, _ := snapshot.List()
+	for _, p := range pools {
+		got = append(got, p.Pool.Namespace+"/"+p.Pool.Name)
+	}
+	if diff := cmp.Diff([]string{"ns/pool-a", "other/pool-a"}, got); diff != "" {
+		t.Errorf("unexpected pools in the snapshot (-want, +got): %s", diff)
+	}
+	poolInfo, err := snapshot.Get("other/pool-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if poolInfo.Pool != other {
+		t.Errorf("got pool %s/%s, want other/pool-a", poolInfo.Pool.Namespace, poolInfo.Pool.Name)
+	}
+}
+
+func TestPoolCohorts(t *testing.T) {
+	makeCohort := func(namespace, name string) *scpv1alpha1.StorageCohort {
+		return &scpv1alpha1.StorageCohort{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}
+	}
+	cohortOf := func(cache *cacheImpl) *scpv1alpha1.StorageCohort {
+		return cache.pools["ns/pool-a"].Cohort
+	}
+	cache := newCache(time.Second, time.Second, nil)
+	local, other := makeCohort("ns", "cohort"), makeCohort("other", "cohort")
+	cache.AddCohort(local)
+	cache.AddCohort(other)
+
+	// A reference without namespace resolves in the namespace of the pool.
+	pool := makePool("pool-a", "10Gi")
+	pool.Spec.StorageCohortReference = &corev1.ObjectReference{Name: "cohort"}
+	cache.AddPool(pool)
+	if got := cohortOf(cache); got != local {
+		t.Errorf("got cohort %v, want ns/cohort", got)
+	}
+	if err := cache.RemoveCohort(other); err != nil {
+		t.Fatal(err)
+	}
+	if got := cohortOf(cache); got != local {
+		t.Errorf("removing a cohort of another namespace changed the cohort of the pool to %v", got)
+	}
+	if err := cache.RemoveCohort(local); err != nil {
+		t.Fatal(err)
+	}
+	if got := cohortOf(cache); got != nil {
+		t.Errorf("got cohort %v after it was removed, want none", got)
+	}
+
+	// A reference with a namespace resolves in that namespace.
+	cache.AddCohort(other)
+	updated := pool.DeepCopy()
+	updated.Spec.StorageCohortReference.Namespace = "other"
+	cache.UpdatePool(pool, updated)
+	if got := cohortOf(cache); got != other {
+		t.Errorf("got cohort %v, want other/cohort", got)
+	}
+}
@@ -0,0 +1,84 @@
+package cache
+
+import (
+	scpv1alpha1 "github.com/openebs/device-localpv/pkg/apis/openebs.io/scp/v1alpha1"
+)
+
+// Cache collects volumes' information and provides pool-level aggregated information.
+// It's intended for the scheduler to look up pools efficiently. Volumes placed on a pool are
+// either bound, i.e. confirmed by the api server, or assumed, i.e. the scheduler already decided
+// on them but the binding is still in progress.
+//
+// State Machine of a volume's events in the scheduler's cache:
+//
+//	          Assume             Add
+//	Initial --------> Assumed ---------> Added <--+ Update
+//	   ^               |   |            ^  |  |   |
+//	   |      Forget   |   | Expire     |  |  +---+
+//	   +---------------+   v       Add  |  | Remove
+//	                    Expired --------+  v
+//	                                     Deleted
+//
+// Note that an assumed volume can expire, because if we haven't received Add event notifying us
+// for a while, there might be some problems and we shouldn't keep the volume in cache anymore.
+type Cache interface {
+	// PoolCount returns the number of pools in the cache.
+	PoolCount() int
+
+	// VolumeCount returns the number of volumes in the cache (including those from deleted
+	// pools).
+	VolumeCount() (int, error)
+
+	// AssumeVolume assumes a volume scheduled and aggregates the volume's information into the
+	// pool it references. The implementation also decides the policy to expire volume before
+	// being confirmed (receiving Add event). After expiration, its information would be
+	// subtracted.
+	AssumeVolume(volume *scpv1alpha1.StorageVolume) error
+
+	// FinishBinding signals that cache for assumed volume can be expired.
+	FinishBinding(volume *scpv1alpha1.StorageVolume) error
+
+	// ForgetVolume removes an assumed volume from cache.
+	ForgetVolume(volume *scpv1alpha1.StorageVolume) error
+
+	// AddVolume either confirms a volume if it's assumed, or adds it back if it's expired.
+	// If added back, the volume's information would be added again.
+	AddVolume(volume *scpv1alpha1.StorageVolume) error
+
+	// UpdateVolume removes oldVolume's information and adds newVolume's information.
+	UpdateVolume(oldVolume, newVolume *scpv1alpha1.StorageVolume) error
+
+	// RemoveVolume removes a volume. The volume's information would be subtracted from the
+	// assigned pool.
+	RemoveVolume(volume *scpv1alpha1.StorageVolume) error
+
+	// GetVolume returns the volume from the cache with the same namespace and the same name
+	// of the specified volume.
+	GetVolume(volume *scpv1alpha1.StorageVolume) (*scpv1alpha1.StorageVolume, error)
+
+	// IsAssumedVolume returns true if the volume is assumed and not expired.
+	IsAssumedVolume(volume *scpv1alpha1.StorageVolume) (bool, error)
+
+	// AddPool adds overall information about pool.
+	AddPool(pool *scpv1alpha1.StoragePool)
+
+	// UpdatePool updates overall information about pool.
+	UpdatePool(oldPool, newPool *scpv1alpha1.StoragePool)
+
+	// RemovePool removes overall information about pool.
+	RemovePool(pool *scpv1alpha1.StoragePool) error
+
+	// AddCohort adds a cohort, the pools which reference it are updated with it.
+	AddCohort(cohort *scpv1alpha1.StorageCohort)
+
+	// UpdateCohort updates a cohort, the pools which reference it are updated with it.
+	UpdateCohort(oldCohort, newCohort *scpv1alpha1.StorageCohort)
+
+	// RemoveCohort removes a cohort, the pools which reference it are left without cohort.
+	RemoveCohort(cohort *scpv1alpha1.StorageCohort) error
+
+	// UpdateSnapshot updates the passed infoSnapshot to the current contents of Cache.
+	// The snapshot only includes pools that are not deleted at the time this function is
+	// called.
+	UpdateSnapshot(poolSnapshot *Snapshot) error
+}
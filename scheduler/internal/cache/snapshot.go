@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/shovanmaity/volume-scheduler/framework"
+)
+
+// Snapshot is a snapshot of cache PoolInfo. The scheduler takes a snapshot at the beginning of
+// each scheduling cycle and uses it for its operations in that cycle.
+type Snapshot struct {
+	// poolInfoMap a map of pool namespace/name key to a snapshot of its PoolInfo.
+	poolInfoMap map[string]*framework.PoolInfo
+	// poolInfoList is the list of pools ordered by key.
+	poolInfoList []*framework.PoolInfo
+	// generation is the largest PoolInfo generation the snapshot has seen.
+	generation int64
+}
+
+var _ framework.PoolInfoLister = &Snapshot{}
+
+// NewEmptySnapshot initializes a Snapshot struct and returns it.
+func NewEmptySnapshot() *Snapshot {
+	return &Snapshot{
+		poolInfoMap: make(map[string]*framework.PoolInfo),
+	}
+}
+
+// updatePoolInfoList rebuilds the list of pools from the map. The pools are ordered by key so
+// that the scheduler walks them in the same order every cycle.
+func (s *Snapshot) updatePoolInfoList() {
+	s.poolInfoList = make([]*framework.PoolInfo, 0, len(s.poolInfoMap))
+	for _, poolInfo := range s.poolInfoMap {
+		s.poolInfoList = append(s.poolInfoList, poolInfo)
+	}
+	sort.Slice(s.poolInfoList, func(i, j int) bool {
+		return framework.GetPoolKey(s.poolInfoList[i].Pool) < framework.GetPoolKey(s.poolInfoList[j].Pool)
+	})
+}
+
+// NumPools returns the number of pools in the snapshot.
+func (s *Snapshot) NumPools() int {
+	return len(s.poolInfoList)
+}
+
+// List returns the list of pools in the snapshot.
+func (s *Snapshot) List() ([]*framework.PoolInfo, error) {
+	return s.poolInfoList, nil
+}
+
+// Get returns the PoolInfo of the pool with the given namespace/name key.
+func (s *Snapshot) Get(poolKey string) (*framework.PoolInfo, error) {
+	if v, ok := s.poolInfoMap[poolKey]; ok && v.Pool != nil {
+		return v, nil
+	}
+	return nil, fmt.Errorf("poolinfo not found for pool %q", poolKey)
+}
@@ -0,0 +1,43 @@
+package queue
+
+import (
+	"github.com/shovanmaity/volume-scheduler/framework"
+)
+
+// Events that add a volume to a queue, as they are labelled in the metrics.
+const (
+	// VolumeAdd is the event when a volume is added.
+	VolumeAdd = "VolumeAdd"
+	// VolumeUpdate is the event when a volume is updated.
+	VolumeUpdate = "VolumeUpdate"
+	// ScheduleAttemptFailure is the event when a schedule attempt fails.
+	ScheduleAttemptFailure = "ScheduleAttemptFailure"
+	// BackoffComplete is the event when a volume finishes backoff.
+	BackoffComplete = "BackoffComplete"
+)
+
+// Events that trigger a move of the unschedulable volumes.
+var (
+	// AssignedVolumeAdd is the event when a volume placed on a pool is added.
+	AssignedVolumeAdd = framework.ClusterEvent{Resource: framework.StorageVolume, ActionType: framework.Add, Label: "AssignedVolumeAdd"}
+	// AssignedVolumeUpdate is the event when a volume placed on a pool is updated.
+	AssignedVolumeUpdate = framework.ClusterEvent{Resource: framework.StorageVolume, ActionType: framework.Update, Label: "AssignedVolumeUpdate"}
+	// AssignedVolumeDelete is the event when a volume placed on a pool is deleted.
+	AssignedVolumeDelete = framework.ClusterEvent{Resource: framework.StorageVolume, ActionType: framework.Delete, Label: "AssignedVolumeDelete"}
+	// PoolAdd is the event when a pool is added.
+	PoolAdd = framework.ClusterEvent{Resource: framework.StoragePool, ActionType: framework.Add, Label: "PoolAdd"}
+	// PoolCapacityChange is the event when the capacity of a pool changes.
+	PoolCapacityChange = framework.ClusterEvent{Resource: framework.StoragePool, ActionType: framework.UpdatePoolCapacity, Label: "PoolCapacityChange"}
+	// PoolLabelChange is the event when the labels of a pool change.
+	PoolLabelChange = framework.ClusterEvent{Resource: framework.StoragePool, ActionType: framework.UpdatePoolLabel, Label: "PoolLabelChange"}
+	// PoolTaintChange is the event when the taints of a pool change.
+	PoolTaintChange = framework.ClusterEvent{Resource: framework.StoragePool, ActionType: framework.UpdatePoolTaint, Label: "PoolTaintChange"}
+	// PoolCohortChange is the event when a pool moves to another cohort.
+	PoolCohortChange = framework.ClusterEvent{Resource: framework.StoragePool, ActionType: framework.UpdatePoolCohort, Label: "PoolCohortChange"}
+	// CohortAdd is the event when a cohort is added.
+	CohortAdd = framework.ClusterEvent{Resource: framework.StorageCohort, ActionType: framework.Add, Label: "CohortAdd"}
+	// CohortLabelChange is the event when the labels of a cohort change.
+	CohortLabelChange = framework.ClusterEvent{Resource: framework.StorageCohort, ActionType: framework.UpdateCohortLabel, Label: "CohortLabelChange"}
+	// UnschedulableTimeout is the event when a volume stayed too long in unschedulableQ.
+	UnschedulableTimeout = framework.ClusterEvent{Resource: framework.WildCard, ActionType: framework.All, Label: "UnschedulableTimeout"}
+)
@@ -0,0 +1,662 @@
+// Package queue implements the queue of the StorageVolumes waiting to be scheduled.
+package queue
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	scpv1alpha1 "github.com/openebs/device-localpv/pkg/apis/openebs.io/scp/v1alpha1"
+	"github.com/shovanmaity/volume-scheduler/framework"
+	"github.com/shovanmaity/volume-scheduler/scheduler/internal/heap"
+	"github.com/shovanmaity/volume-scheduler/scheduler/metrics"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// DefaultVolumeInitialBackoffDuration is the default value for the initial backoff duration
+	// for unschedulable volumes.
+	DefaultVolumeInitialBackoffDuration time.Duration = 1 * time.Second
+	// DefaultVolumeMaxBackoffDuration is the default value for the max backoff duration
+	// for unschedulable volumes.
+	DefaultVolumeMaxBackoffDuration time.Duration = 10 * time.Second
+	// unschedulableQTimeInterval is the longest a volume stays in unschedulableQ before it is
+	// moved back to the activeQ, in case no event that could make it schedulable showed up.
+	unschedulableQTimeInterval = 60 * time.Second
+)
+
+// SchedulingQueue is an interface for a queue to store volumes waiting to be scheduled.
+// The interface follows a pattern similar to cache.FIFO and cache.Heap and
+// makes it easy to use those data structures as a SchedulingQueue.
+type SchedulingQueue interface {
+	// Add adds a new unscheduled volume to activeQ.
+	Add(volume *scpv1alpha1.StorageVolume) error
+	// AddUnschedulableIfNotPresent adds an unschedulable volume back to scheduling queue.
+	// The volumeSchedulingCycle represents the current scheduling cycle number which can be
+	// returned by calling SchedulingCycle().
+	AddUnschedulableIfNotPresent(volumeInfo *framework.QueuedVolumeInfo, volumeSchedulingCycle int64) error
+	// SchedulingCycle returns the current number of scheduling cycle which is
+	// cached by scheduling queue. Normally, incrementing this number whenever
+	// a volume is popped (e.g. called Pop()) is enough.
+	SchedulingCycle() int64
+	// Pop removes the head of the queue and returns it. It blocks if the
+	// queue is empty and waits until a new item is added to the queue.
+	Pop() (*framework.QueuedVolumeInfo, error)
+	// Update updates a volume waiting to be scheduled.
+	Update(oldVolume, newVolume *scpv1alpha1.StorageVolume) error
+	// Delete deletes a volume waiting to be scheduled.
+	Delete(volume *scpv1alpha1.StorageVolume) error
+	// MoveAllToActiveOrBackoffQueue moves the unschedulable volumes the event may make
+	// schedulable to activeQ or backoffQ.
+	MoveAllToActiveOrBackoffQueue(event framework.ClusterEvent)
+	// Close closes the SchedulingQueue so that the goroutine which is
+	// waiting to pop items can exit gracefully.
+	Close()
+	// NumUnschedulableVolumes returns the number of unschedulable volumes exist in the
+	// SchedulingQueue.
+	NumUnschedulableVolumes() int
+	// PendingVolumes returns all the pending volumes in the queue.
+	PendingVolumes() []*scpv1alpha1.StorageVolume
+	// Run starts the goroutines managing the queue.
+	Run()
+}
+
+// NewSchedulingQueue initializes a priority queue as a new scheduling queue.
+func NewSchedulingQueue(lessFn framework.LessFunc, opts ...Option) SchedulingQueue {
+	return NewPriorityQueue(lessFn, opts...)
+}
+
+// PriorityQueue implements a scheduling queue.
+// The head of PriorityQueue is the highest priority pending volume. This structure
+// has three sub queues. One sub-queue holds volumes that are being considered for
+// scheduling. This is called activeQ and is a Heap. Another queue holds
+// volumes that are already tried and are determined to be unschedulable. The latter
+// is called unschedulableQ. The third queue holds volumes that are moved from
+// unschedulable queues and will be moved to active queue when backoff are completed.
+type PriorityQueue struct {
+	stop  chan struct{}
+	clock clock.Clock
+
+	// volume initial backoff duration.
+	volumeInitialBackoffDuration time.Duration
+	// volume maximum backoff duration.
+	volumeMaxBackoffDuration time.Duration
+
+	lock sync.RWMutex
+	cond sync.Cond
+
+	// activeQ is heap structure that scheduler actively looks at to find volumes to
+	// schedule. Head of heap is the highest priority volume.
+	activeQ *heap.Heap
+	// volumeBackoffQ is a heap ordered by backoff expiry. Volumes which have completed backoff
+	// are popped from this heap before the scheduler looks at activeQ
+	volumeBackoffQ *heap.Heap
+	// unschedulableQ holds volumes that have been tried and determined unschedulable.
+	unschedulableQ *unschedulableVolumes
+	// schedulingCycle represents sequence number of scheduling cycle and is incremented
+	// when a volume is popped.
+	schedulingCycle int64
+	// moveRequestCycle caches the sequence number of scheduling cycle when we
+	// received a move request. Unschedulable volumes in and before this scheduling
+	// cycle will be put back to activeQueue if we were trying to schedule them
+	// when we received move request.
+	moveRequestCycle int64
+
+	// clusterEventMap records the plugins interested in each event.
+	clusterEventMap map[framework.ClusterEvent]sets.String
+
+	// closed indicates that the queue is closed.
+	// It is mainly used to let Pop() exit its control loop while waiting for an item.
+	closed bool
+}
+
+type priorityQueueOptions struct {
+	clock                        clock.Clock
+	volumeInitialBackoffDuration time.Duration
+	volumeMaxBackoffDuration     time.Duration
+	clusterEventMap              map[framework.ClusterEvent]sets.String
+}
+
+// Option configures a PriorityQueue
+type Option func(*priorityQueueOptions)
+
+// WithClock sets clock for PriorityQueue, the default clock is clock.RealClock.
+func WithClock(clock clock.Clock) Option {
+	return func(o *priorityQueueOptions) {
+		o.clock = clock
+	}
+}
+
+// WithVolumeInitialBackoffDuration sets volume initial backoff duration for PriorityQueue.
+func WithVolumeInitialBackoffDuration(duration time.Duration) Option {
+	return func(o *priorityQueueOptions) {
+		o.volumeInitialBackoffDuration = duration
+	}
+}
+
+// WithVolumeMaxBackoffDuration sets volume max backoff duration for PriorityQueue.
+func WithVolumeMaxBackoffDuration(duration time.Duration) Option {
+	return func(o *priorityQueueOptions) {
+		o.volumeMaxBackoffDuration = duration
+	}
+}
+
+// WithClusterEventMap sets clusterEventMap for PriorityQueue.
+func WithClusterEventMap(m map[framework.ClusterEvent]sets.String) Option {
+	return func(o *priorityQueueOptions) {
+		o.clusterEventMap = m
+	}
+}
+
+var defaultPriorityQueueOptions = priorityQueueOptions{
+	clock:                        clock.RealClock{},
+	volumeInitialBackoffDuration: DefaultVolumeInitialBackoffDuration,
+	volumeMaxBackoffDuration:     DefaultVolumeMaxBackoffDuration,
+}
+
+// Making sure that PriorityQueue implements SchedulingQueue.
+var _ SchedulingQueue = &PriorityQueue{}
+
+// newQueuedVolumeInfoForLookup builds a QueuedVolumeInfo object for a lookup in the queue.
+func newQueuedVolumeInfoForLookup(volume *scpv1alpha1.StorageVolume) *framework.QueuedVolumeInfo {
+	// Since this is only used for a lookup in the queue, we only need to set the Volume,
+	// and so we avoid creating a full VolumeInfo, which is expensive to instantiate frequently.
+	return &framework.QueuedVolumeInfo{
+		VolumeInfo: &framework.VolumeInfo{Volume: volume},
+	}
+}
+
+// NewPriorityQueue creates a PriorityQueue object.
+func NewPriorityQueue(lessFn framework.LessFunc, opts ...Option) *PriorityQueue {
+	options := defaultPriorityQueueOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	comp := func(volumeInfo1, volumeInfo2 interface{}) bool {
+		qvInfo1 := volumeInfo1.(*framework.QueuedVolumeInfo)
+		qvInfo2 := volumeInfo2.(*framework.QueuedVolumeInfo)
+		return lessFn(qvInfo1, qvInfo2)
+	}
+
+	pq := &PriorityQueue{
+		clock:                        options.clock,
+		stop:                         make(chan struct{}),
+		volumeInitialBackoffDuration: options.volumeInitialBackoffDuration,
+		volumeMaxBackoffDuration:     options.volumeMaxBackoffDuration,
+		activeQ:                      heap.New(volumeInfoKeyFunc, comp),
+		unschedulableQ:               newUnschedulableVolumes(),
+		moveRequestCycle:             -1,
+		clusterEventMap:              options.clusterEventMap,
+	}
+	pq.cond.L = &pq.lock
+	pq.volumeBackoffQ = heap.New(volumeInfoKeyFunc, pq.volumesCompareBackoffCompleted)
+
+	return pq
+}
+
+// Run starts the goroutine to pump from volumeBackoffQ to activeQ
+func (p *PriorityQueue) Run() {
+	go wait.Until(p.flushBackoffQCompleted, 1.0*time.Second, p.stop)
+	go wait.Until(p.flushUnschedulableQLeftover, 30*time.Second, p.stop)
+}
+
+// Add adds a volume to the active queue. It should be called only when a new volume
+// is added so there is no chance the volume is already in active/unschedulable/backoff queues
+func (p *PriorityQueue) Add(volume *scpv1alpha1.StorageVolume) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	defer p.recordPendingVolumes()
+	qvInfo := p.newQueuedVolumeInfo(volume)
+	if err := p.activeQ.Add(qvInfo); err != nil {
+		klog.ErrorS(err, "Error adding volume to the active queue", "volume", klog.KObj(volume))
+		return err
+	}
+	metrics.SchedulerQueueIncomingVolumes.WithLabelValues("active", VolumeAdd).Inc()
+	if p.unschedulableQ.get(volume) != nil {
+		klog.ErrorS(nil, "Error: volume is already in the unschedulable queue", "volume", klog.KObj(volume))
+		p.unschedulableQ.delete(volume)
+	}
+	// Delete volume from backoffQ if it is backing off
+	if err := p.volumeBackoffQ.Delete(qvInfo); err == nil {
+		klog.ErrorS(nil, "Error: volume is already in the volumeBackoff queue", "volume", klog.KObj(volume))
+	}
+	p.cond.Broadcast()
+
+	return nil
+}
+
+// isVolumeBackingoff returns true if a volume is still waiting for its backoff timer.
+// If this returns true, the volume should not be re-tried.
+func (p *PriorityQueue) isVolumeBackingoff(volumeInfo *framework.QueuedVolumeInfo) bool {
+	boTime := p.getBackoffTime(volumeInfo)
+	return boTime.After(p.clock.Now())
+}
+
+// SchedulingCycle returns current scheduling cycle.
+func (p *PriorityQueue) SchedulingCycle() int64 {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return p.schedulingCycle
+}
+
+// AddUnschedulableIfNotPresent inserts a volume that cannot be scheduled into
+// the queue, unless it is already in the queue. Normally, PriorityQueue puts
+// unschedulable volumes in `unschedulableQ`. But if there has been a recent move
+// request, then the volume is put in `volumeBackoffQ`. So is a volume nominated to a
+// pool, the preemption made room for it without any event moving it.
+func (p *PriorityQueue) AddUnschedulableIfNotPresent(qvInfo *framework.QueuedVolumeInfo,
+	volumeSchedulingCycle int64) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	defer p.recordPendingVolumes()
+	volume := qvInfo.Volume
+	if p.unschedulableQ.get(volume) != nil {
+		return fmt.Errorf("volume %v is already present in unschedulable queue", klog.KObj(volume))
+	}
+
+	if _, exists, _ := p.activeQ.Get(qvInfo); exists {
+		return fmt.Errorf("volume %v is already present in the active queue", klog.KObj(volume))
+	}
+	if _, exists, _ := p.volumeBackoffQ.Get(qvInfo); exists {
+		return fmt.Errorf("volume %v is already present in the backoff queue", klog.KObj(volume))
+	}
+
+	// Refresh the timestamp since the volume is re-added.
+	qvInfo.Timestamp = p.clock.Now()
+
+	// If a move request has been received or the volume was nominated, move it to the
+	// BackoffQ, otherwise move it to unschedulableQ.
+	if p.moveRequestCycle >= volumeSchedulingCycle || len(qvInfo.NominatedPoolName) != 0 {
+		if err := p.volumeBackoffQ.Add(qvInfo); err != nil {
+			return fmt.Errorf("error adding volume %v to the backoff queue: %v", volume.Name, err)
+		}
+		metrics.SchedulerQueueIncomingVolumes.WithLabelValues("backoff", ScheduleAttemptFailure).Inc()
+	} else {
+		p.unschedulableQ.addOrUpdate(qvInfo)
+		metrics.SchedulerQueueIncomingVolumes.WithLabelValues("unschedulable", ScheduleAttemptFailure).Inc()
+	}
+
+	return nil
+}
+
+// flushBackoffQCompleted Moves all volumes from backoffQ which have completed backoff in to
+// activeQ
+func (p *PriorityQueue) flushBackoffQCompleted() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	defer p.recordPendingVolumes()
+	broadcast := false
+	for {
+		rawVolumeInfo := p.volumeBackoffQ.Peek()
+		if rawVolumeInfo == nil {
+			break
+		}
+		volume := rawVolumeInfo.(*framework.QueuedVolumeInfo).Volume
+		boTime := p.getBackoffTime(rawVolumeInfo.(*framework.QueuedVolumeInfo))
+		if boTime.After(p.clock.Now()) {
+			break
+		}
+		_, err := p.volumeBackoffQ.Pop()
+		if err != nil {
+			klog.ErrorS(err, "Unable to pop volume from backoff queue despite backoff completion",
+				"volume", klog.KObj(volume))
+			break
+		}
+		if err := p.activeQ.Add(rawVolumeInfo); err != nil {
+			klog.ErrorS(err, "Error adding volume to the active queue", "volume", klog.KObj(volume))
+		} else {
+			metrics.SchedulerQueueIncomingVolumes.WithLabelValues("active", BackoffComplete).Inc()
+		}
+		broadcast = true
+	}
+
+	if broadcast {
+		p.cond.Broadcast()
+	}
+}
+
+// flushUnschedulableQLeftover moves volumes which stay in unschedulableQ longer than
+// unschedulableQTimeInterval to backoffQ or activeQ.
+func (p *PriorityQueue) flushUnschedulableQLeftover() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	defer p.recordPendingVolumes()
+
+	var volumesToMove []*framework.QueuedVolumeInfo
+	currentTime := p.clock.Now()
+	for _, qvInfo := range p.unschedulableQ.volumeInfoMap {
+		lastScheduleTime := qvInfo.Timestamp
+		if currentTime.Sub(lastScheduleTime) > unschedulableQTimeInterval {
+			volumesToMove = append(volumesToMove, qvInfo)
+		}
+	}
+
+	if len(volumesToMove) > 0 {
+		p.moveVolumesToActiveOrBackoffQueue(volumesToMove, UnschedulableTimeout)
+	}
+}
+
+// Pop removes the head of the active queue and returns it. It blocks if the
+// activeQ is empty and waits until a new item is added to the queue. It
+// increments scheduling cycle when a volume is popped.
+func (p *PriorityQueue) Pop() (*framework.QueuedVolumeInfo, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	defer p.recordPendingVolumes()
+	for p.activeQ.Len() == 0 {
+		// When the queue is empty, invocation of Pop() is blocked until new item is enqueued.
+		// When Close() is called, the p.closed is set and the condition is broadcast,
+		// which causes this loop to continue and return from the Pop().
+		if p.closed {
+			return nil, fmt.Errorf("scheduling queue is closed")
+		}
+		p.cond.Wait()
+	}
+	obj, err := p.activeQ.Pop()
+	if err != nil {
+		return nil, err
+	}
+	qvInfo := obj.(*framework.QueuedVolumeInfo)
+	qvInfo.Attempts++
+	p.schedulingCycle++
+	return qvInfo, err
+}
+
+// isVolumeUpdated checks if the volume is updated in a way that it may have become
+// schedulable. It drops status of the volume and compares it with old version.
+func isVolumeUpdated(oldVolume, newVolume *scpv1alpha1.StorageVolume) bool {
+	strip := func(volume *scpv1alpha1.StorageVolume) *scpv1alpha1.StorageVolume {
+		v := volume.DeepCopy()
+		v.ResourceVersion = ""
+		v.Generation = 0
+		v.Status = scpv1alpha1.StorageVolumeStatus{}
+		return v
+	}
+	return !equality.Semantic.DeepEqual(strip(oldVolume), strip(newVolume))
+}
+
+// Update updates a volume in the active or backoff queue if present. Otherwise, it removes
+// the item from the unschedulable queue if volume is updated in a way that it may
+// become schedulable and adds the updated one to the active queue.
+// If volume is not present in any of the queues, it is added to the active queue.
+func (p *PriorityQueue) Update(oldVolume, newVolume *scpv1alpha1.StorageVolume) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	defer p.recordPendingVolumes()
+
+	if oldVolume != nil {
+		oldVolumeInfo := newQueuedVolumeInfoForLookup(oldVolume)
+		// If the volume is already in the active queue, just update it there.
+		if oldQVInfo, exists, _ := p.activeQ.Get(oldVolumeInfo); exists {
+			qvInfo := updateVolume(oldQVInfo, newVolume)
+			return p.activeQ.Update(qvInfo)
+		}
+
+		// If the volume is in the backoff queue, update it there.
+		if oldQVInfo, exists, _ := p.volumeBackoffQ.Get(oldVolumeInfo); exists {
+			qvInfo := updateVolume(oldQVInfo, newVolume)
+			return p.volumeBackoffQ.Update(qvInfo)
+		}
+	}
+
+	// If the volume is in the unschedulable queue, updating it may make it schedulable.
+	if usQVInfo := p.unschedulableQ.get(newVolume); usQVInfo != nil {
+		qvInfo := updateVolume(usQVInfo, newVolume)
+		if isVolumeUpdated(oldVolume, newVolume) {
+			if p.isVolumeBackingoff(usQVInfo) {
+				if err := p.volumeBackoffQ.Add(qvInfo); err != nil {
+					return err
+				}
+				p.unschedulableQ.delete(usQVInfo.Volume)
+				metrics.SchedulerQueueIncomingVolumes.WithLabelValues("backoff", VolumeUpdate).Inc()
+			} else {
+				if err := p.activeQ.Add(qvInfo); err != nil {
+					return err
+				}
+				p.unschedulableQ.delete(usQVInfo.Volume)
+				metrics.SchedulerQueueIncomingVolumes.WithLabelValues("active", VolumeUpdate).Inc()
+				p.cond.Broadcast()
+			}
+		} else {
+			// Volume update didn't make it schedulable, keep it in the unschedulable queue.
+			p.unschedulableQ.addOrUpdate(qvInfo)
+		}
+
+		return nil
+	}
+	// If volume is not in any of the queues, we put it in the active queue.
+	qvInfo := p.newQueuedVolumeInfo(newVolume)
+	if err := p.activeQ.Add(qvInfo); err != nil {
+		return err
+	}
+	metrics.SchedulerQueueIncomingVolumes.WithLabelValues("active", VolumeUpdate).Inc()
+	p.cond.Broadcast()
+	return nil
+}
+
+// Delete deletes the item from either of the two queues. It assumes the volume is
+// only in one queue.
+func (p *PriorityQueue) Delete(volume *scpv1alpha1.StorageVolume) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	defer p.recordPendingVolumes()
+	if err := p.activeQ.Delete(newQueuedVolumeInfoForLookup(volume)); err != nil {
+		// The item was probably not found in the activeQ.
+		p.volumeBackoffQ.Delete(newQueuedVolumeInfoForLookup(volume))
+		p.unschedulableQ.delete(volume)
+	}
+	return nil
+}
+
+// MoveAllToActiveOrBackoffQueue moves all volumes from unschedulableQ to activeQ or backoffQ.
+// This function adds all volumes and then signals the condition variable to ensure that
+// if Pop() is waiting for an item, it receives the signal after all the volumes are in the
+// queue and the head is the highest priority volume.
+func (p *PriorityQueue) MoveAllToActiveOrBackoffQueue(event framework.ClusterEvent) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	defer p.recordPendingVolumes()
+	unschedulableVolumes := make([]*framework.QueuedVolumeInfo, 0, len(p.unschedulableQ.volumeInfoMap))
+	for _, qvInfo := range p.unschedulableQ.volumeInfoMap {
+		unschedulableVolumes = append(unschedulableVolumes, qvInfo)
+	}
+	p.moveVolumesToActiveOrBackoffQueue(unschedulableVolumes, event)
+}
+
+// NOTE: this function assumes lock has been acquired in caller
+func (p *PriorityQueue) moveVolumesToActiveOrBackoffQueue(volumeInfoList []*framework.QueuedVolumeInfo,
+	event framework.ClusterEvent) {
+	moved := false
+	for _, qvInfo := range volumeInfoList {
+		// If the event doesn't help making the volume schedulable, continue.
+		// Note: we don't run the check if UnschedulablePlugins is nil or empty, which means
+		// the volume was rejected by something else than the Filter plugins, e.g. Reserve or
+		// Permit, and any event may help.
+		if len(qvInfo.UnschedulablePlugins) != 0 && !p.volumeMatchesEvent(qvInfo, event) {
+			continue
+		}
+		volume := qvInfo.Volume
+		if p.isVolumeBackingoff(qvInfo) {
+			if err := p.volumeBackoffQ.Add(qvInfo); err != nil {
+				klog.ErrorS(err, "Error adding volume to the backoff queue", "volume", klog.KObj(volume))
+			} else {
+				metrics.SchedulerQueueIncomingVolumes.WithLabelValues("backoff", event.Label).Inc()
+				p.unschedulableQ.delete(volume)
+			}
+		} else {
+			if err := p.activeQ.Add(qvInfo); err != nil {
+				klog.ErrorS(err, "Error adding volume to the scheduling queue", "volume", klog.KObj(volume))
+			} else {
+				moved = true
+				metrics.SchedulerQueueIncomingVolumes.WithLabelValues("active", event.Label).Inc()
+				p.unschedulableQ.delete(volume)
+			}
+		}
+	}
+	klog.V(5).InfoS("Moved unschedulable volumes", "event", event.Label, "count", len(volumeInfoList))
+	p.moveRequestCycle = p.schedulingCycle
+	if moved {
+		p.cond.Broadcast()
+	}
+}
+
+// recordPendingVolumes sets the pending volumes metrics to the length of each queue.
+// NOTE: this function assumes lock has been acquired in caller
+func (p *PriorityQueue) recordPendingVolumes() {
+	metrics.ActiveVolumes().Set(float64(p.activeQ.Len()))
+	metrics.BackoffVolumes().Set(float64(p.volumeBackoffQ.Len()))
+	metrics.UnschedulableVolumes().Set(float64(len(p.unschedulableQ.volumeInfoMap)))
+}
+
+// volumeMatchesEvent returns whether the event may make the volume schedulable, that is whether
+// one of the plugins which rejected the volume registered the event.
+func (p *PriorityQueue) volumeMatchesEvent(qvInfo *framework.QueuedVolumeInfo, clusterEvent framework.ClusterEvent) bool {
+	if clusterEvent.IsWildCard() {
+		return true
+	}
+
+	for evt, nameSet := range p.clusterEventMap {
+		// Firstly verify if the two ClusterEvents match:
+		// - either the registered event from plugin side is a WildCardEvent,
+		// - or the two events have identical Resource fields and *compatible* ActionType.
+		//   Note the ActionTypes don't need to be *identical*. We check if the ANDed value
+		//   is zero or not. In this way, it's easy to tell Update&Delete is not compatible,
+		//   but Update&All is.
+		evtMatch := evt.IsWildCard() ||
+			(evt.Resource == clusterEvent.Resource && evt.ActionType&clusterEvent.ActionType != 0)
+
+		// Secondly verify the plugin name matches.
+		if evtMatch && nameSet.HasAny(qvInfo.UnschedulablePlugins.UnsortedList()...) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// PendingVolumes returns all the pending volumes in the queue. This function is
+// used for debugging purposes in the scheduler cache dumper and comparer.
+func (p *PriorityQueue) PendingVolumes() []*scpv1alpha1.StorageVolume {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	var result []*scpv1alpha1.StorageVolume
+	for _, qvInfo := range p.activeQ.List() {
+		result = append(result, qvInfo.(*framework.QueuedVolumeInfo).Volume)
+	}
+	for _, qvInfo := range p.volumeBackoffQ.List() {
+		result = append(result, qvInfo.(*framework.QueuedVolumeInfo).Volume)
+	}
+	for _, qvInfo := range p.unschedulableQ.volumeInfoMap {
+		result = append(result, qvInfo.Volume)
+	}
+	return result
+}
+
+// Close closes the priority queue.
+func (p *PriorityQueue) Close() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	close(p.stop)
+	p.closed = true
+	p.cond.Broadcast()
+}
+
+// NumUnschedulableVolumes returns the number of unschedulable volumes exist in the
+// SchedulingQueue.
+func (p *PriorityQueue) NumUnschedulableVolumes() int {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return len(p.unschedulableQ.volumeInfoMap)
+}
+
+// newQueuedVolumeInfo builds a QueuedVolumeInfo object.
+func (p *PriorityQueue) newQueuedVolumeInfo(volume *scpv1alpha1.StorageVolume) *framework.QueuedVolumeInfo {
+	now := p.clock.Now()
+	return &framework.QueuedVolumeInfo{
+		VolumeInfo:              framework.NewVolumeInfo(volume),
+		Timestamp:               now,
+		InitialAttemptTimestamp: now,
+	}
+}
+
+// getBackoffTime returns the time that volumeInfo completes backoff
+func (p *PriorityQueue) getBackoffTime(volumeInfo *framework.QueuedVolumeInfo) time.Time {
+	duration := p.calculateBackoffDuration(volumeInfo)
+	backoffTime := volumeInfo.Timestamp.Add(duration)
+	return backoffTime
+}
+
+// calculateBackoffDuration is a helper function for calculating the backoffDuration
+// based on the number of attempts the volume has made.
+func (p *PriorityQueue) calculateBackoffDuration(volumeInfo *framework.QueuedVolumeInfo) time.Duration {
+	duration := p.volumeInitialBackoffDuration
+	for i := 1; i < volumeInfo.Attempts; i++ {
+		duration = duration * 2
+		if duration > p.volumeMaxBackoffDuration {
+			return p.volumeMaxBackoffDuration
+		}
+	}
+	return duration
+}
+
+func (p *PriorityQueue) volumesCompareBackoffCompleted(volumeInfo1, volumeInfo2 interface{}) bool {
+	qvInfo1 := volumeInfo1.(*framework.QueuedVolumeInfo)
+	qvInfo2 := volumeInfo2.(*framework.QueuedVolumeInfo)
+	bo1 := p.getBackoffTime(qvInfo1)
+	bo2 := p.getBackoffTime(qvInfo2)
+	return bo1.Before(bo2)
+}
+
+func updateVolume(oldQVInfo interface{}, newVolume *scpv1alpha1.StorageVolume) *framework.QueuedVolumeInfo {
+	qvInfo := oldQVInfo.(*framework.QueuedVolumeInfo)
+	qvInfo.VolumeInfo = framework.NewVolumeInfo(newVolume)
+	return qvInfo
+}
+
+// unschedulableVolumes holds volumes that cannot be scheduled. This data structure
+// is used to implement unschedulableQ.
+type unschedulableVolumes struct {
+	// volumeInfoMap is a map key by a volume's full-name and the value is a pointer to the
+	// QueuedVolumeInfo.
+	volumeInfoMap map[string]*framework.QueuedVolumeInfo
+}
+
+// Add adds a volume to the unschedulable volumeInfoMap.
+func (u *unschedulableVolumes) addOrUpdate(qvInfo *framework.QueuedVolumeInfo) {
+	u.volumeInfoMap[volumeKey(qvInfo.Volume)] = qvInfo
+}
+
+// Delete deletes a volume from the unschedulable volumeInfoMap.
+func (u *unschedulableVolumes) delete(volume *scpv1alpha1.StorageVolume) {
+	delete(u.volumeInfoMap, volumeKey(volume))
+}
+
+// Get returns the QueuedVolumeInfo if a volume with the same key as the key of the given
+// "volume" is found in the map. It returns nil otherwise.
+func (u *unschedulableVolumes) get(volume *scpv1alpha1.StorageVolume) *framework.QueuedVolumeInfo {
+	if qvInfo, exists := u.volumeInfoMap[volumeKey(volume)]; exists {
+		return qvInfo
+	}
+	return nil
+}
+
+// newUnschedulableVolumes initializes a new object of unschedulableVolumes.
+func newUnschedulableVolumes() *unschedulableVolumes {
+	return &unschedulableVolumes{
+		volumeInfoMap: make(map[string]*framework.QueuedVolumeInfo),
+	}
+}
+
+// volumeKey returns the namespace/name key of the volume.
+func volumeKey(volume *scpv1alpha1.StorageVolume) string {
+	return volume.Namespace + "/" + volume.Name
+}
+
+func volumeInfoKeyFunc(obj interface{}) (string, error) {
+	return volumeKey(obj.(*framework.QueuedVolumeInfo).Volume), nil
+}
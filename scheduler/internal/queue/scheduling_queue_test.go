@@ -0,0 +1,303 @@
+package queue
+
+import (
+	"testing"
+	"time"
+
+	scpv1alpha1 "github.com/openebs/device-localpv/pkg/apis/openebs.io/scp/v1alpha1"
+	"github.com/shovanmaity/volume-scheduler/framework"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	poolPlugin   = "pool-plugin"
+	cohortPlugin = "cohort-plugin"
+	anyPlugin    = "any-plugin"
+)
+
+func timestampLess(qvInfo1, qvInfo2 *framework.QueuedVolumeInfo) bool {
+	return qvInfo1.Timestamp.Before(qvInfo2.Timestamp)
+}
+
+func newTestQueue(c clock.Clock) *PriorityQueue {
+	return NewPriorityQueue(timestampLess, WithClock(c), WithClusterEventMap(
+		map[framework.ClusterEvent]sets.String{
+			PoolAdd:   sets.NewString(poolPlugin),
+			CohortAdd: sets.NewString(cohortPlugin),
+			{Resource: framework.WildCard, ActionType: framework.All}: sets.NewString(anyPlugin),
+		}))
+}
+
+func newTestVolume(name string) *scpv1alpha1.StorageVolume {
+	return &scpv1alpha1.StorageVolume{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name},
+	}
+}
+
+// addUnschedulable runs the volume through a failed scheduling attempt where the given
+// plugins rejected it.
+func addUnschedulable(t *testing.T, q *PriorityQueue, volume *scpv1alpha1.StorageVolume, plugins ...string) {
+	t.Helper()
+	if err := q.Add(volume); err != nil {
+		t.Fatal(err)
+	}
+	qvInfo, err := q.Pop()
+	if err != nil {
+		t.Fatal(err)
+	}
+	qvInfo.UnschedulablePlugins = sets.NewString(plugins...)
+	if err := q.AddUnschedulableIfNotPresent(qvInfo, q.SchedulingCycle()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// queueOf returns the sub-queue holding the volume.
+func queueOf(q *PriorityQueue, volume *scpv1alpha1.StorageVolume) string {
+	lookup := newQueuedVolumeInfoForLookup(volume)
+	if _, exists, _ := q.activeQ.Get(lookup); exists {
+		return "active"
+	}
+	if _, exists, _ := q.volumeBackoffQ.Get(lookup); exists {
+		return "backoff"
+	}
+	if q.unschedulableQ.get(volume) != nil {
+		return "unschedulable"
+	}
+	return ""
+}
+
+func TestMoveAllToActiveOrBackoffQueue(t *testing.T) {
+	tests := []struct {
+		name    string
+		plugins []string
+		event   framework.ClusterEvent
+		// backoffDone tells whether the backoff of the volume completed before the event.
+		backoffDone bool
+		want        string
+	}{
+		{
+			name:        "event registered by the rejecting plugin",
+			plugins:     []string{poolPlugin},
+			event:       PoolAdd,
+			backoffDone: true,
+			want:        "active",
+		},
+		{
+			name:    "event registered by the rejecting plugin during backoff",
+			plugins: []string{poolPlugin},
+			event:   PoolAdd,
+			want:    "backoff",
+		},
+		{
+			name:        "same resource, other action",
+			plugins:     []string{poolPlugin},
+			event:       PoolLabelChange,
+			backoffDone: true,
+			want:        "unschedulable",
+		},
+		{
+			name:        "event registered by another plugin",
+			plugins:     []string{poolPlugin},
+			event:       CohortAdd,
+			backoffDone: true,
+			want:        "unschedulable",
+		},
+		{
+			name:        "one of the rejecting plugins registered the event",
+			plugins:     []string{poolPlugin, cohortPlugin},
+			event:       CohortAdd,
+			backoffDone: true,
+			want:        "active",
+		},
+		{
+			name:        "plugin registered the wildcard event",
+			plugins:     []string{anyPlugin},
+			event:       PoolLabelChange,
+			backoffDone: true,
+			want:        "active",
+		},
+		{
+			name:        "rejected by no filter plugin",
+			event:       AssignedVolumeDelete,
+			backoffDone: true,
+			want:        "active",
+		},
+		{
+			name:        "unschedulable timeout",
+			plugins:     []string{poolPlugin},
+			event:       UnschedulableTimeout,
+			backoffDone: true,
+			want:        "active",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := clock.NewFakeClock(time.Now())
+			q := newTestQueue(c)
+			volume := newTestVolume("vol")
+			addUnschedulable(t, q, volume, tt.plugins...)
+			if got := queueOf(q, volume); got != "unschedulable" {
+				t.Fatalf("volume is in the %q queue, want unschedulable", got)
+			}
+			if tt.backoffDone {
+				c.Step(2 * DefaultVolumeInitialBackoffDuration)
+			}
+			q.MoveAllToActiveOrBackoffQueue(tt.event)
+			if got := queueOf(q, volume); got != tt.want {
+				t.Errorf("volume is in the %q queue, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddUnschedulableAfterMoveRequest(t *testing.T) {
+	q := newTestQueue(clock.NewFakeClock(time.Now()))
+	volume := newTestVolume("vol")
+	if err := q.Add(volume); err != nil {
+		t.Fatal(err)
+	}
+	qvInfo, err := q.Pop()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cycle := q.SchedulingCycle()
+
+	// An event arrived while the volume was being scheduled, so it may be schedulable already.
+	q.MoveAllToActiveOrBackoffQueue(PoolAdd)
+	qvInfo.UnschedulablePlugins = sets.NewString(cohortPlugin)
+	if err := q.AddUnschedulableIfNotPresent(qvInfo, cycle); err != nil {
+		t.Fatal(err)
+	}
+	if got := queueOf(q, volume); got != "backoff" {
+		t.Errorf("volume is in the %q queue, want backoff", got)
+	}
+}
+
+func TestAddUnschedulableNominated(t *testing.T) {
+	q := newTestQueue(clock.NewFakeClock(time.Now()))
+	nominated, other := newTestVolume("nominated"), newTestVolume("other")
+	for _, volume := range []*scpv1alpha1.StorageVolume{nominated, other} {
+		if err := q.Add(volume); err != nil {
+			t.Fatal(err)
+		}
+		qvInfo, err := q.Pop()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if volume == nominated {
+			qvInfo.NominatedPoolName = "ns/pool"
+		}
+		if err := q.AddUnschedulableIfNotPresent(qvInfo, q.SchedulingCycle()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The preemption made room for the nominated volume, it doesn't wait for an event.
+	if got := queueOf(q, nominated); got != "backoff" {
+		t.Errorf("nominated volume is in the %q queue, want backoff", got)
+	}
+	if got := queueOf(q, other); got != "unschedulable" {
+		t.Errorf("volume is in the %q queue, want unschedulable", got)
+	}
+}
+
+func TestBackoffDuration(t *testing.T) {
+	q := newTestQueue(clock.NewFakeClock(time.Now()))
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: time.Second},
+		{attempts: 2, want: 2 * time.Second},
+		{attempts: 4, want: 8 * time.Second},
+		{attempts: 5, want: DefaultVolumeMaxBackoffDuration},
+		{attempts: 20, want: DefaultVolumeMaxBackoffDuration},
+	}
+	for _, tt := range tests {
+		got := q.calculateBackoffDuration(&framework.QueuedVolumeInfo{Attempts: tt.attempts})
+		if got != tt.want {
+			t.Errorf("%d attempts: got backoff %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestFlushQueues(t *testing.T) {
+	c := clock.NewFakeClock(time.Now())
+	q := newTestQueue(c)
+	backingOff, leftover := newTestVolume("backing-off"), newTestVolume("leftover")
+	addUnschedulable(t, q, backingOff, poolPlugin)
+	addUnschedulable(t, q, leftover, cohortPlugin)
+	q.MoveAllToActiveOrBackoffQueue(PoolAdd)
+	if got := queueOf(q, backingOff); got != "backoff" {
+		t.Fatalf("volume is in the %q queue, want backoff", got)
+	}
+
+	q.flushBackoffQCompleted()
+	if got := queueOf(q, backingOff); got != "backoff" {
+		t.Errorf("volume left the backoff queue before its backoff completed: %q", got)
+	}
+	c.Step(2 * DefaultVolumeInitialBackoffDuration)
+	q.flushBackoffQCompleted()
+	if got := queueOf(q, backingOff); got != "active" {
+		t.Errorf("volume is in the %q queue after its backoff, want active", got)
+	}
+
+	q.flushUnschedulableQLeftover()
+	if got := queueOf(q, leftover); got != "unschedulable" {
+		t.Errorf("volume left the unschedulable queue before the timeout: %q", got)
+	}
+	c.Step(unschedulableQTimeInterval)
+	q.flushUnschedulableQLeftover()
+	if got := queueOf(q, leftover); got != "active" {
+		t.Errorf("volume is in the %q queue after the timeout, want active", got)
+	}
+}
+
+func TestUpdateUnschedulableVolume(t *testing.T) {
+	tests := []struct {
+		name   string
+		update func(volume *scpv1alpha1.StorageVolume)
+		want   string
+	}{
+		{
+			name: "status update",
+			update: func(volume *scpv1alpha1.StorageVolume) {
+				volume.ResourceVersion = "2"
+				volume.Status.Condition = append(volume.Status.Condition, scpv1alpha1.StorageVolumeCondition{
+					Type: "PoolScheduled",
+				})
+			},
+			want: "unschedulable",
+		},
+		{
+			name: "spec update",
+			update: func(volume *scpv1alpha1.StorageVolume) {
+				volume.Labels = map[string]string{"tier": "fast"}
+			},
+			want: "active",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := clock.NewFakeClock(time.Now())
+			q := newTestQueue(c)
+			oldVolume := newTestVolume("vol")
+			addUnschedulable(t, q, oldVolume, poolPlugin)
+			c.Step(2 * DefaultVolumeInitialBackoffDuration)
+
+			newVolume := oldVolume.DeepCopy()
+			tt.update(newVolume)
+			if err := q.Update(oldVolume, newVolume); err != nil {
+				t.Fatal(err)
+			}
+			if got := queueOf(q, newVolume); got != tt.want {
+				t.Errorf("volume is in the %q queue, want %q", got, tt.want)
+			}
+			if got := q.PendingVolumes(); len(got) != 1 || got[0] != newVolume {
+				t.Errorf("queue doesn't hold the updated volume: %v", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// histogramVecMetric is the data structure passed in the buffer channel between the main
+// framework thread and the metricsRecorder goroutine.
+type histogramVecMetric struct {
+	metric      *prometheus.HistogramVec
+	labelValues []string
+	value       float64
+}
+
+// MetricAsyncRecorder records metric in a separate goroutine to avoid overhead in the critical
+// path. Observations which don't fit in the buffer are dropped rather than blocking the caller.
+// A nil recorder records the observations synchronously instead.
+type MetricAsyncRecorder struct {
+	// bufferCh is a channel that serves as a metrics buffer before the metricsRecorder goroutine
+	// reports it.
+	bufferCh chan *histogramVecMetric
+	// if bufferSize is reached, incoming metrics will be discarded.
+	bufferSize int
+	// how often the recorder runs to flush the metrics.
+	interval time.Duration
+
+	// stopCh is used to stop the goroutine which periodically flushes metrics.
+	stopCh <-chan struct{}
+	// IsStoppedCh is closed once the goroutine flushed the remaining metrics and stopped.
+	IsStoppedCh chan struct{}
+}
+
+// NewMetricsAsyncRecorder returns a MetricAsyncRecorder flushing its buffer every interval. The
+// goroutine flushing the buffer runs until stopCh is closed, forever if stopCh is nil.
+func NewMetricsAsyncRecorder(bufferSize int, interval time.Duration, stopCh <-chan struct{}) *MetricAsyncRecorder {
+	recorder := &MetricAsyncRecorder{
+		bufferCh:    make(chan *histogramVecMetric, bufferSize),
+		bufferSize:  bufferSize,
+		interval:    interval,
+		stopCh:      stopCh,
+		IsStoppedCh: make(chan struct{}),
+	}
+	go recorder.run()
+	return recorder
+}
+
+// ObservePluginDurationAsync observes the plugin_execution_duration_seconds metric. The metric
+// will be flushed to Prometheus asynchronously.
+func (r *MetricAsyncRecorder) ObservePluginDurationAsync(extensionPoint, pluginName, status string, value float64) {
+	r.observeMetricAsync(PluginExecutionDuration, value, pluginName, extensionPoint, status)
+}
+
+// ObserveExtensionPointDurationAsync observes the framework_extension_point_duration_seconds
+// metric. The metric will be flushed to Prometheus asynchronously.
+func (r *MetricAsyncRecorder) ObserveExtensionPointDurationAsync(extensionPoint, status, profile string, value float64) {
+	r.observeMetricAsync(FrameworkExtensionPointDuration, value, extensionPoint, status, profile)
+}
+
+func (r *MetricAsyncRecorder) observeMetricAsync(m *prometheus.HistogramVec, value float64, labelsValues ...string) {
+	if r == nil {
+		m.WithLabelValues(labelsValues...).Observe(value)
+		return
+	}
+	newMetric := &histogramVecMetric{
+		metric:      m,
+		labelValues: labelsValues,
+		value:       value,
+	}
+	select {
+	case r.bufferCh <- newMetric:
+	default:
+	}
+}
+
+// run flushes buffered metrics into Prometheus every interval.
+func (r *MetricAsyncRecorder) run() {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.FlushMetrics()
+		case <-r.stopCh:
+			r.FlushMetrics()
+			close(r.IsStoppedCh)
+			return
+		}
+	}
+}
+
+// FlushMetrics tries to clean up the bufferCh by reading at most bufferSize metrics.
+func (r *MetricAsyncRecorder) FlushMetrics() {
+	for i := 0; i < r.bufferSize; i++ {
+		select {
+		case m := <-r.bufferCh:
+			m.metric.WithLabelValues(m.labelValues...).Observe(m.value)
+		default:
+			return
+		}
+	}
+}
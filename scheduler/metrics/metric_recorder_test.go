@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func newTestHistogramVec() *prometheus.HistogramVec {
+	return prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "test_duration_seconds"},
+		[]string{"label"})
+}
+
+func TestMetricAsyncRecorder(t *testing.T) {
+	h := newTestHistogramVec()
+	stopCh := make(chan struct{})
+	r := NewMetricsAsyncRecorder(10, time.Hour, stopCh)
+	r.observeMetricAsync(h, 1, "a")
+	r.observeMetricAsync(h, 1, "b")
+	if got := testutil.CollectAndCount(h); got != 0 {
+		t.Errorf("got %d series before the buffer is flushed, want 0", got)
+	}
+
+	// Stopping the recorder flushes the buffered observations.
+	close(stopCh)
+	<-r.IsStoppedCh
+	if got := testutil.CollectAndCount(h); got != 2 {
+		t.Errorf("got %d series after the recorder stopped, want 2", got)
+	}
+}
+
+func TestMetricAsyncRecorderDropsObservations(t *testing.T) {
+	h := newTestHistogramVec()
+	stopCh := make(chan struct{})
+	r := NewMetricsAsyncRecorder(1, time.Hour, stopCh)
+	r.observeMetricAsync(h, 1, "a")
+	r.observeMetricAsync(h, 1, "b")
+	close(stopCh)
+	<-r.IsStoppedCh
+	if got := testutil.CollectAndCount(h); got != 1 {
+		t.Errorf("got %d series, want 1", got)
+	}
+}
+
+func TestNilMetricAsyncRecorder(t *testing.T) {
+	h := newTestHistogramVec()
+	var r *MetricAsyncRecorder
+	r.observeMetricAsync(h, 1, "a")
+	if got := testutil.CollectAndCount(h); got != 1 {
+		t.Errorf("got %d series, want 1", got)
+	}
+}
@@ -0,0 +1,135 @@
+// Package metrics holds the Prometheus metrics of the scheduler.
+package metrics
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	// SchedulerSubsystem - subsystem name used by scheduler.
+	SchedulerSubsystem = "volume_scheduler"
+	// DefaultMetricsPath is the path the metrics are served on.
+	DefaultMetricsPath = "/metrics"
+)
+
+// Results of a scheduling attempt.
+const (
+	// ScheduledResult is used when the volume was scheduled.
+	ScheduledResult = "scheduled"
+	// UnschedulableResult is used when the volume didn't fit on any pool.
+	UnschedulableResult = "unschedulable"
+	// ErrorResult is used when the scheduling attempt failed with an error.
+	ErrorResult = "error"
+)
+
+var (
+	scheduleAttempts = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: SchedulerSubsystem,
+			Name:      "schedule_attempts_total",
+			Help:      "Number of attempts to schedule volumes, by the result. 'unschedulable' means a volume could not be scheduled, while 'error' means an internal scheduler problem.",
+		}, []string{"result", "profile"})
+
+	// FrameworkExtensionPointDuration is the latency of running all the plugins of an
+	// extension point.
+	FrameworkExtensionPointDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Subsystem: SchedulerSubsystem,
+			Name:      "framework_extension_point_duration_seconds",
+			Help:      "Latency for running all plugins of a specific extension point.",
+			// Start with 0.1ms with the last bucket being [~200ms, Inf)
+			Buckets: prometheus.ExponentialBuckets(0.0001, 2, 12),
+		}, []string{"extension_point", "status", "profile"})
+
+	// PluginExecutionDuration is the latency of running a plugin at an extension point.
+	PluginExecutionDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Subsystem: SchedulerSubsystem,
+			Name:      "plugin_execution_duration_seconds",
+			Help:      "Duration for running a plugin at a specific extension point.",
+			// Start with 0.01ms with the last bucket being [~22ms, Inf). We use a small factor
+			// (1.5) so that we have better granularity since plugin latency is very sensitive.
+			Buckets: prometheus.ExponentialBuckets(0.00001, 1.5, 20),
+		}, []string{"plugin", "extension_point", "status"})
+
+	// SchedulerQueueIncomingVolumes is the number of volumes added to the scheduling queues,
+	// by the event and the queue they were added to.
+	SchedulerQueueIncomingVolumes = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: SchedulerSubsystem,
+			Name:      "queue_incoming_volumes_total",
+			Help:      "Number of volumes added to scheduling queues by event and queue type.",
+		}, []string{"queue", "event"})
+
+	pendingVolumes = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Subsystem: SchedulerSubsystem,
+			Name:      "pending_volumes",
+			Help:      "Number of pending volumes, by the queue type. 'active' means number of volumes in activeQ; 'backoff' means number of volumes in backoffQ; 'unschedulable' means number of volumes in unschedulableQ.",
+		}, []string{"queue"})
+
+	registry = prometheus.NewRegistry()
+
+	metricsList = []prometheus.Collector{
+		scheduleAttempts,
+		FrameworkExtensionPointDuration,
+		PluginExecutionDuration,
+		SchedulerQueueIncomingVolumes,
+		pendingVolumes,
+	}
+)
+
+var registerMetrics sync.Once
+
+// Register all metrics.
+func Register() {
+	// Register the metrics.
+	registerMetrics.Do(func() {
+		registry.MustRegister(metricsList...)
+	})
+}
+
+// Handler returns the handler serving the registered metrics.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// ScheduledVolume records a successful scheduling attempt.
+func ScheduledVolume(profile string) {
+	scheduleAttempts.WithLabelValues(ScheduledResult, profile).Inc()
+}
+
+// UnschedulableVolume records a scheduling attempt for an unschedulable volume.
+func UnschedulableVolume(profile string) {
+	scheduleAttempts.WithLabelValues(UnschedulableResult, profile).Inc()
+}
+
+// VolumeScheduleError records a scheduling attempt that had an error.
+func VolumeScheduleError(profile string) {
+	scheduleAttempts.WithLabelValues(ErrorResult, profile).Inc()
+}
+
+// ActiveVolumes returns the pending volumes metric with the label active.
+func ActiveVolumes() prometheus.Gauge {
+	return pendingVolumes.WithLabelValues("active")
+}
+
+// BackoffVolumes returns the pending volumes metric with the label backoff.
+func BackoffVolumes() prometheus.Gauge {
+	return pendingVolumes.WithLabelValues("backoff")
+}
+
+// UnschedulableVolumes returns the pending volumes metric with the label unschedulable.
+func UnschedulableVolumes() prometheus.Gauge {
+	return pendingVolumes.WithLabelValues("unschedulable")
+}
+
+// SinceInSeconds gets the time since the specified start in seconds.
+func SinceInSeconds(start time.Time) float64 {
+	return time.Since(start).Seconds()
+}
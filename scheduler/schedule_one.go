@@ -0,0 +1,622 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	scpv1alpha1 "github.com/openebs/device-localpv/pkg/apis/openebs.io/scp/v1alpha1"
+	"github.com/shovanmaity/volume-scheduler/framework"
+	"github.com/shovanmaity/volume-scheduler/framework/parallelize"
+	frameworkruntime "github.com/shovanmaity/volume-scheduler/framework/runtime"
+	internalqueue "github.com/shovanmaity/volume-scheduler/scheduler/internal/queue"
+	"github.com/shovanmaity/volume-scheduler/scheduler/metrics"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// minFeasiblePoolsToFind is the minimum number of pools that would be scored in each
+	// scheduling cycle. This is a semi-arbitrary value to ensure that a certain minimum of pools
+	// are checked for feasibility. This in turn helps ensure a minimum level of spreading.
+	minFeasiblePoolsToFind = 100
+	// minFeasiblePoolsPercentageToFind is the minimum percentage of pools that would be scored
+	// in each scheduling cycle. This is a semi-arbitrary value to ensure that a certain minimum
+	// of pools are checked for feasibility. This in turn helps ensure a minimum level of
+	// spreading.
+	minFeasiblePoolsPercentageToFind = 5
+)
+
+// ErrNoPoolsAvailable is used to describe the error that no pools available to schedule volumes.
+var ErrNoPoolsAvailable = errors.New("no pools available to schedule volumes")
+
+// ScheduleResult represents the result of scheduling a volume.
+type ScheduleResult struct {
+	// SuggestedPool is the pool the volume was placed on.
+	SuggestedPool *scpv1alpha1.StoragePool
+	// EvaluatedPools is the number of pools the Filter plugins ran on.
+	EvaluatedPools int
+	// FeasiblePools is the number of pools which passed the Filter plugins.
+	FeasiblePools int
+}
+
+// Diagnosis records the details to diagnose a scheduling failure.
+type Diagnosis struct {
+	PoolToStatusMap      framework.PoolToStatusMap
+	UnschedulablePlugins sets.String
+}
+
+// FitError describes a fit error of a volume.
+type FitError struct {
+	Volume      *scpv1alpha1.StorageVolume
+	NumAllPools int
+	Diagnosis   Diagnosis
+	// NominatedPoolName is the key of the pool the PostFilter plugins nominated, the volume is
+	// expected to fit on it once the victims are evicted.
+	NominatedPoolName string
+}
+
+// Error returns detailed information of why the volume failed to fit on each pool.
+func (f *FitError) Error() string {
+	reasons := make(map[string]int)
+	for _, status := range f.Diagnosis.PoolToStatusMap {
+		for _, reason := range status.Reasons() {
+			reasons[reason]++
+		}
+	}
+
+	reasonStrings := make([]string, 0, len(reasons))
+	for k, v := range reasons {
+		reasonStrings = append(reasonStrings, fmt.Sprintf("%v %v", v, k))
+	}
+	sort.Strings(reasonStrings)
+	return fmt.Sprintf("0/%v pools are available: %v.", f.NumAllPools, strings.Join(reasonStrings, ", "))
+}
+
+// Reason returns the sorted, comma separated names of the plugins which rejected the volume on
+// at least one pool, or VolumeReasonUnschedulable if the statuses don't tell.
+func (f *FitError) Reason() string {
+	plugins := sets.NewString()
+	for _, status := range f.Diagnosis.PoolToStatusMap {
+		if name := status.PluginName(); len(name) != 0 {
+			plugins.Insert(name)
+		}
+	}
+	if plugins.Len() == 0 {
+		return VolumeReasonUnschedulable
+	}
+	return strings.Join(plugins.List(), ",")
+}
+
+// ScheduleOne does the entire scheduling workflow for a single volume. The scheduling cycle,
+// which selects a pool and runs the Reserve and Permit plugins, runs synchronously so that the
+// next volume is scheduled against the capacity assumed for this one. The binding cycle, which
+// waits on permit and runs the PreBind, Bind and PostBind plugins, runs in its own goroutine.
+// A volume which fails in either cycle is put back to the scheduling queue.
+func (sched *Scheduler) ScheduleOne(ctx context.Context, volumeInfo *framework.QueuedVolumeInfo) {
+	volume := volumeInfo.Volume
+	schedulingCycle := sched.SchedulingQueue.SchedulingCycle()
+	fwk, err := sched.frameworkForVolume(volume)
+	if err != nil {
+		sched.handleSchedulingFailure(ctx, volumeInfo, err, schedulingCycle)
+		return
+	}
+
+	klog.V(3).InfoS("Attempting to schedule volume", "volume", klog.KObj(volume))
+	state := framework.NewCycleState()
+	// Recording the plugin metrics of every cycle is expensive, decide once per cycle whether
+	// this one records them.
+	state.SetRecordPluginMetrics(rand.Intn(100) < pluginMetricsSamplePercent)
+	scheduleResult, assumedVolume, err := sched.schedulingCycle(ctx, fwk, state, volume)
+	if err != nil {
+		var fitError *FitError
+		if errors.Is(err, ErrNoPoolsAvailable) || errors.As(err, &fitError) {
+			metrics.UnschedulableVolume(fwk.ProfileName())
+		} else {
+			metrics.VolumeScheduleError(fwk.ProfileName())
+		}
+		sched.handleSchedulingFailure(ctx, volumeInfo, err, schedulingCycle)
+		return
+	}
+
+	// Bind the volume to its pool asynchronously, the next volume can be scheduled in the
+	// meantime as the cache already accounts for the assumed volume.
+	go func() {
+		if err := sched.bindingCycle(ctx, fwk, state, scheduleResult, assumedVolume); err != nil {
+			metrics.VolumeScheduleError(fwk.ProfileName())
+			sched.handleSchedulingFailure(ctx, volumeInfo, err, schedulingCycle)
+			return
+		}
+		metrics.ScheduledVolume(fwk.ProfileName())
+	}()
+}
+
+// schedulingCycle selects a pool for the volume, assumes the volume on it and runs the Reserve
+// and Permit plugins. It returns the assumed volume, which the binding cycle binds. Whenever the
+// volume fails after it was assumed, it is unreserved and forgotten before returning.
+func (sched *Scheduler) schedulingCycle(ctx context.Context, fwk *frameworkruntime.Framework,
+	state *framework.CycleState, volume *scpv1alpha1.StorageVolume) (
+	*ScheduleResult, *scpv1alpha1.StorageVolume, error) {
+	scheduleResult, err := sched.schedulePool(ctx, fwk, state, volume)
+	if err != nil {
+		var fitError *FitError
+		if errors.As(err, &fitError) {
+			result, status := fwk.RunPostFilterPlugins(ctx, state, volume,
+				fitError.Diagnosis.PoolToStatusMap)
+			if status.Code() == framework.Error {
+				klog.ErrorS(status.AsError(), "Status after running PostFilter plugins for volume",
+					"volume", klog.KObj(volume))
+			} else if status.IsSuccess() && result != nil {
+				// The scheduler only records the nomination, evicting the victims which are
+				// already bound is left to the PostFilter plugins and the volume owners.
+				klog.V(2).InfoS("Volume nominated to a pool", "volume", klog.KObj(volume),
+					"pool", result.NominatedPoolName, "victims", len(result.Victims))
+				fitError.NominatedPoolName = result.NominatedPoolName
+			}
+		}
+		return nil, nil, err
+	}
+
+	pool := framework.PoolReference(scheduleResult.SuggestedPool)
+	cohort := scheduleResult.SuggestedPool.Spec.StorageCohortReference
+
+	// Tell the cache to assume that a volume now is placed on a given pool, even though it's not
+	// bound yet. This allows us to keep scheduling without waiting on binding to occur.
+	assumedVolume := volume.DeepCopy()
+	if err := sched.assume(assumedVolume, pool, cohort); err != nil {
+		return nil, nil, err
+	}
+
+	// Run the Reserve method of reserve plugins.
+	if sts := fwk.RunReservePluginsReserve(ctx, state, assumedVolume, pool, cohort); !sts.IsSuccess() {
+		sched.unreserve(ctx, fwk, state, assumedVolume, pool, cohort)
+		return nil, nil, sts.AsError()
+	}
+
+	// Run "permit" plugins. A volume the plugins want to wait for is handed over to the binding
+	// cycle, which waits on it.
+	runPermitStatus := fwk.RunPermitPlugins(ctx, state, assumedVolume, pool, cohort)
+	if runPermitStatus.Code() != framework.Wait && !runPermitStatus.IsSuccess() {
+		sched.unreserve(ctx, fwk, state, assumedVolume, pool, cohort)
+		return nil, nil, runPermitStatus.AsError()
+	}
+	return scheduleResult, assumedVolume, nil
+}
+
+// bindingCycle waits on permit for the assumed volume and runs the PreBind, Bind and PostBind
+// plugins. Whenever the volume fails, it is unreserved and forgotten, and since the capacity it
+// held is released, the unschedulable volumes are moved back to be retried.
+func (sched *Scheduler) bindingCycle(ctx context.Context, fwk *frameworkruntime.Framework,
+	state *framework.CycleState, scheduleResult *ScheduleResult, assumedVolume *scpv1alpha1.StorageVolume) error {
+	pool := framework.PoolReference(scheduleResult.SuggestedPool)
+	cohort := scheduleResult.SuggestedPool.Spec.StorageCohortReference
+
+	// failed rolls back the assumed volume.
+	failed := func(err error) error {
+		if sched.unreserve(ctx, fwk, state, assumedVolume, pool, cohort) {
+			// Forgetting an assumed volume is treated as an assigned volume delete event, as the
+			// assumed volume held some capacity of the pool in the cache.
+			sched.SchedulingQueue.MoveAllToActiveOrBackoffQueue(internalqueue.AssignedVolumeDelete)
+		}
+		return err
+	}
+
+	waitOnPermitStatus := fwk.WaitOnPermit(ctx, assumedVolume)
+	if !waitOnPermitStatus.IsSuccess() {
+		return failed(waitOnPermitStatus.AsError())
+	}
+
+	// Run "prebind" plugins.
+	if sts := fwk.RunPreBindPlugins(ctx, state, assumedVolume, pool, cohort); !sts.IsSuccess() {
+		return failed(sts.AsError())
+	}
+
+	if err := sched.bind(ctx, fwk, state, assumedVolume, scheduleResult.SuggestedPool); err != nil {
+		return failed(err)
+	}
+	if err := sched.Cache.FinishBinding(assumedVolume); err != nil {
+		klog.ErrorS(err, "Scheduler cache FinishBinding failed")
+	}
+	klog.V(2).InfoS("Successfully bound volume to pool", "volume", klog.KObj(assumedVolume),
+		"pool", klog.KObj(scheduleResult.SuggestedPool), "evaluatedPools",
+		scheduleResult.EvaluatedPools, "feasiblePools", scheduleResult.FeasiblePools)
+	msg := fmt.Sprintf("Successfully assigned %v to pool %v", klog.KObj(assumedVolume),
+		klog.KObj(scheduleResult.SuggestedPool))
+	sched.recorder.Event(assumedVolume, corev1.EventTypeNormal, VolumeReasonScheduled, msg)
+	if err := sched.updateVolumeCondition(ctx, assumedVolume, corev1.ConditionTrue,
+		VolumeReasonScheduled, msg); err != nil {
+		klog.ErrorS(err, "Error updating volume condition", "volume", klog.KObj(assumedVolume))
+	}
+
+	// Run "postbind" plugins.
+	fwk.RunPostBindPlugins(ctx, state, assumedVolume, pool, cohort)
+	return nil
+}
+
+// unreserve cleans up the state associated with the assumed volume: it triggers un-reserve and
+// removes the assumed volume from the cache. It returns whether the volume was forgotten.
+func (sched *Scheduler) unreserve(ctx context.Context, fwk *frameworkruntime.Framework,
+	state *framework.CycleState, assumedVolume *scpv1alpha1.StorageVolume, pool, cohort *corev1.ObjectReference) bool {
+	fwk.RunReservePluginsUnreserve(ctx, state, assumedVolume, pool, cohort)
+	if err := sched.Cache.ForgetVolume(assumedVolume); err != nil {
+		klog.ErrorS(err, "Scheduler cache ForgetVolume failed")
+		return false
+	}
+	return true
+}
+
+// handleSchedulingFailure puts a volume which failed its scheduling or binding cycle back to the
+// scheduling queue. It is retried after a backoff or once an event that may make it schedulable
+// arrives. When the volume didn't fit, only the events of the plugins that rejected it count,
+// and a volume nominated to a pool by preemption is retried after its backoff. The failure is
+// recorded as a FailedScheduling event and in the PoolScheduled condition of the volume. When
+// the volume didn't fit, the message summarizes the reasons the pools were rejected for and the
+// reason names the plugins which rejected them. The api calls are made in their own goroutine so
+// that they don't hold up the scheduling loop.
+func (sched *Scheduler) handleSchedulingFailure(ctx context.Context, volumeInfo *framework.QueuedVolumeInfo,
+	err error, schedulingCycle int64) {
+	volume := volumeInfo.Volume
+	volumeInfo.UnschedulablePlugins = nil
+	volumeInfo.NominatedPoolName = ""
+	reason := VolumeReasonSchedulerError
+	var fitError *FitError
+	if errors.As(err, &fitError) {
+		volumeInfo.UnschedulablePlugins = fitError.Diagnosis.UnschedulablePlugins
+		volumeInfo.NominatedPoolName = fitError.NominatedPoolName
+		reason = fitError.Reason()
+	} else if errors.Is(err, ErrNoPoolsAvailable) {
+		reason = VolumeReasonUnschedulable
+	}
+	klog.V(2).InfoS("Unable to schedule volume, retrying", "volume", klog.KObj(volume), "err", err)
+	if err := sched.SchedulingQueue.AddUnschedulableIfNotPresent(volumeInfo, schedulingCycle); err != nil {
+		klog.ErrorS(err, "Error occurred while adding volume back to the scheduling queue",
+			"volume", klog.KObj(volume))
+	}
+
+	msg := err.Error()
+	go func() {
+		sched.recorder.Event(volume, corev1.EventTypeWarning, "FailedScheduling", msg)
+		if err := sched.updateVolumeCondition(ctx, volume, corev1.ConditionFalse, reason, msg); err != nil {
+			klog.ErrorS(err, "Error updating volume condition", "volume", klog.KObj(volume))
+		}
+	}()
+}
+
+// updateVolumeCondition sets the PoolScheduled condition in the status of the volume, unless the
+// volume already has the same condition. The latest version of the volume is updated, as the
+// binding may have changed it since it was scheduled. A volume which was deleted in the meantime
+// is left alone.
+func (sched *Scheduler) updateVolumeCondition(ctx context.Context, volume *scpv1alpha1.StorageVolume,
+	status corev1.ConditionStatus, reason, message string) error {
+	client := sched.client.ScpV1alpha1().StorageVolumes(volume.Namespace)
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		current, err := client.Get(ctx, volume.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if current.UID != volume.UID {
+			return nil
+		}
+		if !setVolumeCondition(current, status, reason, message) {
+			return nil
+		}
+		_, err = client.Update(ctx, current, metav1.UpdateOptions{})
+		return err
+	})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// setVolumeCondition adds or updates the PoolScheduled condition of the volume. The transition
+// time only changes with the status of the condition. It returns false if the volume already had
+// the same condition.
+func setVolumeCondition(volume *scpv1alpha1.StorageVolume, status corev1.ConditionStatus,
+	reason, message string) bool {
+	now := metav1.Now()
+	conditions := volume.Status.Condition
+	for i := range conditions {
+		c := &conditions[i]
+		if c.Type != VolumeConditionPoolScheduled {
+			continue
+		}
+		if c.Status == status && c.Reason == reason && c.Message == message {
+			return false
+		}
+		if c.Status != status {
+			c.LastTransitionTime = now
+		}
+		c.Status = status
+		c.Reason = reason
+		c.Message = message
+		c.LastUpdateTime = now
+		return true
+	}
+	volume.Status.Condition = append(conditions, scpv1alpha1.StorageVolumeCondition{
+		Type: VolumeConditionPoolScheduled,
+		Condition: scpv1alpha1.Condition{
+			Status:             status,
+			LastUpdateTime:     now,
+			LastTransitionTime: now,
+			Reason:             reason,
+			Message:            message,
+		},
+	})
+	return true
+}
+
+// assume signals to the cache that a volume is already in the cache, so that binding can be
+// asynchronous. assume modifies `assumed`.
+func (sched *Scheduler) assume(assumed *scpv1alpha1.StorageVolume, pool, cohort *corev1.ObjectReference) error {
+	// Optimistically assume that the binding will succeed. If the binding fails, the scheduler
+	// will release resources allocated to the assumed volume immediately.
+	assumed.Spec.StoragePoolReference = pool
+	assumed.Spec.StorageCohortReference = cohort
+
+	if err := sched.Cache.AssumeVolume(assumed); err != nil {
+		klog.ErrorS(err, "Scheduler cache AssumeVolume failed")
+		return err
+	}
+	return nil
+}
+
+// bind binds a volume to a given pool. The Bind plugins are expected to persist the decision, a
+// volume no Bind plugin handled is an error.
+func (sched *Scheduler) bind(ctx context.Context, fwk *frameworkruntime.Framework,
+	state *framework.CycleState, volume *scpv1alpha1.StorageVolume, pool *scpv1alpha1.StoragePool) error {
+	bindStatus := fwk.RunBindPlugins(ctx, state, volume, framework.PoolReference(pool),
+		pool.Spec.StorageCohortReference)
+	if bindStatus.IsSuccess() {
+		return nil
+	}
+	if bindStatus.Code() == framework.Error {
+		return bindStatus.AsError()
+	}
+	return fmt.Errorf("bind status: %s, %v", bindStatus.Code(), bindStatus.Message())
+}
+
+// schedulePool tries to schedule the given volume to one of the pools. If it succeeds, it will
+// return the chosen pool. If it fails, it will return a FitError with reasons.
+func (sched *Scheduler) schedulePool(ctx context.Context, fwk *frameworkruntime.Framework,
+	state *framework.CycleState, volume *scpv1alpha1.StorageVolume) (*ScheduleResult, error) {
+	if err := sched.Cache.UpdateSnapshot(sched.poolInfoSnapshot); err != nil {
+		return nil, err
+	}
+
+	allPools, err := sched.poolInfoSnapshot.List()
+	if err != nil {
+		return nil, err
+	}
+	if len(allPools) == 0 {
+		return nil, ErrNoPoolsAvailable
+	}
+
+	feasiblePools, diagnosis, err := sched.FindPoolsThatFit(ctx, fwk, state, volume, allPools)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(feasiblePools) == 0 {
+		return nil, &FitError{
+			Volume:      volume,
+			NumAllPools: len(allPools),
+			Diagnosis:   diagnosis,
+		}
+	}
+
+	// When only one pool after predicate, just use it.
+	if len(feasiblePools) == 1 {
+		return &ScheduleResult{
+			SuggestedPool:  feasiblePools[0],
+			EvaluatedPools: 1 + len(diagnosis.PoolToStatusMap),
+			FeasiblePools:  1,
+		}, nil
+	}
+
+	priorityList, err := prioritizePools(ctx, fwk, state, volume, feasiblePools)
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err := selectPool(priorityList, feasiblePools)
+	return &ScheduleResult{
+		SuggestedPool:  pool,
+		EvaluatedPools: len(feasiblePools) + len(diagnosis.PoolToStatusMap),
+		FeasiblePools:  len(feasiblePools),
+	}, err
+}
+
+// FindPoolsThatFit runs the PreFilter plugins and then the Filter plugins on the pools in
+// parallel. It returns the pools which passed all the filters together with the statuses of the
+// ones which did not. Not every pool is necessarily evaluated, see numFeasiblePoolsToFind.
+func (sched *Scheduler) FindPoolsThatFit(ctx context.Context, fwk *frameworkruntime.Framework,
+	state *framework.CycleState, volume *scpv1alpha1.StorageVolume, allPools []*framework.PoolInfo) (
+	[]*scpv1alpha1.StoragePool, Diagnosis, error) {
+	diagnosis := Diagnosis{
+		PoolToStatusMap:      make(framework.PoolToStatusMap),
+		UnschedulablePlugins: sets.NewString(),
+	}
+
+	// Run "prefilter" plugins.
+	s := fwk.RunPreFilterPlugins(ctx, state, volume)
+	if !s.IsSuccess() {
+		if !s.IsUnschedulable() {
+			return nil, diagnosis, s.AsError()
+		}
+		// All pools will have the same status. Some non trivial refactoring is needed to avoid
+		// this copy.
+		for _, p := range allPools {
+			diagnosis.PoolToStatusMap[framework.GetPoolKey(p.Pool)] = s
+		}
+		diagnosis.UnschedulablePlugins.Insert(s.PluginName())
+		return nil, diagnosis, nil
+	}
+
+	feasiblePools, err := sched.findPoolsThatPassFilters(ctx, fwk, state, volume, diagnosis, allPools)
+	if err != nil {
+		return nil, diagnosis, err
+	}
+	return feasiblePools, diagnosis, nil
+}
+
+// findPoolsThatPassFilters finds the pools that fit the filter plugins. It stops as soon as
+// numFeasiblePoolsToFind pools were found and starts where the previous call stopped.
+func (sched *Scheduler) findPoolsThatPassFilters(ctx context.Context, fwk *frameworkruntime.Framework,
+	state *framework.CycleState, volume *scpv1alpha1.StorageVolume, diagnosis Diagnosis,
+	pools []*framework.PoolInfo) ([]*scpv1alpha1.StoragePool, error) {
+	numPoolsToFind := sched.numFeasiblePoolsToFind(int32(len(pools)))
+
+	// Create feasible list with enough space to avoid growing it and allow assigning.
+	feasiblePools := make([]*scpv1alpha1.StoragePool, numPoolsToFind)
+
+	if !fwk.HasFilterPlugins() {
+		length := len(pools)
+		for i := range feasiblePools {
+			feasiblePools[i] = pools[(sched.nextStartPoolIndex+i)%length].Pool
+		}
+		sched.nextStartPoolIndex = (sched.nextStartPoolIndex + len(feasiblePools)) % length
+		sortPools(feasiblePools)
+		return feasiblePools, nil
+	}
+
+	errCh := parallelize.NewErrorChannel()
+	var statusesLock sync.Mutex
+	var feasiblePoolsLen int32
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	checkPool := func(i int) {
+		// We check the pools starting from where we left off in the previous scheduling cycle,
+		// this is to make sure all pools have the same chance of being examined across volumes.
+		poolInfo := pools[(sched.nextStartPoolIndex+i)%len(pools)]
+		status := fwk.RunFilterPlugins(ctx, state, volume, poolInfo).Merge()
+		if status.Code() == framework.Error {
+			errCh.SendErrorWithCancel(status.AsError(), cancel)
+			return
+		}
+		if status.IsSuccess() {
+			length := atomic.AddInt32(&feasiblePoolsLen, 1)
+			if length > numPoolsToFind {
+				cancel()
+				atomic.AddInt32(&feasiblePoolsLen, -1)
+			} else {
+				feasiblePools[length-1] = poolInfo.Pool
+			}
+		} else {
+			statusesLock.Lock()
+			diagnosis.PoolToStatusMap[framework.GetPoolKey(poolInfo.Pool)] = status
+			diagnosis.UnschedulablePlugins.Insert(status.PluginName())
+			statusesLock.Unlock()
+		}
+	}
+
+	// Stops searching for more pools once the configured number of feasible pools are found.
+	fwk.Parallelizer().UntilWithErrorChannel(ctx, len(pools), checkPool, errCh)
+	processedPools := int(feasiblePoolsLen) + len(diagnosis.PoolToStatusMap)
+	sched.nextStartPoolIndex = (sched.nextStartPoolIndex + processedPools) % len(pools)
+
+	feasiblePools = feasiblePools[:feasiblePoolsLen]
+	if err := errCh.ReceiveError(); err != nil {
+		return nil, err
+	}
+
+	// Pools are appended in the order their filters finished, sort them so that the outcome of
+	// the cycle doesn't depend on goroutine scheduling.
+	sortPools(feasiblePools)
+	return feasiblePools, nil
+}
+
+// numFeasiblePoolsToFind returns the number of feasible pools that once found, the scheduler
+// stops its search for more feasible pools.
+func (sched *Scheduler) numFeasiblePoolsToFind(numAllPools int32) (numPools int32) {
+	if numAllPools < minFeasiblePoolsToFind || sched.percentageOfPoolsToScore >= 100 {
+		return numAllPools
+	}
+
+	adaptivePercentage := sched.percentageOfPoolsToScore
+	if adaptivePercentage <= 0 {
+		basePercentageOfPoolsToScore := int32(50)
+		adaptivePercentage = basePercentageOfPoolsToScore - numAllPools/125
+		if adaptivePercentage < minFeasiblePoolsPercentageToFind {
+			adaptivePercentage = minFeasiblePoolsPercentageToFind
+		}
+	}
+
+	numPools = numAllPools * adaptivePercentage / 100
+	if numPools < minFeasiblePoolsToFind {
+		return minFeasiblePoolsToFind
+	}
+
+	return numPools
+}
+
+// prioritizePools runs the PreScore and Score plugins and returns the summed weighted score of
+// each pool. When no Score plugin is configured every pool gets the same score.
+func prioritizePools(ctx context.Context, fwk *frameworkruntime.Framework, state *framework.CycleState,
+	volume *scpv1alpha1.StorageVolume, pools []*scpv1alpha1.StoragePool) (framework.PoolScoreList, error) {
+	// Run PreScore plugins.
+	preScoreStatus := fwk.RunPreScorePlugins(ctx, state, volume, pools)
+	if !preScoreStatus.IsSuccess() {
+		return nil, preScoreStatus.AsError()
+	}
+
+	// Run the Score plugins.
+	scoresMap, scoreStatus := fwk.RunScorePlugins(ctx, state, volume, pools)
+	if !scoreStatus.IsSuccess() {
+		return nil, scoreStatus.AsError()
+	}
+
+	result := scoresMap.Sum()
+	if result == nil {
+		result = make(framework.PoolScoreList, 0, len(pools))
+		for _, pool := range pools {
+			result = append(result, framework.PoolScore{
+				Name:      pool.GetName(),
+				Namespace: pool.GetNamespace(),
+				Score:     1,
+			})
+		}
+	}
+
+	if klog.V(10).Enabled() {
+		for i := range result {
+			klog.InfoS("Calculated pool's final score for volume", "volume", klog.KObj(volume),
+				"pool", result[i].Name, "score", result[i].Score)
+		}
+	}
+	return result, nil
+}
+
+// selectPool takes a prioritized list of pools and then picks the one with the highest score.
+// Ties are broken by the namespace and name of the pools so that the same input always selects
+// the same pool. poolScoreList holds the pools in the same order as pools.
+func selectPool(poolScoreList framework.PoolScoreList, pools []*scpv1alpha1.StoragePool) (
+	*scpv1alpha1.StoragePool, error) {
+	if len(poolScoreList) == 0 {
+		return nil, fmt.Errorf("empty priorityList")
+	}
+	selected := 0
+	for i := 1; i < len(poolScoreList); i++ {
+		score, maxScore := poolScoreList[i].Score, poolScoreList[selected].Score
+		if score > maxScore || (score == maxScore && framework.GetPoolKey(pools[i]) < framework.GetPoolKey(pools[selected])) {
+			selected = i
+		}
+	}
+	return pools[selected], nil
+}
+
+// sortPools sorts the pools by namespace and name.
+func sortPools(pools []*scpv1alpha1.StoragePool) {
+	sort.Slice(pools, func(i, j int) bool {
+		return framework.GetPoolKey(pools[i]) < framework.GetPoolKey(pools[j])
+	})
+}
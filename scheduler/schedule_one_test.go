@@ -0,0 +1,215 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	scpv1alpha1 "github.com/openebs/device-localpv/pkg/apis/openebs.io/scp/v1alpha1"
+	"github.com/shovanmaity/volume-scheduler/apis/config"
+	"github.com/shovanmaity/volume-scheduler/framework"
+	"github.com/shovanmaity/volume-scheduler/framework/plugins/queuesort"
+	frameworkruntime "github.com/shovanmaity/volume-scheduler/framework/runtime"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const rejectPlugin = "test-reject"
+
+// rejectPoolsPlugin is a Filter plugin rejecting the given pools.
+type rejectPoolsPlugin struct {
+	pools sets.String
+}
+
+func (pl *rejectPoolsPlugin) Name() string { return rejectPlugin }
+
+func (pl *rejectPoolsPlugin) Filter(_ context.Context, _ *framework.CycleState, _ *scpv1alpha1.StorageVolume,
+	poolInfo *framework.PoolInfo) *framework.Status {
+	if pl.pools.Has(poolInfo.Pool.Name) {
+		return framework.NewStatus(framework.Unschedulable, "rejected")
+	}
+	return nil
+}
+
+// newTestFramework returns a framework running its filters one pool at a time, so that the
+// pools it evaluates are predictable. With a nil rejected set the profile has no Filter plugin.
+func newTestFramework(t *testing.T, rejected sets.String) *frameworkruntime.Framework {
+	t.Helper()
+	registry := frameworkruntime.Registry{
+		queuesort.Name: queuesort.New,
+		rejectPlugin: func(_ runtime.Object, _ framework.Handle) (framework.Plugin, error) {
+			return &rejectPoolsPlugin{pools: rejected}, nil
+		},
+	}
+	profile := &config.VolumeSchedulerProfile{
+		SchedulerName: "test",
+		Plugins: &config.Plugins{
+			QueueSort: config.PluginSet{Enabled: []config.Plugin{{Name: queuesort.Name}}},
+		},
+	}
+	if rejected != nil {
+		profile.Plugins.Filter = config.PluginSet{Enabled: []config.Plugin{{Name: rejectPlugin}}}
+	}
+	fwk, err := frameworkruntime.NewFramework(registry, profile, frameworkruntime.WithParallelism(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fwk
+}
+
+func makePoolInfos(n int) []*framework.PoolInfo {
+	var poolInfos []*framework.PoolInfo
+	for i := 0; i < n; i++ {
+		poolInfos = append(poolInfos, &framework.PoolInfo{Pool: &scpv1alpha1.StoragePool{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: poolName(i)},
+		}})
+	}
+	return poolInfos
+}
+
+func poolName(i int) string {
+	return fmt.Sprintf("pool-%03d", i)
+}
+
+// poolNames returns the names of the pools with an index in [from, to).
+func poolNames(from, to int) []string {
+	var names []string
+	for i := from; i < to; i++ {
+		names = append(names, poolName(i))
+	}
+	return names
+}
+
+func TestNumFeasiblePoolsToFind(t *testing.T) {
+	tests := []struct {
+		name                     string
+		percentageOfPoolsToScore int32
+		numAllPools              int32
+		want                     int32
+	}{
+		{
+			name:        "not set percentageOfPoolsToScore and pools number not more than 100",
+			numAllPools: 10,
+			want:        10,
+		},
+		{
+			name:                     "set percentageOfPoolsToScore and pools number not more than 100",
+			percentageOfPoolsToScore: 40,
+			numAllPools:              99,
+			want:                     99,
+		},
+		{
+			name:        "not set percentageOfPoolsToScore and the adaptive number is below the minimum",
+			numAllPools: 200,
+			want:        100,
+		},
+		{
+			name:        "not set percentageOfPoolsToScore and pools number more than 100",
+			numAllPools: 1000,
+			want:        420,
+		},
+		{
+			name:        "not set percentageOfPoolsToScore and the adaptive percentage is below 5",
+			numAllPools: 6000,
+			want:        300,
+		},
+		{
+			name:                     "set percentageOfPoolsToScore and pools number more than 100",
+			percentageOfPoolsToScore: 40,
+			numAllPools:              1000,
+			want:                     400,
+		},
+		{
+			name:                     "set percentageOfPoolsToScore below the minimum number of pools",
+			percentageOfPoolsToScore: 5,
+			numAllPools:              1000,
+			want:                     100,
+		},
+		{
+			name:                     "set percentageOfPoolsToScore to 100",
+			percentageOfPoolsToScore: 100,
+			numAllPools:              1000,
+			want:                     1000,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sched := &Scheduler{percentageOfPoolsToScore: tt.percentageOfPoolsToScore}
+			if got := sched.numFeasiblePoolsToFind(tt.numAllPools); got != tt.want {
+				t.Errorf("numFeasiblePoolsToFind(%d) = %d, want %d", tt.numAllPools, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindPoolsThatPassFiltersRotation(t *testing.T) {
+	tests := []struct {
+		name     string
+		numPools int
+		// rejected is the set of pools the filter rejects, nil for a profile without filters.
+		rejected sets.String
+		// wantPools and wantNextStart are the feasible pools and the next start index after
+		// each call.
+		wantPools     [][]string
+		wantNextStart []int
+	}{
+		{
+			name:          "every pool fits",
+			numPools:      200,
+			rejected:      sets.NewString(),
+			wantPools:     [][]string{poolNames(0, 100), poolNames(100, 200), poolNames(0, 100)},
+			wantNextStart: []int{100, 0, 100},
+		},
+		{
+			name:          "rejected pools count as processed",
+			numPools:      200,
+			rejected:      sets.NewString(poolNames(0, 10)...),
+			wantPools:     [][]string{poolNames(10, 110), append(poolNames(110, 200), poolNames(10, 20)...)},
+			wantNextStart: []int{110, 20},
+		},
+		{
+			name:          "no filter plugins",
+			numPools:      200,
+			wantPools:     [][]string{poolNames(0, 100), poolNames(100, 200)},
+			wantNextStart: []int{100, 0},
+		},
+		{
+			name:          "fewer pools than the minimum to find",
+			numPools:      50,
+			rejected:      sets.NewString(poolName(3)),
+			wantPools:     [][]string{append(poolNames(0, 3), poolNames(4, 50)...)},
+			wantNextStart: []int{0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fwk := newTestFramework(t, tt.rejected)
+			pools := makePoolInfos(tt.numPools)
+			sched := &Scheduler{}
+			for i := range tt.wantPools {
+				diagnosis := Diagnosis{
+					PoolToStatusMap:      make(framework.PoolToStatusMap),
+					UnschedulablePlugins: sets.NewString(),
+				}
+				feasible, err := sched.findPoolsThatPassFilters(context.Background(), fwk,
+					framework.NewCycleState(), &scpv1alpha1.StorageVolume{}, diagnosis, pools)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var got []string
+				for _, p := range feasible {
+					got = append(got, p.Name)
+				}
+				if diff := cmp.Diff(sets.NewString(tt.wantPools[i]...).List(), got); diff != "" {
+					t.Errorf("call %d: unexpected feasible pools (-want, +got): %s", i, diff)
+				}
+				if sched.nextStartPoolIndex != tt.wantNextStart[i] {
+					t.Errorf("call %d: got next start index %d, want %d", i, sched.nextStartPoolIndex,
+						tt.wantNextStart[i])
+				}
+			}
+		})
+	}
+}
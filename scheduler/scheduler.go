@@ -0,0 +1,217 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	scpv1alpha1 "github.com/openebs/device-localpv/pkg/apis/openebs.io/scp/v1alpha1"
+	clientset "github.com/openebs/device-localpv/pkg/generated/clientset/scp/internalclientset"
+	informers "github.com/openebs/device-localpv/pkg/generated/informer/scp/externalversions"
+	"github.com/shovanmaity/volume-scheduler/apis/config"
+	"github.com/shovanmaity/volume-scheduler/framework"
+	frameworkruntime "github.com/shovanmaity/volume-scheduler/framework/runtime"
+	"github.com/shovanmaity/volume-scheduler/profile"
+	internalcache "github.com/shovanmaity/volume-scheduler/scheduler/internal/cache"
+	internalqueue "github.com/shovanmaity/volume-scheduler/scheduler/internal/queue"
+	"github.com/shovanmaity/volume-scheduler/scheduler/metrics"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+)
+
+// SchedulerNameAnnotation is the annotation a StorageVolume uses to choose the profile it is
+// scheduled with. Volumes without it are scheduled with the default profile.
+const SchedulerNameAnnotation = "volume-scheduler.openebs.io/scheduler-name"
+
+// Condition of a StorageVolume telling whether it was placed on a pool, and its reasons.
+const (
+	// VolumeConditionPoolScheduled is the type of the condition set in the status of the volumes
+	// the scheduler attempted to schedule.
+	VolumeConditionPoolScheduled scpv1alpha1.StorageVolumeConditionType = "PoolScheduled"
+	// VolumeReasonScheduled is used when the volume was bound to a pool.
+	VolumeReasonScheduled = "Scheduled"
+	// VolumeReasonUnschedulable is used when the volume didn't fit on any pool and no plugin
+	// rejected it, i.e. when there are no pools. Otherwise the reason lists the plugins which
+	// rejected the volume.
+	VolumeReasonUnschedulable = "Unschedulable"
+	// VolumeReasonSchedulerError is used when the scheduling attempt failed with an error.
+	VolumeReasonSchedulerError = "SchedulerError"
+)
+
+const (
+	// Duration the scheduler will wait before expiring an assumed volume.
+	durationToExpireAssumedVolume = 15 * time.Minute
+	// Percentage of the scheduling cycles which record the plugin metrics.
+	pluginMetricsSamplePercent = 10
+	// Number of plugin metrics buffered before they are flushed, extra ones are dropped.
+	metricsBufferSize = 1000
+	// Interval the buffered plugin metrics are flushed at.
+	metricsFlushInterval = time.Second
+)
+
+// Scheduler watches for new unscheduled volumes. It attempts to find pools that they fit on and
+// writes bindings back to the api server.
+type Scheduler struct {
+	// Profiles are the scheduling profiles.
+	Profiles profile.Map
+
+	// client updates the status of the volumes.
+	client clientset.Interface
+
+	// recorder records the scheduling events of the volumes.
+	recorder record.EventRecorder
+
+	// It is expected that changes made via Cache will be observed by poolInfoSnapshot at the
+	// beginning of every scheduling cycle.
+	Cache internalcache.Cache
+
+	// poolInfoSnapshot is the snapshot of the cache the current scheduling cycle runs on.
+	poolInfoSnapshot *internalcache.Snapshot
+
+	// SchedulingQueue holds volumes to be scheduled.
+	SchedulingQueue internalqueue.SchedulingQueue
+
+	percentageOfPoolsToScore int32
+
+	// metricsBindAddress is the address the metrics are served on, empty when they are not
+	// served.
+	metricsBindAddress string
+
+	// nextStartPoolIndex is the index of the pool the next filtering pass starts at, so that
+	// every pool gets its turn when not all of them are evaluated.
+	nextStartPoolIndex int
+}
+
+// New returns a Scheduler running the profiles of the given configuration, built from the
+// plugins of the registry. The scheduler and the plugins use client to talk to the api server.
+// The scheduler is fed by the informers of informerFactory, which the caller starts, and records
+// the scheduling events of the volumes with recorder, whose scheme must know StorageVolume.
+// stopCh stops the background routines of the scheduler cache.
+func New(client clientset.Interface, informerFactory informers.SharedInformerFactory,
+	recorder record.EventRecorder, cfg *config.VolumeSchedulerConfiguration,
+	registry frameworkruntime.Registry, stopCh <-chan struct{}) (*Scheduler, error) {
+	metrics.Register()
+	schedulerCache := internalcache.New(durationToExpireAssumedVolume, stopCh)
+
+	// Profiles are built on the snapshot, so that plugins see the pools of the cycle they
+	// run in.
+	snapshot := internalcache.NewEmptySnapshot()
+	clusterEventMap := make(map[framework.ClusterEvent]sets.String)
+	metricsRecorder := metrics.NewMetricsAsyncRecorder(metricsBufferSize, metricsFlushInterval, stopCh)
+	profiles, err := profile.NewMap(cfg, registry,
+		frameworkruntime.WithMetricsRecorder(metricsRecorder),
+		frameworkruntime.WithClientSet(client),
+		frameworkruntime.WithSnapshotPoolInfoLister(snapshot),
+		frameworkruntime.WithClusterEventMap(clusterEventMap))
+	if err != nil {
+		return nil, fmt.Errorf("initializing profiles: %w", err)
+	}
+	if len(profiles) == 0 {
+		return nil, errors.New("at least one profile is required")
+	}
+
+	// Profiles are required to have equivalent queue sort plugins.
+	var lessFn framework.LessFunc
+	for _, fwk := range profiles {
+		lessFn = fwk.QueueSortFunc()
+		break
+	}
+
+	sched := &Scheduler{
+		Profiles:                 profiles,
+		client:                   client,
+		recorder:                 recorder,
+		Cache:                    schedulerCache,
+		poolInfoSnapshot:         snapshot,
+		SchedulingQueue:          internalqueue.NewSchedulingQueue(lessFn, internalqueue.WithClusterEventMap(clusterEventMap)),
+		percentageOfPoolsToScore: cfg.PercentageOfPoolsToScore,
+		metricsBindAddress:       cfg.MetricsBindAddress,
+	}
+	addAllEventHandlers(sched, informerFactory)
+	return sched, nil
+}
+
+// Run begins scheduling the volumes of the scheduling queue and serves the metrics. It blocks
+// until the context is done.
+func (sched *Scheduler) Run(ctx context.Context) {
+	if len(sched.metricsBindAddress) != 0 {
+		go sched.serveMetrics(ctx)
+	}
+	sched.SchedulingQueue.Run()
+	go wait.UntilWithContext(ctx, sched.scheduleNext, 0)
+	<-ctx.Done()
+	sched.SchedulingQueue.Close()
+}
+
+// serveMetrics serves the metrics on metricsBindAddress until the context is done.
+func (sched *Scheduler) serveMetrics(ctx context.Context) {
+	mux := http.NewServeMux()
+	mux.Handle(metrics.DefaultMetricsPath, metrics.Handler())
+	server := &http.Server{Addr: sched.metricsBindAddress, Handler: mux}
+	go func() {
+		<-ctx.Done()
+		if err := server.Close(); err != nil {
+			klog.ErrorS(err, "Failed to close the metrics server")
+		}
+	}()
+	klog.InfoS("Serving metrics", "address", sched.metricsBindAddress, "path", metrics.DefaultMetricsPath)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		klog.ErrorS(err, "Failed to serve metrics", "address", sched.metricsBindAddress)
+	}
+}
+
+// scheduleNext pops the next volume from the scheduling queue and schedules it. It returns as
+// soon as the scheduling cycle of the volume is done, the volume is bound asynchronously.
+func (sched *Scheduler) scheduleNext(ctx context.Context) {
+	volumeInfo, err := sched.SchedulingQueue.Pop()
+	if err != nil {
+		klog.V(3).InfoS("Stopped popping volumes", "err", err)
+		return
+	}
+	if sched.skipVolumeSchedule(volumeInfo.Volume) {
+		return
+	}
+	sched.ScheduleOne(ctx, volumeInfo)
+}
+
+// skipVolumeSchedule returns true if we could skip scheduling the volume for specified cases.
+func (sched *Scheduler) skipVolumeSchedule(volume *scpv1alpha1.StorageVolume) bool {
+	// Case 1: volume is being deleted.
+	if volume.DeletionTimestamp != nil {
+		klog.V(3).InfoS("Skip schedule deleting volume", "volume", klog.KObj(volume))
+		return true
+	}
+
+	// Case 2: volume that has been assumed could be skipped. An assumed volume can be added
+	// again to the scheduling queue if it got an update event during its previous scheduling
+	// cycle but before getting assumed.
+	isAssumed, err := sched.Cache.IsAssumedVolume(volume)
+	if err != nil {
+		klog.ErrorS(err, "Failed to check whether volume is assumed", "volume", klog.KObj(volume))
+		return false
+	}
+	return isAssumed
+}
+
+// frameworkForVolume returns the framework of the profile the volume asks for.
+func (sched *Scheduler) frameworkForVolume(volume *scpv1alpha1.StorageVolume) (
+	*frameworkruntime.Framework, error) {
+	name := profileNameForVolume(volume)
+	fwk, ok := sched.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("profile not found for scheduler name %q", name)
+	}
+	return fwk, nil
+}
+
+// profileNameForVolume returns the name of the profile the volume asks for.
+func profileNameForVolume(volume *scpv1alpha1.StorageVolume) string {
+	if name := volume.GetAnnotations()[SchedulerNameAnnotation]; name != "" {
+		return name
+	}
+	return config.DefaultSchedulerName
+}